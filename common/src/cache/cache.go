@@ -0,0 +1,41 @@
+/*
+ *  cache.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMiss is returned by Get, if the key is not cached or expired.
+var ErrMiss = errors.New("cache miss")
+
+// Cache stores values by key for the TTL of the cache. All errors besides ErrMiss are specific to the backend, a
+// cache in front of a database should treat them like a miss.
+type Cache interface {
+	// Get returns the value of the key or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set stores the value of the key, replacing the value before.
+	Set(ctx context.Context, key string, value []byte) error
+	// Delete removes the keys, missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+	// Close releases the connections of the cache.
+	Close() error
+}
+
+// New returns the cache of the configured backend, nil for the backend 'none'.
+func New(config Config) (Cache, error) {
+
+	switch config.Backend {
+	case BackendMemory:
+		return NewLRU(config.Size, config.TTL), nil
+	case BackendRedis:
+		return NewRedis(config)
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,81 @@
+/*
+ *  config.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package cache
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+const (
+	BackendNone   = "none"
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// Config contains the properties of the cache. The memory backend is local to an instance, so the instances of a
+// service only see the writes of each other after the TTL. Redis is shared by all instances.
+type Config struct {
+	Backend       string
+	TTL           time.Duration
+	Size          int
+	RedisAddr     string
+	RedisPassword string
+	RedisDB       int
+	RedisPrefix   string
+	RedisTimeout  time.Duration
+	LoadTimeout   time.Duration
+}
+
+// BindConfig takes a Config and a FlagSet and stores the cache-relevant flags in the corresponding config fields.
+func BindConfig(config *Config, fs *flag.FlagSet, defaultPrefix string) {
+	fs.StringVar(&config.Backend, "cache.backend", BackendNone, "cache backend, either 'none', 'memory' or 'redis'")
+	fs.DurationVar(&config.TTL, "cache.ttl", time.Minute, "time an entry is cached")
+	fs.IntVar(&config.Size, "cache.size", 10000, "maximum number of entries of the memory cache, the least recently used are evicted")
+	fs.StringVar(&config.RedisAddr, "cache.redis.addr", "localhost:6379", "the redis address host:port")
+	fs.StringVar(&config.RedisPassword, "cache.redis.password", "", "the redis password")
+	fs.IntVar(&config.RedisDB, "cache.redis.db", 0, "the redis database")
+	fs.StringVar(&config.RedisPrefix, "cache.redis.prefix", defaultPrefix+":", "prefix of all redis keys")
+	fs.DurationVar(&config.RedisTimeout, "cache.redis.timeout", 100*time.Millisecond, "timeout of the redis commands, a slow cache is treated as a miss")
+	fs.DurationVar(&config.LoadTimeout, "cache.loadTimeout", 5*time.Second, "timeout of the query loading a missing entry, which is shared by all concurrent lookups of the entry")
+}
+
+// IsValid returns true, if the backend is known and its properties are set.
+func (config *Config) IsValid() bool {
+
+	if config.Backend != BackendNone && config.Backend != BackendMemory && config.Backend != BackendRedis {
+		fmt.Println("cache backend must either be 'none', 'memory' or 'redis'")
+		return false
+	}
+
+	if config.Backend == BackendNone {
+		return true
+	}
+
+	if config.TTL <= 0 {
+		fmt.Println("cache ttl must be positive")
+		return false
+	}
+
+	if config.LoadTimeout <= 0 {
+		fmt.Println("cache load timeout must be positive")
+		return false
+	}
+
+	if config.Backend == BackendMemory && config.Size < 1 {
+		fmt.Println("cache size must be positive")
+		return false
+	}
+
+	if config.Backend == BackendRedis && config.RedisAddr == "" {
+		fmt.Println("please specify a redis address")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,106 @@
+/*
+ *  config_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package cache
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindConfig(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args     []string
+		expected Config
+	}{
+		"defaults": {
+			args: []string{},
+			expected: Config{
+				Backend:      BackendNone,
+				TTL:          time.Minute,
+				Size:         10000,
+				RedisAddr:    "localhost:6379",
+				RedisPrefix:  "test:",
+				RedisTimeout: 100 * time.Millisecond,
+				LoadTimeout:  5 * time.Second,
+			},
+		},
+		"everything set": {
+			args: []string{
+				"--cache.backend", "redis",
+				"--cache.ttl", "5m",
+				"--cache.size", "100",
+				"--cache.redis.addr", "redis:6380",
+				"--cache.redis.password", "secret",
+				"--cache.redis.db", "2",
+				"--cache.redis.prefix", "other:",
+				"--cache.redis.timeout", "1s",
+				"--cache.loadTimeout", "2s",
+			},
+			expected: Config{
+				Backend:       BackendRedis,
+				TTL:           5 * time.Minute,
+				Size:          100,
+				RedisAddr:     "redis:6380",
+				RedisPassword: "secret",
+				RedisDB:       2,
+				RedisPrefix:   "other:",
+				RedisTimeout:  time.Second,
+				LoadTimeout:   2 * time.Second,
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			cfg := Config{}
+
+			BindConfig(&cfg, fs, "test")
+
+			err := fs.Parse(tc.args)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg)
+		})
+	}
+}
+
+func TestConfig_IsValid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		cfg     Config
+		isValid bool
+	}{
+		"none":                        {cfg: Config{Backend: BackendNone}, isValid: true},
+		"memory":                      {cfg: Config{Backend: BackendMemory, TTL: time.Minute, Size: 1, LoadTimeout: time.Second}, isValid: true},
+		"redis":                       {cfg: Config{Backend: BackendRedis, TTL: time.Minute, RedisAddr: "localhost:6379", LoadTimeout: time.Second}, isValid: true},
+		"unknown backend":             {cfg: Config{Backend: "memcached", TTL: time.Minute, Size: 1, LoadTimeout: time.Second}, isValid: false},
+		"ttl not positive":            {cfg: Config{Backend: BackendMemory, Size: 1, LoadTimeout: time.Second}, isValid: false},
+		"load timeout not positive":   {cfg: Config{Backend: BackendMemory, TTL: time.Minute, Size: 1}, isValid: false},
+		"size not positive":           {cfg: Config{Backend: BackendMemory, TTL: time.Minute, LoadTimeout: time.Second}, isValid: false},
+		"size is ignored by redis":    {cfg: Config{Backend: BackendRedis, TTL: time.Minute, RedisAddr: "localhost:6379", LoadTimeout: time.Second}, isValid: true},
+		"redis address missing":       {cfg: Config{Backend: BackendRedis, TTL: time.Minute, LoadTimeout: time.Second}, isValid: false},
+		"none ignores the properties": {cfg: Config{Backend: BackendNone, TTL: -1}, isValid: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.isValid, tc.cfg.IsValid())
+		})
+	}
+}
@@ -0,0 +1,121 @@
+/*
+ *  lru.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRU is an in-process cache of up to size entries. An entry expires after the TTL, if the cache is full the least
+// recently used entry is evicted.
+type LRU struct {
+	mutex   sync.Mutex
+	size    int
+	ttl     time.Duration
+	entries map[string]*list.Element
+	// the most recently used entry is at the front
+	order *list.List
+	now   func() time.Time
+}
+
+type lruEntry struct {
+	key     string
+	value   []byte
+	expires time.Time
+}
+
+func NewLRU(size int, ttl time.Duration) *LRU {
+	return &LRU{
+		size:    size,
+		ttl:     ttl,
+		entries: map[string]*list.Element{},
+		order:   list.New(),
+		now:     time.Now,
+	}
+}
+
+func (lru *LRU) Get(ctx context.Context, key string) ([]byte, error) {
+
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	element, ok := lru.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !lru.now().Before(entry.expires) {
+		lru.remove(element)
+		return nil, ErrMiss
+	}
+
+	lru.order.MoveToFront(element)
+
+	return copyBytes(entry.value), nil
+}
+
+func (lru *LRU) Set(ctx context.Context, key string, value []byte) error {
+
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	entry := &lruEntry{key: key, value: copyBytes(value), expires: lru.now().Add(lru.ttl)}
+
+	if element, ok := lru.entries[key]; ok {
+		element.Value = entry
+		lru.order.MoveToFront(element)
+		return nil
+	}
+
+	lru.entries[key] = lru.order.PushFront(entry)
+	for lru.order.Len() > lru.size {
+		lru.remove(lru.order.Back())
+	}
+
+	return nil
+}
+
+func (lru *LRU) Delete(ctx context.Context, keys ...string) error {
+
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	for _, key := range keys {
+		if element, ok := lru.entries[key]; ok {
+			lru.remove(element)
+		}
+	}
+
+	return nil
+}
+
+func (lru *LRU) Close() error {
+	return nil
+}
+
+// Len returns the number of entries, including the expired ones not evicted yet.
+func (lru *LRU) Len() int {
+
+	lru.mutex.Lock()
+	defer lru.mutex.Unlock()
+
+	return lru.order.Len()
+}
+
+func (lru *LRU) remove(element *list.Element) {
+	lru.order.Remove(element)
+	delete(lru.entries, element.Value.(*lruEntry).key)
+}
+
+// copyBytes keeps the cached values apart from the slices of the callers
+func copyBytes(value []byte) []byte {
+	return append([]byte(nil), value...)
+}
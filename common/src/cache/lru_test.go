@@ -0,0 +1,116 @@
+/*
+ *  lru_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+
+	cases := map[string]struct {
+		steps    func(lru *LRU, advance func(time.Duration))
+		missing  []string
+		expected map[string]string
+	}{
+		"get returns the value": {
+			steps: func(lru *LRU, advance func(time.Duration)) {
+				lru.Set(ctx, "a", []byte("1"))
+			},
+			expected: map[string]string{"a": "1"},
+		},
+		"set replaces the value": {
+			steps: func(lru *LRU, advance func(time.Duration)) {
+				lru.Set(ctx, "a", []byte("1"))
+				lru.Set(ctx, "a", []byte("2"))
+			},
+			expected: map[string]string{"a": "2"},
+		},
+		"least recently used is evicted": {
+			steps: func(lru *LRU, advance func(time.Duration)) {
+				lru.Set(ctx, "a", []byte("1"))
+				lru.Set(ctx, "b", []byte("2"))
+				lru.Get(ctx, "a")
+				lru.Set(ctx, "c", []byte("3"))
+				lru.Set(ctx, "d", []byte("4"))
+			},
+			expected: map[string]string{"a": "1", "c": "3", "d": "4"},
+			missing:  []string{"b"},
+		},
+		"expired entries are missing": {
+			steps: func(lru *LRU, advance func(time.Duration)) {
+				lru.Set(ctx, "a", []byte("1"))
+				advance(time.Second)
+				lru.Set(ctx, "b", []byte("2"))
+				advance(2 * time.Second)
+			},
+			expected: map[string]string{"b": "2"},
+			missing:  []string{"a"},
+		},
+		"delete removes the keys": {
+			steps: func(lru *LRU, advance func(time.Duration)) {
+				lru.Set(ctx, "a", []byte("1"))
+				lru.Set(ctx, "b", []byte("2"))
+				lru.Set(ctx, "c", []byte("3"))
+				lru.Delete(ctx, "a", "c", "x")
+			},
+			expected: map[string]string{"b": "2"},
+			missing:  []string{"a", "c", "x"},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			now := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+			lru := NewLRU(3, 3*time.Second)
+			lru.now = func() time.Time { return now }
+
+			tc.steps(lru, func(d time.Duration) { now = now.Add(d) })
+
+			for key, value := range tc.expected {
+				cached, err := lru.Get(ctx, key)
+				assert.NoError(t, err, key)
+				assert.Equal(t, value, string(cached), key)
+			}
+			for _, key := range tc.missing {
+				_, err := lru.Get(ctx, key)
+				assert.ErrorIs(t, err, ErrMiss, key)
+			}
+			assert.LessOrEqual(t, lru.Len(), 3)
+		})
+	}
+}
+
+func TestLRU_copiesValues(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	lru := NewLRU(1, time.Minute)
+
+	value := []byte("1")
+	lru.Set(ctx, "a", value)
+	value[0] = '2'
+
+	cached, err := lru.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(cached))
+
+	cached[0] = '3'
+	cached, err = lru.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(cached))
+}
@@ -0,0 +1,114 @@
+/*
+ *  redis.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	verrors "sheazuzu/common/src/errors"
+)
+
+// Redis is a cache shared by all instances of a service. All keys are prefixed, so a redis database can be shared by
+// several services.
+type Redis struct {
+	client  *redis.Client
+	prefix  string
+	ttl     time.Duration
+	timeout time.Duration
+}
+
+func NewRedis(config Config) (*Redis, error) {
+	op := verrors.Op("cache: New redis")
+
+	client := redis.NewClient(&redis.Options{
+		Addr:         config.RedisAddr,
+		Password:     config.RedisPassword,
+		DB:           config.RedisDB,
+		DialTimeout:  config.RedisTimeout,
+		ReadTimeout:  config.RedisTimeout,
+		WriteTimeout: config.RedisTimeout,
+	})
+
+	// the service starts with an unreachable redis, but the configuration should be checked
+	ctx, cancel := context.WithTimeout(context.Background(), config.RedisTimeout)
+	defer cancel()
+
+	err := client.Ping(ctx).Err()
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		client.Close()
+		return nil, verrors.E(op, err, verrors.REDIS, verrors.Info{Name: "addr", Val: config.RedisAddr})
+	}
+
+	return &Redis{
+		client:  client,
+		prefix:  config.RedisPrefix,
+		ttl:     config.TTL,
+		timeout: config.RedisTimeout,
+	}, nil
+}
+
+func (cache *Redis) Get(ctx context.Context, key string) ([]byte, error) {
+	op := verrors.Op("cache: Redis get")
+
+	ctx, cancel := context.WithTimeout(ctx, cache.timeout)
+	defer cancel()
+
+	value, err := cache.client.Get(ctx, cache.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, verrors.E(op, err, verrors.REDIS, verrors.Info{Name: "key", Val: key})
+	}
+
+	return value, nil
+}
+
+func (cache *Redis) Set(ctx context.Context, key string, value []byte) error {
+	op := verrors.Op("cache: Redis set")
+
+	ctx, cancel := context.WithTimeout(ctx, cache.timeout)
+	defer cancel()
+
+	err := cache.client.Set(ctx, cache.prefix+key, value, cache.ttl).Err()
+	if err != nil {
+		return verrors.E(op, err, verrors.REDIS, verrors.Info{Name: "key", Val: key})
+	}
+
+	return nil
+}
+
+func (cache *Redis) Delete(ctx context.Context, keys ...string) error {
+	op := verrors.Op("cache: Redis delete")
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = cache.prefix + key
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cache.timeout)
+	defer cancel()
+
+	err := cache.client.Del(ctx, prefixed...).Err()
+	if err != nil {
+		return verrors.E(op, err, verrors.REDIS, verrors.Info{Name: "keys", Val: keys})
+	}
+
+	return nil
+}
+
+func (cache *Redis) Close() error {
+	return cache.client.Close()
+}
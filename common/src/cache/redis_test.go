@@ -0,0 +1,52 @@
+/*
+ *  redis_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package cache
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// the test needs a redis, e.g. docker run -p 6379:6379 redis
+func TestRedis(t *testing.T) {
+
+	addr := os.Getenv("SHEAZUZU_TEST_REDIS_ADDR")
+	if addr == "" {
+		t.Skip("SHEAZUZU_TEST_REDIS_ADDR is not set")
+	}
+
+	ctx := context.Background()
+	redis, err := NewRedis(Config{
+		Backend:      BackendRedis,
+		TTL:          time.Minute,
+		RedisAddr:    addr,
+		RedisPrefix:  "sheazuzu-test:" + t.Name() + ":",
+		RedisTimeout: time.Second,
+	})
+	require.NoError(t, err)
+	defer redis.Close()
+
+	_, err = redis.Get(ctx, "a")
+	assert.ErrorIs(t, err, ErrMiss)
+
+	assert.NoError(t, redis.Set(ctx, "a", []byte("1")))
+	assert.NoError(t, redis.Set(ctx, "b", []byte("2")))
+
+	value, err := redis.Get(ctx, "a")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(value))
+
+	assert.NoError(t, redis.Delete(ctx, "a", "b", "c"))
+
+	_, err = redis.Get(ctx, "b")
+	assert.ErrorIs(t, err, ErrMiss)
+}
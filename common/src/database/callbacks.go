@@ -0,0 +1,167 @@
+/*
+ *  callbacks.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package database
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+	"sheazuzu/common/src/metrics"
+	"sheazuzu/common/src/tracing"
+)
+
+// the operations of the queries, they name the spans and tag the metrics
+const (
+	OperationCreate = "create"
+	OperationQuery  = "query"
+	OperationUpdate = "update"
+	OperationDelete = "delete"
+)
+
+// the keys of the values gorm passes from the callbacks before a query to the ones after it
+const (
+	contextKey = "instrumentation:context"
+	spanKey    = "instrumentation:span"
+	startKey   = "instrumentation:start"
+)
+
+// the table of a raw query, which has no model
+const rawTable = "raw"
+
+// WithContext returns the connection, which passes the context to the callbacks of RegisterCallbacks, so the spans of
+// its queries are children of the span of the context
+func WithContext(db *gorm.DB, ctx context.Context) *gorm.DB {
+	return db.Set(contextKey, ctx)
+}
+
+// RegisterCallbacks instruments the create, query, update and delete operations of gorm. Every query becomes a tracing
+// span with the table, the rows affected and the SQL without its values, see WithContext. The duration of the queries is
+// recorded by table and operation, see metrics.RegisterQueryViews, and queries taking at least the slow query threshold
+// are logged. A threshold of 0 logs no queries. Statements run with Exec are not passed to the callbacks by gorm.
+func RegisterCallbacks(db *gorm.DB, slowQueryThreshold time.Duration, logger *zap.SugaredLogger) {
+
+	instrumentation := &queryInstrumentation{slowQueryThreshold: slowQueryThreshold, logger: logger}
+
+	// gorm logs the registration of every callback with the logger of the connection
+	quiet := db.New()
+	quiet.SetLogger(silentLogger{})
+	callback := quiet.Callback()
+
+	callback.Create().Before("gorm:create").Register("instrumentation:before_create", instrumentation.before(OperationCreate))
+	callback.Create().After("gorm:create").Register("instrumentation:after_create", instrumentation.after(OperationCreate))
+	callback.Query().Before("gorm:query").Register("instrumentation:before_query", instrumentation.before(OperationQuery))
+	callback.Query().After("gorm:query").Register("instrumentation:after_query", instrumentation.after(OperationQuery))
+	callback.RowQuery().Before("gorm:row_query").Register("instrumentation:before_row_query", instrumentation.before(OperationQuery))
+	callback.RowQuery().After("gorm:row_query").Register("instrumentation:after_row_query", instrumentation.after(OperationQuery))
+	callback.Update().Before("gorm:update").Register("instrumentation:before_update", instrumentation.before(OperationUpdate))
+	callback.Update().After("gorm:update").Register("instrumentation:after_update", instrumentation.after(OperationUpdate))
+	callback.Delete().Before("gorm:delete").Register("instrumentation:before_delete", instrumentation.before(OperationDelete))
+	callback.Delete().After("gorm:delete").Register("instrumentation:after_delete", instrumentation.after(OperationDelete))
+}
+
+type silentLogger struct{}
+
+func (silentLogger) Print(...interface{}) {}
+
+type queryInstrumentation struct {
+	slowQueryThreshold time.Duration
+	logger             *zap.SugaredLogger
+}
+
+func (instrumentation *queryInstrumentation) before(operation string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+
+		_, span := tracing.StartSpan(scopeContext(scope), "SQL "+operation+" "+scopeTable(scope))
+		scope.Set(spanKey, span)
+		scope.Set(startKey, time.Now())
+	}
+}
+
+func (instrumentation *queryInstrumentation) after(operation string) func(scope *gorm.Scope) {
+	return func(scope *gorm.Scope) {
+
+		value, ok := scope.Get(startKey)
+		if !ok {
+			return
+		}
+		duration := time.Since(value.(time.Time))
+
+		ctx := scopeContext(scope)
+		table := scopeTable(scope)
+		statement := SanitizeSQL(scope.SQL)
+		rowsAffected := scope.DB().RowsAffected
+
+		if value, ok := scope.Get(spanKey); ok {
+			span := value.(*trace.Span)
+			span.AddAttributes(
+				trace.StringAttribute("table", table),
+				trace.StringAttribute("operation", operation),
+				trace.StringAttribute("statement", statement),
+				trace.Int64Attribute("rows_affected", rowsAffected),
+			)
+			// a missing record is an expected result, not a failure
+			if scope.HasError() && !gorm.IsRecordNotFoundError(scope.DB().Error) {
+				span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: scope.DB().Error.Error()})
+			}
+			span.End()
+		}
+
+		metrics.RecordQuery(ctx, table, operation, duration)
+
+		if instrumentation.slowQueryThreshold > 0 && duration >= instrumentation.slowQueryThreshold {
+			tracing.LoggerWithTraceID(ctx, instrumentation.logger).Warnw("slow query",
+				"table", table,
+				"operation", operation,
+				"duration", duration,
+				"rowsAffected", rowsAffected,
+				"statement", statement)
+		}
+	}
+}
+
+// scopeContext returns the context passed with WithContext, without one the spans have no parent
+func scopeContext(scope *gorm.Scope) context.Context {
+
+	if value, ok := scope.Get(contextKey); ok {
+		if ctx, ok := value.(context.Context); ok {
+			return ctx
+		}
+	}
+
+	return context.Background()
+}
+
+func scopeTable(scope *gorm.Scope) string {
+
+	if scope.Value == nil {
+		return rawTable
+	}
+
+	table := scope.TableName()
+	if table == "" {
+		return rawTable
+	}
+
+	return table
+}
+
+var (
+	stringLiteral = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numberLiteral = regexp.MustCompile(`(^|[^\w$.])-?\d+(?:\.\d+)?`)
+)
+
+// SanitizeSQL replaces the string and number literals of the statement by '?', the values passed as arguments are
+// not part of the statement anyway. Quoted identifiers and the placeholders of PostgreSQL, e.g. $1, are kept.
+func SanitizeSQL(statement string) string {
+
+	statement = stringLiteral.ReplaceAllString(statement, "?")
+	return numberLiteral.ReplaceAllString(statement, "${1}?")
+}
@@ -0,0 +1,117 @@
+/*
+ *  callbacks_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package database
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opencensus.io/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestSanitizeSQL(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		statement string
+		expected  string
+	}{
+		"placeholders":      {statement: "SELECT * FROM `match_data` WHERE (id = ?)", expected: "SELECT * FROM `match_data` WHERE (id = ?)"},
+		"string literals":   {statement: "SELECT * FROM t WHERE a = 'x' AND b = 'it''s'", expected: "SELECT * FROM t WHERE a = ? AND b = ?"},
+		"number literals":   {statement: "SELECT * FROM t WHERE a = 1 AND b > -2.5 LIMIT 10", expected: "SELECT * FROM t WHERE a = ? AND b > ? LIMIT ?"},
+		"identifiers":       {statement: `SAVEPOINT sp_1; SELECT "t1"."a2" FROM t1`, expected: `SAVEPOINT sp_1; SELECT "t1"."a2" FROM t1`},
+		"postgres bindvars": {statement: `SELECT * FROM "match_data" WHERE (id = $1)`, expected: `SELECT * FROM "match_data" WHERE (id = $1)`},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, SanitizeSQL(tc.statement))
+		})
+	}
+}
+
+// spanRecorder records the ended spans of the queries
+type spanRecorder struct {
+	mutex sync.Mutex
+	spans []*trace.SpanData
+}
+
+func (recorder *spanRecorder) ExportSpan(span *trace.SpanData) {
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if strings.HasPrefix(span.Name, "SQL ") {
+		recorder.spans = append(recorder.spans, span)
+	}
+}
+
+type team struct {
+	ID   int
+	Name string
+}
+
+func TestRegisterCallbacks(t *testing.T) {
+
+	recorder := &spanRecorder{}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	core, logs := observer.New(zap.WarnLevel)
+
+	db, err := gorm.Open("sqlite3", ":memory:")
+	require.NoError(t, err)
+	defer db.Close()
+	require.NoError(t, db.Exec("CREATE TABLE teams (id INTEGER PRIMARY KEY, name TEXT)").Error)
+
+	// every query is slow
+	RegisterCallbacks(db, time.Nanosecond, zap.New(core).Sugar())
+
+	// the queries are sampled with their parent
+	ctx, parent := trace.StartSpan(context.Background(), "request", trace.WithSampler(trace.AlwaysSample()))
+	defer parent.End()
+	conn := WithContext(db, ctx)
+
+	require.NoError(t, conn.Create(&team{ID: 1, Name: "Wolfsburg"}).Error)
+	require.NoError(t, conn.Model(&team{ID: 1}).Update("name", "VfL Wolfsburg").Error)
+	var found team
+	require.NoError(t, conn.Where("name = 'VfL Wolfsburg'").First(&found).Error)
+	assert.True(t, conn.Where("id = ?", 2).First(&team{}).RecordNotFound())
+	require.NoError(t, conn.Delete(&team{ID: 1}).Error)
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	names := []string{}
+	for _, span := range recorder.spans {
+		names = append(names, span.Name)
+		assert.Equal(t, parent.SpanContext().SpanID, span.ParentSpanID)
+		assert.Equal(t, "teams", span.Attributes["table"])
+		assert.Equal(t, int32(trace.StatusCodeOK), span.Status.Code, span.Name)
+	}
+	assert.Equal(t, []string{"SQL create teams", "SQL update teams", "SQL query teams", "SQL query teams", "SQL delete teams"}, names)
+
+	if assert.Len(t, recorder.spans, 5) {
+		query := recorder.spans[2]
+		assert.Equal(t, int64(1), query.Attributes["rows_affected"])
+		assert.NotContains(t, query.Attributes["statement"], "Wolfsburg")
+		assert.Contains(t, query.Attributes["statement"], "name = ?")
+	}
+
+	assert.Equal(t, 5, logs.FilterMessage("slow query").Len())
+}
@@ -1,52 +1,227 @@
 package database
 
 import (
+	"database/sql"
 	"flag"
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DriverMySQL    = "mysql"
+	DriverPostgres = "postgres"
+	DriverSQLite   = "sqlite"
+)
+
+// the levels of the query log
+const (
+	LogOff   = "off"   // nothing is logged
+	LogError = "error" // failed queries are logged
+	LogAll   = "all"   // every query is logged with its duration
+)
+
+// SQLiteInMemory as database name keeps a SQLite database in memory, it is lost when the service stops
+const SQLiteInMemory = ":memory:"
+
+// the SSL modes follow the sslmode of PostgreSQL, they are mapped to the TLS configuration of MySQL
+const (
+	SSLDisable    = "disable"     // no SSL
+	SSLRequire    = "require"     // SSL without verifying the server certificate
+	SSLVerifyCA   = "verify-ca"   // SSL with a server certificate signed by the root certificate
+	SSLVerifyFull = "verify-full" // like verify-ca, the server certificate also has to match the endpoint
 )
 
 type Config struct {
-	Endpoint     string
-	Port         int
-	DatabaseName string
-	Config       string
-	UserName     string
-	Password     string
+	Driver         string
+	Endpoint       string
+	Port           int
+	DatabaseName   string
+	Config         string
+	UserName       string
+	Password       string
+	SSLMode        string
+	SSLRootCert    string
+	SSLCert        string
+	SSLKey         string
+	ConnectTimeout time.Duration
+
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+	LogLevel        string
+	// SlowQueryThreshold is the duration from which on a query is logged as slow, 0 logs no slow queries
+	SlowQueryThreshold time.Duration
+
+	// Replicas are the endpoints of the read replicas, "<host>" or "<host>:<port>", see ReplicaRouter.
+	// They share the credentials, SSL and pool settings of the primary.
+	Replicas              Endpoints
+	ReplicaHealthInterval time.Duration
+	// ReadYourWritesWindow is the time after a write of a client, in which the reads of the client go to the primary
+	ReadYourWritesWindow time.Duration
 }
 
+// Endpoints is a comma separated list of endpoints as flag value
+type Endpoints []string
+
+func (endpoints *Endpoints) String() string {
+	return strings.Join(*endpoints, ",")
+}
+
+func (endpoints *Endpoints) Set(value string) error {
+
+	*endpoints = nil
+	for _, endpoint := range strings.Split(value, ",") {
+		if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+			*endpoints = append(*endpoints, endpoint)
+		}
+	}
+
+	return nil
+}
+
+// ReplicaConfig returns the config of the read replica with the endpoint, without a port the port of the primary is used
+func (config *Config) ReplicaConfig(endpoint string) (Config, error) {
+
+	replica := *config
+	replica.Endpoint = endpoint
+	replica.Replicas = nil
+
+	host, port, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		// the endpoint has no port
+		return replica, nil
+	}
+
+	replica.Endpoint = host
+	replica.Port, err = strconv.Atoi(port)
+	if err != nil || replica.Port <= 0 {
+		return Config{}, fmt.Errorf("the port of the replica endpoint %s is invalid", endpoint)
+	}
+
+	return replica, nil
+}
+
+// GetDatabaseConn returns the DSN of the driver. With SQLite the database name is the path of the database file
+// and endpoint, port, credentials and SSL are not used.
 func (config *Config) GetDatabaseConn() string {
-	return fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s",
-		config.UserName,
-		config.Password,
-		config.Endpoint,
-		config.Port,
-		config.DatabaseName,
-		config.Config)
-	// "root:455279980@tcp(local:3306)/atb?charset=utf8&parseTime=True&loc=Local"
-	// or
-	// "root:455279980@/atb?charset=utf8&parseTime=True&loc=Local"
+
+	switch config.Driver {
+	case DriverPostgres:
+		return postgresDSN(config)
+	case DriverSQLite:
+		return sqliteDSN(config)
+	default:
+		return mysqlDSN(config)
+	}
+}
+
+// GormDialect returns the name of the gorm dialect of the driver
+func (config *Config) GormDialect() string {
+
+	switch config.Driver {
+	case DriverPostgres:
+		return "postgres"
+	case DriverSQLite:
+		return "sqlite3"
+	default:
+		return "mysql"
+	}
+}
+
+// InMemory returns true, if the database is a SQLite database in memory
+func (config *Config) InMemory() bool {
+	return config.Driver == DriverSQLite && config.DatabaseName == SQLiteInMemory
 }
 
 func BindConfig(config *Config, fs *flag.FlagSet) {
+	fs.StringVar(&config.Driver, "database.driver", DriverMySQL, "database driver, either 'mysql', 'postgres' or 'sqlite'")
 	fs.StringVar(&config.Endpoint, "database.endpoint", "localhost", "database endpoint")
-	fs.IntVar(&config.Port, "database.port", 3306, "database port")
-	fs.StringVar(&config.DatabaseName, "database.name", "", "database endpoint")
-	fs.StringVar(&config.Config, "database.config", "parseTime=true", "database endpoint")
+	fs.IntVar(&config.Port, "database.port", 0, "database port, 0 for the default port of the driver")
+	fs.StringVar(&config.DatabaseName, "database.name", "", "database name, with sqlite the path of the database file or ':memory:'")
+	fs.StringVar(&config.Config, "database.config", "", "additional parameters of the DSN, e.g. 'charset=utf8&loc=Local' with mysql")
 	fs.StringVar(&config.UserName, "database.username", "", "database username")
 	fs.StringVar(&config.Password, "database.password", "", "database password")
+	fs.StringVar(&config.SSLMode, "database.sslMode", SSLDisable, "database SSL mode, either 'disable', 'require', 'verify-ca' or 'verify-full'")
+	fs.StringVar(&config.SSLRootCert, "database.sslRootCert", "", "the root certificate file (PEM) to verify the database server certificate")
+	fs.StringVar(&config.SSLCert, "database.sslCert", "", "the client certificate file (PEM)")
+	fs.StringVar(&config.SSLKey, "database.sslKey", "", "the key file (PEM) of the client certificate")
+	fs.DurationVar(&config.ConnectTimeout, "database.connectTimeout", 10*time.Second, "the timeout of establishing a database connection")
+	fs.IntVar(&config.MaxOpenConns, "database.maxOpenConns", 20, "the maximum number of open connections, 0 for no limit")
+	fs.IntVar(&config.MaxIdleConns, "database.maxIdleConns", 5, "the maximum number of idle connections kept in the pool")
+	fs.DurationVar(&config.ConnMaxLifetime, "database.connMaxLifetime", 30*time.Minute, "the time after which a connection is closed, 0 to keep connections forever")
+	fs.DurationVar(&config.ConnMaxIdleTime, "database.connMaxIdleTime", 5*time.Minute, "the time after which an idle connection is closed, 0 to keep idle connections forever")
+	fs.StringVar(&config.LogLevel, "database.logLevel", LogError, "the queries logged, either 'off', 'error' or 'all'")
+	fs.DurationVar(&config.SlowQueryThreshold, "database.slowQueryThreshold", 200*time.Millisecond, "the duration from which on a query is logged as slow, 0 to log no slow queries")
+	fs.Var(&config.Replicas, "database.replicas", "comma separated endpoints of read replicas, e.g. 'replica-1,replica-2:3307', the reads are spread over them")
+	fs.DurationVar(&config.ReplicaHealthInterval, "database.replicaHealthInterval", 5*time.Second, "the interval of the health checks of the read replicas")
+	fs.DurationVar(&config.ReadYourWritesWindow, "database.readYourWritesWindow", 2*time.Second, "the time after a write of a client, in which its reads go to the primary instead of the replicas")
 
 }
 
 // IsValid checks if the config properties URI, Database and SSLClientCertFile (in case of UseSSL=true) are set.
+// A SQLite database only needs a name.
 func (config *Config) IsValid() bool {
 
+	if config.Driver != DriverMySQL && config.Driver != DriverPostgres && config.Driver != DriverSQLite {
+		fmt.Println("database driver must either be 'mysql', 'postgres' or 'sqlite'")
+		return false
+	}
+
+	if config.LogLevel != LogOff && config.LogLevel != LogError && config.LogLevel != LogAll {
+		fmt.Println("database log level must either be 'off', 'error' or 'all'")
+		return false
+	}
+
+	if config.MaxOpenConns < 0 || config.MaxIdleConns < 0 || config.ConnMaxLifetime < 0 || config.ConnMaxIdleTime < 0 {
+		fmt.Println("the database connection pool settings must not be negative")
+		return false
+	}
+
+	if config.SlowQueryThreshold < 0 {
+		fmt.Println("the slow query threshold must not be negative")
+		return false
+	}
+
+	if config.ReplicaHealthInterval < 0 || config.ReadYourWritesWindow < 0 {
+		fmt.Println("the replica health interval and the read your writes window must not be negative")
+		return false
+	}
+
+	if len(config.Replicas) > 0 && config.ReplicaHealthInterval == 0 {
+		fmt.Println("please specify a replica health interval")
+		return false
+	}
+
+	for _, endpoint := range config.Replicas {
+		if _, err := config.ReplicaConfig(endpoint); err != nil {
+			fmt.Println(err)
+			return false
+		}
+	}
+
+	if config.Driver == DriverSQLite {
+		if len(config.Replicas) > 0 {
+			fmt.Println("sqlite has no read replicas")
+			return false
+		}
+		if config.DatabaseName == "" {
+			fmt.Println("please specify the sqlite database file or ':memory:' as database name")
+			return false
+		}
+		return true
+	}
+
 	if config.Endpoint == "" {
 		fmt.Println("please specify a database endpoint")
 		return false
 	}
 
 	if config.DatabaseName == "" {
-		fmt.Println("please specify a mysql database name")
+		fmt.Printf("please specify a %s database name\n", config.Driver)
 		return false
 	}
 
@@ -60,5 +235,34 @@ func (config *Config) IsValid() bool {
 		return false
 	}
 
+	if config.SSLMode != SSLDisable && config.SSLMode != SSLRequire && config.SSLMode != SSLVerifyCA && config.SSLMode != SSLVerifyFull {
+		fmt.Println("database SSL mode must either be 'disable', 'require', 'verify-ca' or 'verify-full'")
+		return false
+	}
+
+	if (config.SSLMode == SSLVerifyCA || config.SSLMode == SSLVerifyFull) && config.SSLRootCert == "" {
+		fmt.Printf("please specify the root certificate file to verify the server certificate with SSL mode '%s'\n", config.SSLMode)
+		return false
+	}
+
+	if (config.SSLCert == "") != (config.SSLKey == "") {
+		fmt.Println("please specify both the client certificate file and its key file")
+		return false
+	}
+
 	return true
 }
+
+// ConfigurePool applies the pool settings to the connection pool. The pool of SQLite is left unchanged, it holds a
+// single connection, which must not be closed as it would lose an in-memory database.
+func (config *Config) ConfigurePool(db *sql.DB) {
+
+	if config.Driver == DriverSQLite {
+		return
+	}
+
+	db.SetMaxOpenConns(config.MaxOpenConns)
+	db.SetMaxIdleConns(config.MaxIdleConns)
+	db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+}
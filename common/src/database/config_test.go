@@ -0,0 +1,300 @@
+/*
+ *  config_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package database
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDatabaseConn(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		config  Config
+		dsn     string
+		dialect string
+	}{
+		"mysql": {
+			config:  Config{Driver: DriverMySQL, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLDisable},
+			dsn:     "user:secret@tcp(db:3306)/sheazuzu?parseTime=true",
+			dialect: "mysql",
+		},
+		"mysql with parameters": {
+			config:  Config{Driver: DriverMySQL, Endpoint: "db", Port: 3307, DatabaseName: "sheazuzu", Config: "charset=utf8", UserName: "user", Password: "secret", SSLMode: SSLDisable, ConnectTimeout: 5 * time.Second},
+			dsn:     "user:secret@tcp(db:3307)/sheazuzu?parseTime=true&timeout=5s&charset=utf8",
+			dialect: "mysql",
+		},
+		"mysql with ssl": {
+			config:  Config{Driver: DriverMySQL, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLRequire},
+			dsn:     "user:secret@tcp(db:3306)/sheazuzu?parseTime=true&tls=skip-verify",
+			dialect: "mysql",
+		},
+		"mysql with verified ssl": {
+			config:  Config{Driver: DriverMySQL, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLVerifyFull, SSLRootCert: "ca.pem"},
+			dsn:     "user:secret@tcp(db:3306)/sheazuzu?parseTime=true&tls=" + MySQLTLSConfig,
+			dialect: "mysql",
+		},
+		"postgres": {
+			config:  Config{Driver: DriverPostgres, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "s3cr?t", SSLMode: SSLDisable, ConnectTimeout: 10 * time.Second},
+			dsn:     "postgres://user:s3cr%3Ft@db:5432/sheazuzu?connect_timeout=10&sslmode=disable",
+			dialect: "postgres",
+		},
+		"postgres with ssl": {
+			config:  Config{Driver: DriverPostgres, Endpoint: "db", Port: 5433, DatabaseName: "sheazuzu", Config: "application_name=sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLVerifyFull, SSLRootCert: "ca.pem", SSLCert: "client.pem", SSLKey: "client.key"},
+			dsn:     "postgres://user:secret@db:5433/sheazuzu?sslcert=client.pem&sslkey=client.key&sslmode=verify-full&sslrootcert=ca.pem&application_name=sheazuzu",
+			dialect: "postgres",
+		},
+		"sqlite file": {
+			config:  Config{Driver: DriverSQLite, Endpoint: "db", DatabaseName: "/tmp/sheazuzu.db", Config: "_busy_timeout=1000"},
+			dsn:     "file:/tmp/sheazuzu.db?_busy_timeout=1000",
+			dialect: "sqlite3",
+		},
+		"sqlite in memory": {
+			config:  Config{Driver: DriverSQLite, DatabaseName: SQLiteInMemory},
+			dsn:     "file::memory:",
+			dialect: "sqlite3",
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.dsn, tc.config.GetDatabaseConn())
+			assert.Equal(t, tc.dialect, tc.config.GormDialect())
+		})
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		config   Config
+		valid    bool
+		inMemory bool
+	}{
+		"mysql": {
+			config: Config{Driver: DriverMySQL, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLDisable},
+			valid:  true,
+		},
+		"postgres": {
+			config: Config{Driver: DriverPostgres, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLRequire},
+			valid:  true,
+		},
+		"unknown ssl mode": {
+			config: Config{Driver: DriverPostgres, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: "prefer"},
+			valid:  false,
+		},
+		"verified ssl without root certificate": {
+			config: Config{Driver: DriverMySQL, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLVerifyCA},
+			valid:  false,
+		},
+		"client certificate without key": {
+			config: Config{Driver: DriverMySQL, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLRequire, SSLCert: "client.pem"},
+			valid:  false,
+		},
+		"unknown log level": {
+			config: Config{Driver: DriverSQLite, LogLevel: "debug", DatabaseName: "sheazuzu.db"},
+			valid:  false,
+		},
+		"negative pool size": {
+			config: Config{Driver: DriverSQLite, LogLevel: LogAll, DatabaseName: "sheazuzu.db", MaxOpenConns: -1},
+			valid:  false,
+		},
+		"negative slow query threshold": {
+			config: Config{Driver: DriverSQLite, LogLevel: LogAll, DatabaseName: "sheazuzu.db", SlowQueryThreshold: -time.Second},
+			valid:  false,
+		},
+		"replicas": {
+			config: Config{Driver: DriverMySQL, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLDisable, Replicas: Endpoints{"replica-1", "replica-2:3307"}, ReplicaHealthInterval: time.Second},
+			valid:  true,
+		},
+		"replica with invalid port": {
+			config: Config{Driver: DriverMySQL, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLDisable, Replicas: Endpoints{"replica-1:mysql"}, ReplicaHealthInterval: time.Second},
+			valid:  false,
+		},
+		"replicas without health interval": {
+			config: Config{Driver: DriverMySQL, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret", SSLMode: SSLDisable, Replicas: Endpoints{"replica-1"}},
+			valid:  false,
+		},
+		"negative read your writes window": {
+			config: Config{Driver: DriverSQLite, LogLevel: LogAll, DatabaseName: "sheazuzu.db", ReadYourWritesWindow: -time.Second},
+			valid:  false,
+		},
+		"sqlite with replicas": {
+			config: Config{Driver: DriverSQLite, LogLevel: LogError, DatabaseName: "sheazuzu.db", Replicas: Endpoints{"replica-1"}, ReplicaHealthInterval: time.Second},
+			valid:  false,
+		},
+		"mysql without password": {
+			config: Config{Driver: DriverMySQL, LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user"},
+			valid:  false,
+		},
+		"sqlite without credentials": {
+			config: Config{Driver: DriverSQLite, LogLevel: LogError, DatabaseName: "sheazuzu.db"},
+			valid:  true,
+		},
+		"sqlite in memory": {
+			config:   Config{Driver: DriverSQLite, LogLevel: LogError, DatabaseName: SQLiteInMemory},
+			valid:    true,
+			inMemory: true,
+		},
+		"sqlite without name": {
+			config: Config{Driver: DriverSQLite, LogLevel: LogError},
+			valid:  false,
+		},
+		"unknown driver": {
+			config: Config{Driver: "oracle", LogLevel: LogError, Endpoint: "db", DatabaseName: "sheazuzu", UserName: "user", Password: "secret"},
+			valid:  false,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.valid, tc.config.IsValid())
+			assert.Equal(t, tc.inMemory, tc.config.InMemory())
+		})
+	}
+}
+
+func TestReplicaConfig(t *testing.T) {
+	t.Parallel()
+
+	primary := Config{Driver: DriverMySQL, Endpoint: "db", Port: 3306, DatabaseName: "sheazuzu", UserName: "user", Password: "secret", Replicas: Endpoints{"replica"}}
+
+	cases := map[string]struct {
+		endpoint string
+		host     string
+		port     int
+		err      bool
+	}{
+		"host":          {endpoint: "replica", host: "replica", port: 3306},
+		"host and port": {endpoint: "replica:3307", host: "replica", port: 3307},
+		"ipv6":          {endpoint: "[::1]:3307", host: "::1", port: 3307},
+		"invalid port":  {endpoint: "replica:mysql", err: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			replica, err := primary.ReplicaConfig(tc.endpoint)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.host, replica.Endpoint)
+			assert.Equal(t, tc.port, replica.Port)
+			assert.Empty(t, replica.Replicas)
+			assert.Equal(t, primary.UserName, replica.UserName)
+			assert.Equal(t, primary.DatabaseName, replica.DatabaseName)
+		})
+	}
+}
+
+func TestEndpoints_Set(t *testing.T) {
+	t.Parallel()
+
+	endpoints := Endpoints{"old"}
+	assert.NoError(t, endpoints.Set(" replica-1, ,replica-2:3307 "))
+	assert.Equal(t, Endpoints{"replica-1", "replica-2:3307"}, endpoints)
+	assert.Equal(t, "replica-1,replica-2:3307", endpoints.String())
+}
+
+func TestRegisterTLSConfig(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		config Config
+		err    bool
+	}{
+		"mysql without ssl": {
+			config: Config{Driver: DriverMySQL, SSLMode: SSLDisable},
+			err:    false,
+		},
+		"mysql without verification": {
+			config: Config{Driver: DriverMySQL, SSLMode: SSLRequire},
+			err:    false,
+		},
+		"mysql with missing root certificate": {
+			config: Config{Driver: DriverMySQL, SSLMode: SSLVerifyFull, SSLRootCert: "missing.pem"},
+			err:    true,
+		},
+		"mysql with missing client certificate": {
+			config: Config{Driver: DriverMySQL, SSLMode: SSLRequire, SSLCert: "missing.pem", SSLKey: "missing.key"},
+			err:    true,
+		},
+		"postgres reads the certificates itself": {
+			config: Config{Driver: DriverPostgres, SSLMode: SSLVerifyFull, SSLRootCert: "missing.pem"},
+			err:    false,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tc.config.RegisterTLSConfig()
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfigurePool(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		config       Config
+		maxOpenConns int
+	}{
+		"mysql": {
+			config:       Config{Driver: DriverMySQL, MaxOpenConns: 7, MaxIdleConns: 3, ConnMaxLifetime: time.Minute, ConnMaxIdleTime: time.Second},
+			maxOpenConns: 7,
+		},
+		"sqlite is not changed": {
+			config:       Config{Driver: DriverSQLite, MaxOpenConns: 7},
+			maxOpenConns: 0,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// the pool is configured without connecting
+			db, err := sql.Open("sqlite3", "file::memory:")
+			assert.NoError(t, err)
+			defer db.Close()
+
+			tc.config.ConfigurePool(db)
+			assert.Equal(t, tc.maxOpenConns, db.Stats().MaxOpenConnections)
+		})
+	}
+}
@@ -0,0 +1,140 @@
+/*
+ *  replicas.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package database
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+)
+
+// Replica is a read replica of the primary database
+type Replica struct {
+	Endpoint string
+	DB       *gorm.DB
+}
+
+// ReplicaRouter spreads the reads round-robin over the healthy read replicas, the writes go to the primary. The reads
+// of a caller within the read your writes window after its write go to the primary as well, as the replicas may lag
+// behind. The window follows the last write of the caller, which is tracked in its context, see WithSession and
+// SessionHandler; a context without a session is not pinned to the primary. Without a healthy replica the primary serves
+// the reads. The reads and writes of a transaction are not routed, they all run on the primary.
+type ReplicaRouter struct {
+	primary  *gorm.DB
+	replicas []*replicaState
+	window   time.Duration
+	logger   *zap.SugaredLogger
+
+	next uint64
+}
+
+type replicaState struct {
+	Replica
+	healthy int32
+}
+
+// NewReplicaRouter returns the router of the replicas, which are healthy until CheckHealth finds otherwise
+func NewReplicaRouter(primary *gorm.DB, replicas []Replica, window time.Duration, logger *zap.SugaredLogger) *ReplicaRouter {
+
+	states := make([]*replicaState, 0, len(replicas))
+	for _, replica := range replicas {
+		states = append(states, &replicaState{Replica: replica, healthy: 1})
+	}
+
+	return &ReplicaRouter{
+		primary:  primary,
+		replicas: states,
+		window:   window,
+		logger:   logger,
+	}
+}
+
+// Primary returns the primary, which serves the writes
+func (router *ReplicaRouter) Primary() *gorm.DB {
+	return router.primary
+}
+
+// Read returns the database to read from for the caller of the context, the next healthy replica or the primary
+func (router *ReplicaRouter) Read(ctx context.Context) *gorm.DB {
+
+	if lastWrite := LastWrite(ctx); router.window > 0 && !lastWrite.IsZero() && time.Since(lastWrite) < router.window {
+		return router.primary
+	}
+
+	for range router.replicas {
+		replica := router.replicas[atomic.AddUint64(&router.next, 1)%uint64(len(router.replicas))]
+		if atomic.LoadInt32(&replica.healthy) == 1 {
+			return replica.DB
+		}
+	}
+
+	return router.primary
+}
+
+// RecordWrite starts the read your writes window of the caller of the context, it has to be called after every write to
+// the primary
+func (router *ReplicaRouter) RecordWrite(ctx context.Context) {
+	recordWrite(ctx)
+}
+
+// CheckHealth pings all replicas, a replica is healthy if it answers within the timeout
+func (router *ReplicaRouter) CheckHealth(ctx context.Context, timeout time.Duration) {
+
+	var wg sync.WaitGroup
+	for _, replica := range router.replicas {
+		wg.Add(1)
+		go func(replica *replicaState) {
+			defer wg.Done()
+
+			pingCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			err := replica.DB.DB().PingContext(pingCtx)
+
+			if err != nil && atomic.SwapInt32(&replica.healthy, 0) == 1 {
+				router.logger.Warnw("read replica is unhealthy, its reads go to the other replicas", "endpoint", replica.Endpoint, "error", err)
+			}
+			if err == nil && atomic.SwapInt32(&replica.healthy, 1) == 0 {
+				router.logger.Infow("read replica is healthy again", "endpoint", replica.Endpoint)
+			}
+		}(replica)
+	}
+	wg.Wait()
+}
+
+// Run checks the health of the replicas in the interval until the context is done. A check times out after the interval.
+func (router *ReplicaRouter) Run(ctx context.Context, interval time.Duration) {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		router.CheckHealth(ctx, interval)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close closes the connections to the replicas, the primary is left open
+func (router *ReplicaRouter) Close() error {
+
+	var err error
+	for _, replica := range router.replicas {
+		if closeErr := replica.DB.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
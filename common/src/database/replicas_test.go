@@ -0,0 +1,164 @@
+/*
+ *  replicas_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func openSQLite(t *testing.T) *gorm.DB {
+
+	db, err := gorm.Open("sqlite3", "file::memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestReplicaRouter_Read(t *testing.T) {
+	t.Parallel()
+
+	primary, first, second := openSQLite(t), openSQLite(t), openSQLite(t)
+	replicas := []Replica{{Endpoint: "first", DB: first}, {Endpoint: "second", DB: second}}
+
+	cases := map[string]struct {
+		replicas []Replica
+		window   time.Duration
+		write    bool
+		// other reads with the session of another caller than the one of the write
+		other     bool
+		unhealthy []int
+		reads     []*gorm.DB
+	}{
+		"round-robin":             {replicas: replicas, reads: []*gorm.DB{second, first, second, first}},
+		"without replicas":        {reads: []*gorm.DB{primary, primary}},
+		"within the window":       {replicas: replicas, window: time.Minute, write: true, reads: []*gorm.DB{primary, primary}},
+		"write of another caller": {replicas: replicas, window: time.Minute, write: true, other: true, reads: []*gorm.DB{second, first}},
+		"no write":                {replicas: replicas, window: time.Minute, reads: []*gorm.DB{second, first}},
+		"without window":          {replicas: replicas, write: true, reads: []*gorm.DB{second, first}},
+		"unhealthy replica":       {replicas: replicas, unhealthy: []int{0}, reads: []*gorm.DB{second, second}},
+		"no healthy replicas":     {replicas: replicas, unhealthy: []int{0, 1}, reads: []*gorm.DB{primary, primary}},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			router := NewReplicaRouter(primary, tc.replicas, tc.window, zap.NewNop().Sugar())
+			for _, i := range tc.unhealthy {
+				router.replicas[i].healthy = 0
+			}
+			ctx := WithSession(context.Background(), time.Time{})
+			if tc.write {
+				router.RecordWrite(ctx)
+			}
+			if tc.other {
+				ctx = WithSession(context.Background(), time.Time{})
+			}
+
+			var reads []*gorm.DB
+			for range tc.reads {
+				reads = append(reads, router.Read(ctx))
+			}
+			// the databases are compared by identity, they are all empty
+			for i := range reads {
+				assert.Same(t, tc.reads[i], reads[i], "read %d", i)
+			}
+			assert.Same(t, primary, router.Primary())
+		})
+	}
+}
+
+func TestReplicaRouter_windowExpires(t *testing.T) {
+	t.Parallel()
+
+	primary, replica := openSQLite(t), openSQLite(t)
+	router := NewReplicaRouter(primary, []Replica{{Endpoint: "replica", DB: replica}}, 50*time.Millisecond, zap.NewNop().Sugar())
+
+	ctx := WithSession(context.Background(), time.Time{})
+	router.RecordWrite(ctx)
+	assert.Same(t, primary, router.Read(ctx))
+
+	assert.Eventually(t, func() bool { return router.Read(ctx) == replica }, time.Second, 10*time.Millisecond)
+}
+
+func TestReplicaRouter_withoutSession(t *testing.T) {
+	t.Parallel()
+
+	primary, replica := openSQLite(t), openSQLite(t)
+	router := NewReplicaRouter(primary, []Replica{{Endpoint: "replica", DB: replica}}, time.Minute, zap.NewNop().Sugar())
+
+	// the write of a caller without session is not tracked
+	router.RecordWrite(context.Background())
+	assert.Same(t, replica, router.Read(context.Background()))
+
+	// a session continues the last write of an earlier request
+	assert.Same(t, primary, router.Read(WithSession(context.Background(), time.Now().Add(-time.Second))))
+	assert.Same(t, replica, router.Read(WithSession(context.Background(), time.Now().Add(-time.Hour))))
+}
+
+func TestReplicaRouter_CheckHealth(t *testing.T) {
+	t.Parallel()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	primary, healthy, broken := openSQLite(t), openSQLite(t), openSQLite(t)
+	router := NewReplicaRouter(primary, []Replica{{Endpoint: "healthy", DB: healthy}, {Endpoint: "broken", DB: broken}}, 0, zap.New(core).Sugar())
+
+	require.NoError(t, broken.Close())
+	router.CheckHealth(context.Background(), time.Second)
+	router.CheckHealth(context.Background(), time.Second)
+
+	for i := 0; i < 4; i++ {
+		assert.Same(t, healthy, router.Read(context.Background()))
+	}
+	if assert.Equal(t, 1, logs.Len(), "a replica becoming unhealthy is logged once") {
+		assert.Equal(t, "broken", logs.All()[0].ContextMap()["endpoint"])
+		assert.Equal(t, zapcore.WarnLevel, logs.All()[0].Level)
+	}
+
+	// the replica recovers
+	router.replicas[1].DB = openSQLite(t)
+	router.CheckHealth(context.Background(), time.Second)
+
+	reads := map[*gorm.DB]bool{}
+	for i := 0; i < 4; i++ {
+		reads[router.Read(context.Background())] = true
+	}
+	assert.Len(t, reads, 2)
+	assert.Equal(t, 2, logs.Len())
+	assert.Equal(t, "read replica is healthy again", logs.All()[1].Message)
+}
+
+func TestReplicaRouter_Run(t *testing.T) {
+	t.Parallel()
+
+	primary, replica := openSQLite(t), openSQLite(t)
+	router := NewReplicaRouter(primary, []Replica{{Endpoint: "replica", DB: replica}}, 0, zap.NewNop().Sugar())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		router.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	require.NoError(t, replica.Close())
+	assert.Eventually(t, func() bool { return router.Read(context.Background()) == primary }, time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+}
@@ -0,0 +1,133 @@
+/*
+ *  session.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package database
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// LastWriteCookie carries the time of the last write of a client in Unix nanoseconds between its requests
+const LastWriteCookie = "last-write"
+
+type sessionKey struct{}
+
+// session is the last write of one caller, the ReplicaRouter pins the reads of the caller to the primary after it
+type session struct {
+	// lastWrite is the time of the last write in Unix nanoseconds
+	lastWrite int64
+}
+
+// WithSession returns a context, whose writes and reads the ReplicaRouter tracks as the ones of one caller. The last
+// write of the caller is the given time, which may be zero.
+func WithSession(ctx context.Context, lastWrite time.Time) context.Context {
+
+	s := &session{}
+	if !lastWrite.IsZero() {
+		s.lastWrite = lastWrite.UnixNano()
+	}
+
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+// LastWrite returns the time of the last write of the caller of the context, zero without a write or a session
+func LastWrite(ctx context.Context) time.Time {
+
+	s, ok := ctx.Value(sessionKey{}).(*session)
+	if !ok || atomic.LoadInt64(&s.lastWrite) == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, atomic.LoadInt64(&s.lastWrite))
+}
+
+// recordWrite sets the last write of the caller of the context to now, without a session the write is not tracked
+func recordWrite(ctx context.Context) {
+
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		atomic.StoreInt64(&s.lastWrite, time.Now().UnixNano())
+	}
+}
+
+// SessionHandler returns a middleware, which tracks the last write of a client with the LastWriteCookie, so the reads of
+// the client within the read your writes window go to the primary. The cookie is set on a write and expires with the
+// window, the other clients keep reading from the replicas.
+func SessionHandler(window time.Duration) func(http.Handler) http.Handler {
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			var lastWrite time.Time
+			if cookie, err := r.Cookie(LastWriteCookie); err == nil {
+				if nanos, err := strconv.ParseInt(cookie.Value, 10, 64); err == nil && nanos > 0 {
+					lastWrite = time.Unix(0, nanos)
+				}
+			}
+
+			ctx := WithSession(r.Context(), lastWrite)
+			next.ServeHTTP(&sessionWriter{ResponseWriter: w, ctx: ctx, lastWrite: lastWrite, window: window}, r.WithContext(ctx))
+		})
+	}
+}
+
+// sessionWriter sets the LastWriteCookie before the header is written, if the request wrote. It flushes like the
+// underlying writer and unwraps to it for http.ResponseController.
+type sessionWriter struct {
+	http.ResponseWriter
+	ctx         context.Context
+	lastWrite   time.Time
+	window      time.Duration
+	wroteHeader bool
+}
+
+func (w *sessionWriter) WriteHeader(status int) {
+
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if lastWrite := LastWrite(w.ctx); w.window > 0 && lastWrite.After(w.lastWrite) {
+			http.SetCookie(w.ResponseWriter, &http.Cookie{
+				Name:     LastWriteCookie,
+				Value:    strconv.FormatInt(lastWrite.UnixNano(), 10),
+				Path:     "/",
+				MaxAge:   int(math.Ceil(w.window.Seconds())),
+				HttpOnly: true,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *sessionWriter) Write(data []byte) (int, error) {
+
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+// Flush writes the header and sends the buffered data, if the underlying writer supports it
+func (w *sessionWriter) Flush() {
+
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *sessionWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,93 @@
+/*
+ *  session_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package database
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSessionHandler(t *testing.T) {
+	t.Parallel()
+
+	earlier := time.Now().Add(-time.Second).Truncate(time.Microsecond)
+
+	cases := map[string]struct {
+		cookie    string
+		write     bool
+		lastWrite time.Time
+		setCookie bool
+	}{
+		"no cookie":        {},
+		"write":            {write: true, setCookie: true},
+		"earlier write":    {cookie: strconv.FormatInt(earlier.UnixNano(), 10), lastWrite: earlier},
+		"write again":      {cookie: strconv.FormatInt(earlier.UnixNano(), 10), lastWrite: earlier, write: true, setCookie: true},
+		"malformed cookie": {cookie: "yesterday"},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var lastWrite time.Time
+			handler := SessionHandler(2 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				lastWrite = LastWrite(r.Context())
+				if tc.write {
+					recordWrite(r.Context())
+				}
+				w.Write([]byte("ok"))
+			}))
+
+			request := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.cookie != "" {
+				request.AddCookie(&http.Cookie{Name: LastWriteCookie, Value: tc.cookie})
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, request)
+
+			assert.True(t, tc.lastWrite.Equal(lastWrite), "last write %v", lastWrite)
+
+			cookies := recorder.Result().Cookies()
+			if !tc.setCookie {
+				assert.Empty(t, cookies)
+				return
+			}
+			if assert.Len(t, cookies, 1) {
+				assert.Equal(t, LastWriteCookie, cookies[0].Name)
+				assert.Equal(t, 2, cookies[0].MaxAge)
+				nanos, err := strconv.ParseInt(cookies[0].Value, 10, 64)
+				assert.NoError(t, err)
+				assert.WithinDuration(t, time.Now(), time.Unix(0, nanos), time.Second)
+			}
+		})
+	}
+}
+
+func TestSessionHandler_flush(t *testing.T) {
+	t.Parallel()
+
+	handler := SessionHandler(2 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recordWrite(r.Context())
+		flusher, ok := w.(http.Flusher)
+		if assert.True(t, ok, "the writer must stay a flusher") {
+			flusher.Flush()
+		}
+	}))
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.True(t, recorder.Flushed)
+	// the cookie is set with the header written by the flush
+	assert.Len(t, recorder.Result().Cookies(), 1)
+}
@@ -29,12 +29,14 @@ const (
 	HttpEmptyOkResponse
 	HttpClientError
 
-	HttpNoContent   = Kind(204)
-	HttpBadRequest  = Kind(400)
-	HttpForbidden   = Kind(403)
-	HttpNotFound    = Kind(404)
-	HttpInternal    = Kind(500)
-	HttpUnavailable = Kind(503)
+	HttpNoContent     = Kind(204)
+	HttpBadRequest    = Kind(400)
+	HttpForbidden     = Kind(403)
+	HttpNotFound      = Kind(404)
+	HttpNotAcceptable = Kind(406)
+	HttpConflict      = Kind(409)
+	HttpInternal      = Kind(500)
+	HttpUnavailable   = Kind(503)
 )
 
 // The type 'SubService' describes the subservice from which the error originates.
@@ -100,6 +102,8 @@ var kindStringMap = map[Kind]string{
 	HttpBadRequest:      "HTTP Bad Request Error",
 	HttpForbidden:       "HTTP Forbidden Error",
 	HttpNotFound:        "HTTP Not Found Error",
+	HttpNotAcceptable:   "HTTP Not Acceptable Error",
+	HttpConflict:        "HTTP Conflict Error",
 	HttpInternal:        "HTTP Internal Server Error",
 	HttpUnavailable:     "HTTP Service Unavailable Error",
 	HttpEmptyOkResponse: "Empty Okapi Response Error",
@@ -140,10 +144,13 @@ func HttpErrorKindFromStatusCode(status int) Kind {
 // This map defines which error-kinds result in which http-status-codes when send to the user of vicci
 // all undefined Kinds will result in an internal-server-error status-code
 var kindHttpStatusMap = map[Kind]int{
-	HttpNoContent:  http.StatusNoContent,
-	HttpBadRequest: http.StatusBadRequest,
-	HttpNotFound:   http.StatusNotFound,
-	InputError:     http.StatusBadRequest,
+	HttpNoContent:     http.StatusNoContent,
+	HttpBadRequest:    http.StatusBadRequest,
+	HttpNotFound:      http.StatusNotFound,
+	HttpNotAcceptable: http.StatusNotAcceptable,
+	HttpConflict:      http.StatusConflict,
+	HttpUnavailable:   http.StatusServiceUnavailable,
+	InputError:        http.StatusBadRequest,
 }
 
 // receive the appropriate status-code which to send to the user of VICTOR
@@ -187,6 +194,12 @@ func (err *verror) Error() string {
 	return b.String()
 }
 
+// Unwrap returns the underlying error, so the standard library functions errors.Is, errors.As and errors.Unwrap
+// can look through the verror chain
+func (err *verror) Unwrap() error {
+	return err.Err
+}
+
 func printverr(b *strings.Builder, err error, sep string, isFirstLine bool) {
 	if err == nil {
 		return
@@ -7,6 +7,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/assert"
@@ -281,3 +282,14 @@ func TestError(t *testing.T) {
 		})
 	}
 }
+
+func TestUnwrap(t *testing.T) {
+
+	cause := errors.New("actual-error-message")
+
+	err := E(Op("outer op"), E(Op("inner op"), HttpBadRequest, cause))
+
+	assert.True(t, stderrors.Is(err, cause))
+	assert.Equal(t, cause, stderrors.Unwrap(stderrors.Unwrap(err)))
+	assert.Nil(t, stderrors.Unwrap(E(Op("no cause"))))
+}
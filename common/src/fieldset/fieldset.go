@@ -0,0 +1,216 @@
+/*
+ *  fieldset.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+// Package fieldset implements sparse fieldsets: a client selects the properties of a response with a list of
+// property paths like "home_team" or "additional_informations.additional". The paths are validated against the
+// OpenAPI schema of the response and applied to the generated response structs by their json tags.
+package fieldset
+
+import (
+	"fmt"
+	"reflect"
+	verrors "sheazuzu/common/src/errors"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Parse normalizes the fields of a query parameter, empty entries are dropped. nil selects all fields.
+func Parse(fields *[]string) []string {
+
+	if fields == nil {
+		return nil
+	}
+
+	var parsed []string
+	for _, field := range *fields {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			parsed = append(parsed, field)
+		}
+	}
+
+	return parsed
+}
+
+// Validate returns an error of kind verrors.InputError, if one of the fields is not a property path of the schema.
+// Arrays are transparent, a path continues with the properties of the array items.
+func Validate(schema *openapi3.SchemaRef, fields []string) error {
+	op := verrors.Op("fieldset: Validate Fields")
+
+	var unknown []string
+	for _, field := range fields {
+		if !hasProperty(schema, strings.Split(field, ".")) {
+			unknown = append(unknown, field)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return verrors.E(op, verrors.InputError, verrors.Info{Name: "fields", Val: fields},
+			fmt.Sprintf("unknown fields '%s', the known fields are '%s'", strings.Join(unknown, ","), strings.Join(Names(schema), ",")))
+	}
+
+	return nil
+}
+
+func hasProperty(schema *openapi3.SchemaRef, path []string) bool {
+
+	for _, name := range path {
+		schema = items(schema)
+		if schema == nil || schema.Value == nil {
+			return false
+		}
+
+		property, ok := schema.Value.Properties[name]
+		if !ok {
+			return false
+		}
+		schema = property
+	}
+
+	return true
+}
+
+// items returns the schema of the array items for an array schema and the schema itself otherwise
+func items(schema *openapi3.SchemaRef) *openapi3.SchemaRef {
+	for schema != nil && schema.Value != nil && schema.Value.Type == "array" {
+		schema = schema.Value.Items
+	}
+	return schema
+}
+
+// Names returns all property paths of the schema in alphabetical order.
+func Names(schema *openapi3.SchemaRef) []string {
+
+	var names []string
+	var collect func(schema *openapi3.SchemaRef, prefix string, depth int)
+	collect = func(schema *openapi3.SchemaRef, prefix string, depth int) {
+		schema = items(schema)
+		// recursive schemas are not followed forever
+		if schema == nil || schema.Value == nil || depth > 8 {
+			return
+		}
+		for name, property := range schema.Value.Properties {
+			names = append(names, prefix+name)
+			collect(property, prefix+name+".", depth+1)
+		}
+	}
+	collect(schema, "", 0)
+
+	sort.Strings(names)
+	return names
+}
+
+// Selection is the tree of the selected fields. A field mapped to nil is selected with all its nested fields.
+type Selection map[string]Selection
+
+// Select builds the Selection of the fields, nil selects all fields.
+func Select(fields []string) Selection {
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	selection := Selection{}
+	for _, field := range fields {
+		node := selection
+		path := strings.Split(field, ".")
+		for i, name := range path {
+			child, ok := node[name]
+			if ok && child == nil {
+				break // the field is already selected completely
+			}
+			if i == len(path)-1 {
+				node[name] = nil
+				break
+			}
+			if !ok {
+				child = Selection{}
+				node[name] = child
+			}
+			node = child
+		}
+	}
+
+	return selection
+}
+
+// Has returns true, if the field is selected.
+func (selection Selection) Has(name string) bool {
+	if selection == nil {
+		return true
+	}
+	_, ok := selection[name]
+	return ok
+}
+
+// Nested returns the selection of the nested fields of name, nil if all nested fields are selected.
+func (selection Selection) Nested(name string) Selection {
+	return selection[name]
+}
+
+// Project sets all fields of v which are not selected to their zero value, v must be a pointer.
+// The fields are identified by their json tags, so omitted pointer fields disappear from the response.
+func Project(v interface{}, fields []string) {
+	project(reflect.ValueOf(v), Select(fields))
+}
+
+func project(v reflect.Value, selection Selection) {
+
+	if selection == nil {
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			project(v.Elem(), selection)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			project(v.Index(i), selection)
+		}
+
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if !field.CanSet() {
+				continue
+			}
+
+			name := jsonName(v.Type().Field(i))
+			if name == "" {
+				continue
+			}
+
+			if !selection.Has(name) {
+				field.Set(reflect.Zero(field.Type()))
+				continue
+			}
+			project(field, selection.Nested(name))
+		}
+	}
+}
+
+func jsonName(field reflect.StructField) string {
+
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return field.Name
+	}
+
+	name := strings.Split(tag, ",")[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}
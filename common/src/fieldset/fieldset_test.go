@@ -0,0 +1,191 @@
+/*
+ *  fieldset_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package fieldset
+
+import (
+	"net/http"
+	verrors "sheazuzu/common/src/errors"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/stretchr/testify/assert"
+)
+
+type testStadium struct {
+	City     *string `json:"city,omitempty"`
+	Capacity *int    `json:"capacity,omitempty"`
+}
+
+type testTeam struct {
+	Name    *string       `json:"name,omitempty"`
+	Founded *int          `json:"founded,omitempty"`
+	Stadium *testStadium  `json:"stadium,omitempty"`
+	Players []testStadium `json:"players,omitempty"`
+}
+
+func ptr(s string) *string { return &s }
+func intPtr(i int) *int    { return &i }
+
+func team() testTeam {
+	return testTeam{
+		Name:    ptr("HSV"),
+		Founded: intPtr(1887),
+		Stadium: &testStadium{City: ptr("Hamburg"), Capacity: intPtr(57000)},
+		Players: []testStadium{{City: ptr("Kiel"), Capacity: intPtr(1)}},
+	}
+}
+
+func testSchema(t *testing.T) *openapi3.SchemaRef {
+	doc, err := openapi3.NewSwaggerLoader().LoadSwaggerFromData([]byte(`
+openapi: 3.0.1
+info:
+  title: test
+  version: '1.0'
+paths: {}
+components:
+  schemas:
+    Team:
+      type: object
+      properties:
+        name:
+          type: string
+        founded:
+          type: integer
+        stadium:
+          $ref: '#/components/schemas/Stadium'
+        players:
+          type: array
+          items:
+            $ref: '#/components/schemas/Stadium'
+    Stadium:
+      type: object
+      properties:
+        city:
+          type: string
+        capacity:
+          type: integer
+`))
+	assert.NoError(t, err)
+	return doc.Components.Schemas["Team"]
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		fields   *[]string
+		expected []string
+	}{
+		"nil":            {fields: nil, expected: nil},
+		"empty entries":  {fields: &[]string{"", " "}, expected: nil},
+		"trimmed fields": {fields: &[]string{" name", "stadium.city "}, expected: []string{"name", "stadium.city"}},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			assert.Equal(t, tt.expected, Parse(tt.fields))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	schema := testSchema(t)
+
+	tests := map[string]struct {
+		fields []string
+		valid  bool
+	}{
+		"no fields":        {fields: nil, valid: true},
+		"top level":        {fields: []string{"name", "founded"}, valid: true},
+		"nested":           {fields: []string{"stadium.city"}, valid: true},
+		"array items":      {fields: []string{"players.capacity"}, valid: true},
+		"unknown":          {fields: []string{"name", "coach"}, valid: false},
+		"unknown nested":   {fields: []string{"stadium.coach"}, valid: false},
+		"below a scalar":   {fields: []string{"name.first"}, valid: false},
+		"empty path parts": {fields: []string{"stadium."}, valid: false},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := Validate(schema, tt.fields)
+			if tt.valid {
+				assert.NoError(t, err)
+				return
+			}
+			assert.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, verrors.HttpErrorCodeFromError(err))
+		})
+	}
+}
+
+func TestNames(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []string{
+		"founded", "name",
+		"players", "players.capacity", "players.city",
+		"stadium", "stadium.capacity", "stadium.city",
+	}, Names(testSchema(t)))
+}
+
+func TestProject(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		fields   []string
+		expected testTeam
+	}{
+		"all fields": {
+			fields:   nil,
+			expected: team(),
+		},
+		"top level": {
+			fields:   []string{"name"},
+			expected: testTeam{Name: ptr("HSV")},
+		},
+		"nested": {
+			fields:   []string{"founded", "stadium.city"},
+			expected: testTeam{Founded: intPtr(1887), Stadium: &testStadium{City: ptr("Hamburg")}},
+		},
+		"parent selects all nested fields": {
+			fields:   []string{"stadium.city", "stadium"},
+			expected: testTeam{Stadium: &testStadium{City: ptr("Hamburg"), Capacity: intPtr(57000)}},
+		},
+		"array items": {
+			fields:   []string{"players.capacity"},
+			expected: testTeam{Players: []testStadium{{Capacity: intPtr(1)}}},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := team()
+			Project(&actual, tt.fields)
+
+			assert.Equal(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestProjectList(t *testing.T) {
+	t.Parallel()
+
+	actual := []testTeam{team(), team()}
+	Project(&actual, []string{"name"})
+
+	assert.Equal(t, []testTeam{{Name: ptr("HSV")}, {Name: ptr("HSV")}}, actual)
+}
@@ -0,0 +1,75 @@
+/*
+ *  cache.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	cacheKey = tag.MustNewKey("cache")
+
+	mCacheHits    = stats.Int64("cache_hits", "The count of cache hits", "")
+	cacheHitsView = &view.View{
+		Name:        "cache_hits",
+		Measure:     mCacheHits,
+		Description: "The count of cache hits",
+		TagKeys:     []tag.Key{cacheKey},
+		Aggregation: view.Count(),
+	}
+
+	mCacheMisses    = stats.Int64("cache_misses", "The count of cache misses", "")
+	cacheMissesView = &view.View{
+		Name:        "cache_misses",
+		Measure:     mCacheMisses,
+		Description: "The count of cache misses, including the failed cache lookups",
+		TagKeys:     []tag.Key{cacheKey},
+		Aggregation: view.Count(),
+	}
+
+	mCacheErrors    = stats.Int64("cache_errors", "The count of failed cache operations", "")
+	cacheErrorsView = &view.View{
+		Name:        "cache_errors",
+		Measure:     mCacheErrors,
+		Description: "The count of failed cache operations",
+		TagKeys:     []tag.Key{cacheKey},
+		Aggregation: view.Count(),
+	}
+)
+
+// RegisterCacheViews exports the hits, misses and errors of the caches, tagged with the name of the cache
+func RegisterCacheViews() error {
+
+	err := view.Register(cacheHitsView, cacheMissesView, cacheErrorsView)
+	if err != nil {
+		return fmt.Errorf("error registering cache metric views: %s", err)
+	}
+
+	return nil
+}
+
+func RecordCacheHit(ctx context.Context, cache string) {
+	recordCache(ctx, cache, mCacheHits)
+}
+
+func RecordCacheMiss(ctx context.Context, cache string) {
+	recordCache(ctx, cache, mCacheMisses)
+}
+
+func RecordCacheError(ctx context.Context, cache string) {
+	recordCache(ctx, cache, mCacheErrors)
+}
+
+func recordCache(ctx context.Context, cache string, measure *stats.Int64Measure) {
+	// the tag cannot fail, the key and the value are valid
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(cacheKey, cache)}, measure.M(1))
+}
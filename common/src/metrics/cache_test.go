@@ -0,0 +1,50 @@
+/*
+ *  cache_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/stats/view"
+)
+
+func TestRecordCache(t *testing.T) {
+
+	assert.NoError(t, RegisterCacheViews())
+	defer view.Unregister(cacheHitsView, cacheMissesView, cacheErrorsView)
+
+	ctx := context.Background()
+	RecordCacheHit(ctx, "match")
+	RecordCacheHit(ctx, "match")
+	RecordCacheHit(ctx, "other")
+	RecordCacheMiss(ctx, "match")
+	RecordCacheError(ctx, "match")
+
+	cases := map[string]map[string]int64{
+		"cache_hits":   {"match": 2, "other": 1},
+		"cache_misses": {"match": 1},
+		"cache_errors": {"match": 1},
+	}
+
+	for name, expected := range cases {
+		name, expected := name, expected
+
+		t.Run(name, func(t *testing.T) {
+
+			rows, err := view.RetrieveData(name)
+			assert.NoError(t, err)
+
+			counts := map[string]int64{}
+			for _, row := range rows {
+				counts[row.Tags[0].Value] = row.Data.(*view.CountData).Value
+			}
+			assert.Equal(t, expected, counts)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+/*
+ *  database.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+)
+
+var (
+	mDBOpen    = stats.Int64("db_connections_open", "The number of open database connections", "")
+	dbOpenView = &view.View{
+		Name:        "db_connections_open",
+		Measure:     mDBOpen,
+		Description: "The number of open database connections, in use and idle",
+		Aggregation: view.LastValue(),
+	}
+
+	mDBInUse    = stats.Int64("db_connections_in_use", "The number of database connections in use", "")
+	dbInUseView = &view.View{
+		Name:        "db_connections_in_use",
+		Measure:     mDBInUse,
+		Description: "The number of database connections in use",
+		Aggregation: view.LastValue(),
+	}
+
+	mDBIdle    = stats.Int64("db_connections_idle", "The number of idle database connections", "")
+	dbIdleView = &view.View{
+		Name:        "db_connections_idle",
+		Measure:     mDBIdle,
+		Description: "The number of idle database connections",
+		Aggregation: view.LastValue(),
+	}
+
+	mDBWaitCount    = stats.Int64("db_wait_count", "The total number of waits for a database connection", "")
+	dbWaitCountView = &view.View{
+		Name:        "db_wait_count",
+		Measure:     mDBWaitCount,
+		Description: "The total number of waits for a database connection, as the pool was exhausted",
+		Aggregation: view.LastValue(),
+	}
+
+	mDBWaitDuration    = stats.Int64("db_wait_duration", "The total time waited for a database connection", "ms")
+	dbWaitDurationView = &view.View{
+		Name:        "db_wait_duration",
+		Measure:     mDBWaitDuration,
+		Description: "The total time waited for a database connection",
+		Aggregation: view.LastValue(),
+	}
+)
+
+// ReportDBStats exports the statistics of the connection pool of the database until the context is done
+func ReportDBStats(ctx context.Context, db *sql.DB) error {
+
+	err := view.Register(dbOpenView, dbInUseView, dbIdleView, dbWaitCountView, dbWaitDurationView)
+	if err != nil {
+		return fmt.Errorf("error registering database metric views: %s", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+
+		for {
+			recordDBStats(ctx, db.Stats())
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return nil
+}
+
+func recordDBStats(ctx context.Context, dbStats sql.DBStats) {
+	stats.Record(ctx,
+		mDBOpen.M(int64(dbStats.OpenConnections)),
+		mDBInUse.M(int64(dbStats.InUse)),
+		mDBIdle.M(int64(dbStats.Idle)),
+		mDBWaitCount.M(dbStats.WaitCount),
+		mDBWaitDuration.M(dbStats.WaitDuration.Milliseconds()))
+}
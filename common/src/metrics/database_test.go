@@ -0,0 +1,52 @@
+/*
+ *  database_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/stats/view"
+)
+
+func TestRecordDBStats(t *testing.T) {
+
+	err := view.Register(dbOpenView, dbInUseView, dbIdleView, dbWaitCountView, dbWaitDurationView)
+	assert.NoError(t, err)
+
+	recordDBStats(context.Background(), sql.DBStats{
+		OpenConnections: 5,
+		InUse:           3,
+		Idle:            2,
+		WaitCount:       7,
+		WaitDuration:    1500 * time.Millisecond,
+	})
+
+	cases := map[string]float64{
+		"db_connections_open":   5,
+		"db_connections_in_use": 3,
+		"db_connections_idle":   2,
+		"db_wait_count":         7,
+		"db_wait_duration":      1500,
+	}
+
+	for name, expected := range cases {
+		name, expected := name, expected
+
+		t.Run(name, func(t *testing.T) {
+
+			rows, err := view.RetrieveData(name)
+			assert.NoError(t, err)
+			if assert.Len(t, rows, 1) {
+				assert.Equal(t, expected, rows[0].Data.(*view.LastValueData).Value)
+			}
+		})
+	}
+}
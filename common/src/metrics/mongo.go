@@ -0,0 +1,106 @@
+/*
+ *  mongo.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// the results of a check-out of a connection of the MongoDB pool
+const (
+	CheckOutSucceeded = "succeeded"
+	CheckOutFailed    = "failed"
+)
+
+var (
+	reasonKey = tag.MustNewKey("reason")
+
+	mPoolCreated    = stats.Int64("mongo_pool_connections_created", "The count of connections created by the MongoDB pool", "")
+	poolCreatedView = &view.View{
+		Name:        "mongo_pool_connections_created",
+		Measure:     mPoolCreated,
+		Description: "The count of connections created by the MongoDB connection pool",
+		Aggregation: view.Count(),
+	}
+
+	mPoolClosed    = stats.Int64("mongo_pool_connections_closed", "The count of connections closed by the MongoDB pool", "")
+	poolClosedView = &view.View{
+		Name:        "mongo_pool_connections_closed",
+		Measure:     mPoolClosed,
+		Description: "The count of connections closed by the MongoDB connection pool by the reason, e.g. idle or stale",
+		TagKeys:     []tag.Key{reasonKey},
+		Aggregation: view.Count(),
+	}
+
+	mPoolCheckedOut    = stats.Int64("mongo_pool_connections_checked_out", "The number of connections checked out of the MongoDB pool", "")
+	poolCheckedOutView = &view.View{
+		Name:        "mongo_pool_connections_checked_out",
+		Measure:     mPoolCheckedOut,
+		Description: "The number of connections checked out of the MongoDB connection pool, i.e. in use",
+		Aggregation: view.LastValue(),
+	}
+
+	mPoolCheckOuts    = stats.Int64("mongo_pool_checkouts", "The count of check-outs of connections of the MongoDB pool", "")
+	poolCheckOutsView = &view.View{
+		Name:        "mongo_pool_checkouts",
+		Measure:     mPoolCheckOuts,
+		Description: "The count of check-outs of connections of the MongoDB connection pool by their result, either succeeded or failed",
+		TagKeys:     []tag.Key{resultKey},
+		Aggregation: view.Count(),
+	}
+
+	mPoolWaitTime    = stats.Int64("mongo_pool_wait_time", "The time waited for a connection of the MongoDB pool", "ms")
+	poolWaitTimeView = &view.View{
+		Name:        "mongo_pool_wait_time",
+		Measure:     mPoolWaitTime,
+		Description: "The time waited for the check-out of a connection of the MongoDB connection pool",
+		TagKeys:     []tag.Key{resultKey},
+		Aggregation: view.Distribution(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000),
+	}
+)
+
+// RegisterMongoPoolViews exports the connections and the check-outs of the MongoDB connection pool
+func RegisterMongoPoolViews() error {
+
+	err := view.Register(poolCreatedView, poolClosedView, poolCheckedOutView, poolCheckOutsView, poolWaitTimeView)
+	if err != nil {
+		return fmt.Errorf("error registering mongo pool metric views: %s", err)
+	}
+
+	return nil
+}
+
+func RecordPoolConnectionCreated(ctx context.Context) {
+	stats.Record(ctx, mPoolCreated.M(1))
+}
+
+// RecordPoolConnectionClosed records a closed connection with the reason of the driver, e.g. idle
+func RecordPoolConnectionClosed(ctx context.Context, reason string) {
+	// the tag cannot fail, the key and the value are valid
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(reasonKey, reason)}, mPoolClosed.M(1))
+}
+
+// RecordPoolCheckOut records a check-out with its result, see CheckOutSucceeded, the time waited for it and the
+// number of connections checked out afterwards
+func RecordPoolCheckOut(ctx context.Context, result string, wait time.Duration, checkedOut int64) {
+	// the tag cannot fail, the key and the value are valid
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(resultKey, result)},
+		mPoolCheckOuts.M(1),
+		mPoolWaitTime.M(wait.Milliseconds()))
+	stats.Record(ctx, mPoolCheckedOut.M(checkedOut))
+}
+
+// RecordPoolCheckIn records the number of connections checked out after a connection was returned to the pool
+func RecordPoolCheckIn(ctx context.Context, checkedOut int64) {
+	stats.Record(ctx, mPoolCheckedOut.M(checkedOut))
+}
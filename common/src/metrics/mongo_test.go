@@ -0,0 +1,66 @@
+/*
+ *  mongo_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/stats/view"
+)
+
+func TestRecordPool(t *testing.T) {
+
+	assert.NoError(t, RegisterMongoPoolViews())
+	defer view.Unregister(poolCreatedView, poolClosedView, poolCheckedOutView, poolCheckOutsView, poolWaitTimeView)
+
+	ctx := context.Background()
+	RecordPoolConnectionCreated(ctx)
+	RecordPoolConnectionCreated(ctx)
+	RecordPoolConnectionClosed(ctx, "idle")
+	RecordPoolCheckOut(ctx, CheckOutSucceeded, 10*time.Millisecond, 1)
+	RecordPoolCheckOut(ctx, CheckOutSucceeded, 30*time.Millisecond, 2)
+	RecordPoolCheckOut(ctx, CheckOutFailed, time.Second, 2)
+	RecordPoolCheckIn(ctx, 1)
+
+	rows, err := view.RetrieveData("mongo_pool_connections_created")
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.EqualValues(t, 2, rows[0].Data.(*view.CountData).Value)
+	}
+
+	rows, err = view.RetrieveData("mongo_pool_connections_closed")
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, "idle", rows[0].Tags[0].Value)
+		assert.EqualValues(t, 1, rows[0].Data.(*view.CountData).Value)
+	}
+
+	rows, err = view.RetrieveData("mongo_pool_connections_checked_out")
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.Equal(t, 1.0, rows[0].Data.(*view.LastValueData).Value)
+	}
+
+	rows, err = view.RetrieveData("mongo_pool_checkouts")
+	assert.NoError(t, err)
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.Tags[0].Value] = row.Data.(*view.CountData).Value
+	}
+	assert.Equal(t, map[string]int64{CheckOutSucceeded: 2, CheckOutFailed: 1}, counts)
+
+	rows, err = view.RetrieveData("mongo_pool_wait_time")
+	assert.NoError(t, err)
+	for _, row := range rows {
+		if row.Tags[0].Value == CheckOutSucceeded {
+			assert.Equal(t, 20.0, row.Data.(*view.DistributionData).Mean)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+/*
+ *  query.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	tableKey     = tag.MustNewKey("table")
+	operationKey = tag.MustNewKey("operation")
+
+	mQueryLatency    = stats.Int64("sql_query_latency", "The duration of SQL queries", "ms")
+	queryLatencyView = &view.View{
+		Name:        "sql_query_latency",
+		Measure:     mQueryLatency,
+		Description: "The duration of SQL queries by their table and operation, either create, query, update or delete",
+		TagKeys:     []tag.Key{tableKey, operationKey},
+		Aggregation: view.Distribution(1, 2, 5, 10, 20, 50, 100, 200, 500, 1000, 2000, 5000, 10000),
+	}
+)
+
+// RegisterQueryViews exports the duration of the SQL queries
+func RegisterQueryViews() error {
+
+	err := view.Register(queryLatencyView)
+	if err != nil {
+		return fmt.Errorf("error registering query metric views: %s", err)
+	}
+
+	return nil
+}
+
+// RecordQuery records the duration of a SQL query of the table and the operation, e.g. query
+func RecordQuery(ctx context.Context, table string, operation string, duration time.Duration) {
+	// the tags cannot fail, the keys and the values are valid
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(tableKey, table), tag.Upsert(operationKey, operation)},
+		mQueryLatency.M(duration.Milliseconds()))
+}
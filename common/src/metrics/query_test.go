@@ -0,0 +1,43 @@
+/*
+ *  query_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/stats/view"
+)
+
+func TestRecordQuery(t *testing.T) {
+
+	assert.NoError(t, RegisterQueryViews())
+	defer view.Unregister(queryLatencyView)
+
+	ctx := context.Background()
+	RecordQuery(ctx, "match_data", "query", 10*time.Millisecond)
+	RecordQuery(ctx, "match_data", "query", 30*time.Millisecond)
+	RecordQuery(ctx, "match_data", "create", 5*time.Millisecond)
+
+	rows, err := view.RetrieveData("sql_query_latency")
+	assert.NoError(t, err)
+
+	means := map[string]float64{}
+	for _, row := range rows {
+		operation := ""
+		for _, tag := range row.Tags {
+			assert.Contains(t, []string{"match_data", "query", "create"}, tag.Value)
+			if tag.Key == operationKey {
+				operation = tag.Value
+			}
+		}
+		means[operation] = row.Data.(*view.DistributionData).Mean
+	}
+	assert.Equal(t, map[string]float64{"query": 20, "create": 5}, means)
+}
@@ -0,0 +1,77 @@
+/*
+ *  transaction.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+// the results of a transaction
+const (
+	TransactionCommitted = "committed"
+	TransactionAborted   = "aborted"
+	TransactionFailed    = "failed"
+)
+
+var (
+	resultKey = tag.MustNewKey("result")
+
+	mTransactions    = stats.Int64("mongo_transactions", "The count of MongoDB transactions", "")
+	transactionsView = &view.View{
+		Name:        "mongo_transactions",
+		Measure:     mTransactions,
+		Description: "The count of MongoDB transactions by their result, either committed, aborted by the caller or failed",
+		TagKeys:     []tag.Key{resultKey},
+		Aggregation: view.Count(),
+	}
+
+	mTransactionRetries    = stats.Int64("mongo_transaction_retries", "The count of retried MongoDB transactions and commits", "")
+	transactionRetriesView = &view.View{
+		Name:        "mongo_transaction_retries",
+		Measure:     mTransactionRetries,
+		Description: "The count of MongoDB transactions and commits retried after a transient error or an unknown commit result",
+		Aggregation: view.Count(),
+	}
+
+	mTransactionLatency    = stats.Int64("mongo_transaction_latency", "The duration of MongoDB transactions including their retries", "ms")
+	transactionLatencyView = &view.View{
+		Name:        "mongo_transaction_latency",
+		Measure:     mTransactionLatency,
+		Description: "The duration of MongoDB transactions including their retries",
+		TagKeys:     []tag.Key{resultKey},
+		Aggregation: view.Distribution(10, 20, 50, 70, 100, 200, 500, 700, 1000, 2000, 5000, 7000, 10000),
+	}
+)
+
+// RegisterTransactionViews exports the count, the retries and the duration of the MongoDB transactions
+func RegisterTransactionViews() error {
+
+	err := view.Register(transactionsView, transactionRetriesView, transactionLatencyView)
+	if err != nil {
+		return fmt.Errorf("error registering transaction metric views: %s", err)
+	}
+
+	return nil
+}
+
+// RecordTransaction records a finished transaction with its result, see TransactionCommitted
+func RecordTransaction(ctx context.Context, result string, duration time.Duration) {
+	// the tag cannot fail, the key and the value are valid
+	_ = stats.RecordWithTags(ctx, []tag.Mutator{tag.Upsert(resultKey, result)},
+		mTransactions.M(1),
+		mTransactionLatency.M(duration.Milliseconds()))
+}
+
+func RecordTransactionRetry(ctx context.Context) {
+	stats.Record(ctx, mTransactionRetries.M(1))
+}
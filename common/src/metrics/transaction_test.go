@@ -0,0 +1,50 @@
+/*
+ *  transaction_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package metrics
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/stats/view"
+)
+
+func TestRecordTransaction(t *testing.T) {
+
+	assert.NoError(t, RegisterTransactionViews())
+	defer view.Unregister(transactionsView, transactionRetriesView, transactionLatencyView)
+
+	ctx := context.Background()
+	RecordTransaction(ctx, TransactionCommitted, 20*time.Millisecond)
+	RecordTransaction(ctx, TransactionCommitted, 40*time.Millisecond)
+	RecordTransaction(ctx, TransactionAborted, time.Millisecond)
+	RecordTransactionRetry(ctx)
+
+	rows, err := view.RetrieveData("mongo_transactions")
+	assert.NoError(t, err)
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.Tags[0].Value] = row.Data.(*view.CountData).Value
+	}
+	assert.Equal(t, map[string]int64{TransactionCommitted: 2, TransactionAborted: 1}, counts)
+
+	rows, err = view.RetrieveData("mongo_transaction_retries")
+	assert.NoError(t, err)
+	if assert.Len(t, rows, 1) {
+		assert.EqualValues(t, 1, rows[0].Data.(*view.CountData).Value)
+	}
+
+	rows, err = view.RetrieveData("mongo_transaction_latency")
+	assert.NoError(t, err)
+	for _, row := range rows {
+		if row.Tags[0].Value == TransactionCommitted {
+			assert.Equal(t, 30.0, row.Data.(*view.DistributionData).Mean)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+/*
+ *  dialect.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Dialect contains the database specific parts of the migrations
+type Dialect interface {
+	// Lock acquires the migration lock on the connection, so only one process migrates at a time
+	Lock(ctx context.Context, conn *sql.Conn) error
+	// Unlock releases the migration lock on the connection
+	Unlock(ctx context.Context, conn *sql.Conn) error
+	// Placeholder returns the n-th bind parameter of a statement, starting with 1
+	Placeholder(n int) string
+}
+
+// MySQL uses a named lock of MySQL, which is released when the connection is closed
+type MySQL struct {
+	LockName    string
+	LockTimeout time.Duration
+}
+
+func (dialect MySQL) Lock(ctx context.Context, conn *sql.Conn) error {
+
+	var acquired sql.NullInt64
+	err := conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", dialect.LockName, int(dialect.LockTimeout.Seconds())).Scan(&acquired)
+	if err != nil {
+		return err
+	}
+
+	if !acquired.Valid || acquired.Int64 != 1 {
+		return fmt.Errorf("could not acquire the migration lock '%s' within %s, another migration is running", dialect.LockName, dialect.LockTimeout)
+	}
+
+	return nil
+}
+
+func (dialect MySQL) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", dialect.LockName)
+	return err
+}
+
+func (dialect MySQL) Placeholder(int) string {
+	return "?"
+}
+
+// SQLite holds an exclusive transaction while migrating, other connections wait for it up to the busy timeout of the
+// connection. The migrations are run in this transaction and committed by Unlock, also if a migration failed, so the
+// dirty migration is recorded like with MySQL.
+type SQLite struct{}
+
+func (dialect SQLite) Lock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "BEGIN EXCLUSIVE")
+	if err != nil {
+		return fmt.Errorf("could not acquire the migration lock, another migration is running: %s", err)
+	}
+	return nil
+}
+
+func (dialect SQLite) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "COMMIT")
+	return err
+}
+
+func (dialect SQLite) Placeholder(int) string {
+	return "?"
+}
+
+// Postgres uses a session level advisory lock, which is released when the connection is closed
+type Postgres struct {
+	LockID      int64
+	LockTimeout time.Duration
+}
+
+func (dialect Postgres) Lock(ctx context.Context, conn *sql.Conn) error {
+
+	// pg_advisory_lock waits without a timeout, so the query is cancelled after the lock timeout
+	lockCtx, cancel := context.WithTimeout(ctx, dialect.LockTimeout)
+	defer cancel()
+
+	_, err := conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", dialect.LockID)
+	if err != nil {
+		return fmt.Errorf("could not acquire the migration lock %d within %s, another migration is running: %s", dialect.LockID, dialect.LockTimeout, err)
+	}
+
+	return nil
+}
+
+func (dialect Postgres) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", dialect.LockID)
+	return err
+}
+
+func (dialect Postgres) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
@@ -0,0 +1,160 @@
+/*
+ *  migration.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+// Package migrate applies versioned SQL migrations and tracks them in the schema_migrations table.
+package migrate
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionLayout is the layout of the migration versions, the creation time of the migration
+const VersionLayout = "20060102150405"
+
+// Migration is a versioned change of the schema. Up applies the change, Down reverts it.
+// The statements of a migration are separated by a semicolon at the end of a line.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// the migration files are named <version>_<name>.up.sql and <version>_<name>.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Load reads the migrations in the directory dir of fsys, ordered by version.
+// Every migration needs an up and a down file and the versions have to be unique.
+func Load(fsys fs.FS, dir string) ([]Migration, error) {
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := map[int64]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("invalid migration file name '%s', expected <version>_<name>.up.sql or <version>_<name>.down.sql", entry.Name())
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of migration file '%s': %s", entry.Name(), err)
+		}
+
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrations[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			migrations[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("the migrations '%s' and '%s' have the same version %d", migration.Name, match[2], version)
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if len(statements(migration.Up)) == 0 || len(statements(migration.Down)) == 0 {
+			return nil, fmt.Errorf("the migration %d_%s needs an up and a down file with statements", migration.Version, migration.Name)
+		}
+		result = append(result, *migration)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+
+	return result, nil
+}
+
+// Create writes the empty up and down files of a new migration into dir and returns their paths.
+// The version is the creation time, so migrations created on different branches do not collide.
+func Create(dir, name string, now time.Time) (string, string, error) {
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !regexp.MustCompile(`^[a-z0-9_]+$`).MatchString(name) {
+		return "", "", fmt.Errorf("invalid migration name '%s', only lowercase letters, digits and underscores are allowed", name)
+	}
+
+	base := filepath.Join(dir, now.UTC().Format(VersionLayout)+"_"+name)
+	up, down := base+".up.sql", base+".down.sql"
+
+	for _, file := range []struct{ path, comment string }{
+		{up, "-- applies the migration " + name},
+		{down, "-- reverts the migration " + name},
+	} {
+		// O_EXCL does not overwrite a migration created in the same second
+		f, err := os.OpenFile(file.path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err != nil {
+			return "", "", err
+		}
+		_, err = f.WriteString(file.comment + "\n")
+		closeErr := f.Close()
+		if err != nil {
+			return "", "", err
+		}
+		if closeErr != nil {
+			return "", "", closeErr
+		}
+	}
+
+	return up, down, nil
+}
+
+// statements splits the script of a migration into its statements, which end with a semicolon at the end of a line.
+// Lines starting with -- are comments.
+func statements(script string) []string {
+
+	var result []string
+	var statement strings.Builder
+
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		statement.WriteString(line)
+		statement.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			result = append(result, strings.TrimSuffix(strings.TrimSpace(statement.String()), ";"))
+			statement.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(statement.String()); rest != "" {
+		result = append(result, rest)
+	}
+
+	return result
+}
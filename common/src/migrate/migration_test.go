@@ -0,0 +1,144 @@
+/*
+ *  migration_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	cases := map[string]struct {
+		files    fstest.MapFS
+		expected []Migration
+		valid    bool
+	}{
+		"ordered by version": {
+			files: fstest.MapFS{
+				"migrations/2_second.up.sql":   file("CREATE TABLE b (id INT);"),
+				"migrations/2_second.down.sql": file("DROP TABLE b;"),
+				"migrations/1_first.up.sql":    file("CREATE TABLE a (id INT);"),
+				"migrations/1_first.down.sql":  file("DROP TABLE a;"),
+			},
+			expected: []Migration{
+				{Version: 1, Name: "first", Up: "CREATE TABLE a (id INT);", Down: "DROP TABLE a;"},
+				{Version: 2, Name: "second", Up: "CREATE TABLE b (id INT);", Down: "DROP TABLE b;"},
+			},
+			valid: true,
+		},
+		"missing down file": {
+			files: fstest.MapFS{
+				"migrations/1_first.up.sql": file("CREATE TABLE a (id INT);"),
+			},
+			valid: false,
+		},
+		"empty down file": {
+			files: fstest.MapFS{
+				"migrations/1_first.up.sql":   file("CREATE TABLE a (id INT);"),
+				"migrations/1_first.down.sql": file("-- nothing to do\n"),
+			},
+			valid: false,
+		},
+		"same version": {
+			files: fstest.MapFS{
+				"migrations/1_first.up.sql":    file("CREATE TABLE a (id INT);"),
+				"migrations/1_first.down.sql":  file("DROP TABLE a;"),
+				"migrations/1_second.up.sql":   file("CREATE TABLE b (id INT);"),
+				"migrations/1_second.down.sql": file("DROP TABLE b;"),
+			},
+			valid: false,
+		},
+		"invalid file name": {
+			files: fstest.MapFS{
+				"migrations/first.sql": file("CREATE TABLE a (id INT);"),
+			},
+			valid: false,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			migrations, err := Load(tc.files, "migrations")
+			assert.Equal(t, tc.valid, err == nil, "error: %v", err)
+			if tc.valid {
+				assert.Equal(t, tc.expected, migrations)
+			}
+		})
+	}
+}
+
+func TestCreate(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	now := time.Date(2026, 10, 19, 12, 30, 45, 0, time.UTC)
+
+	up, down, err := Create(dir, "Add_Venue", now)
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "20261019123045_add_venue.up.sql"), up)
+	assert.Equal(t, filepath.Join(dir, "20261019123045_add_venue.down.sql"), down)
+
+	content, err := os.ReadFile(up)
+	assert.NoError(t, err)
+	assert.Equal(t, "-- applies the migration add_venue\n", string(content))
+
+	_, _, err = Create(dir, "add_venue", now)
+	assert.Error(t, err, "an existing migration must not be overwritten")
+
+	_, _, err = Create(dir, "add venue", now)
+	assert.Error(t, err)
+}
+
+func TestStatements(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		script   string
+		expected []string
+	}{
+		"one statement": {
+			script:   "CREATE TABLE a (id INT);",
+			expected: []string{"CREATE TABLE a (id INT)"},
+		},
+		"statements over several lines with comments": {
+			script: "-- the teams\nCREATE TABLE a (\n  id INT\n);\n\n-- the index\nCREATE INDEX idx ON a (id);\n",
+			expected: []string{
+				"CREATE TABLE a (\n  id INT\n)",
+				"CREATE INDEX idx ON a (id)",
+			},
+		},
+		"last statement without semicolon": {
+			script:   "DROP TABLE a;\nDROP TABLE b",
+			expected: []string{"DROP TABLE a", "DROP TABLE b"},
+		},
+		"only comments": {
+			script:   "-- nothing to do\n",
+			expected: nil,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, statements(tc.script))
+		})
+	}
+}
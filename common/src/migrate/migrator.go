@@ -0,0 +1,306 @@
+/*
+ *  migrator.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// Table tracks the applied migrations. A migration is dirty, while it is applied or reverted. If it stays dirty,
+// the migration failed halfway and the schema has to be repaired manually, as most DDL statements cannot be rolled back.
+const Table = "schema_migrations"
+
+// Status is the state of a migration in the database
+type Status struct {
+	Migration
+	Applied   bool
+	Dirty     bool
+	AppliedAt *time.Time
+}
+
+// Migrator applies and reverts the migrations. All changes are made under the lock of the dialect.
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+	logger     *zap.SugaredLogger
+}
+
+func NewMigrator(db *sql.DB, dialect Dialect, migrations []Migration, logger *zap.SugaredLogger) *Migrator {
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+		logger:     logger,
+	}
+}
+
+type appliedMigration struct {
+	version   int64
+	name      string
+	dirty     bool
+	appliedAt *time.Time
+}
+
+// Up applies up to steps pending migrations in the order of their versions, all pending migrations if steps is 0
+func (migrator *Migrator) Up(ctx context.Context, steps int) ([]Migration, error) {
+
+	var done []Migration
+	err := migrator.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+
+		for _, migration := range migrator.migrations {
+			if steps > 0 && len(done) == steps {
+				return nil
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			migrator.logger.Infof("applying migration %d_%s", migration.Version, migration.Name)
+
+			err := migrator.exec(ctx, conn, "INSERT INTO "+Table+" (version, name, dirty, applied_at) VALUES ("+migrator.placeholders(4)+")",
+				migration.Version, migration.Name, true, time.Now().UTC())
+			if err != nil {
+				return err
+			}
+
+			err = migrator.run(ctx, conn, migration.Up)
+			if err != nil {
+				return fmt.Errorf("migration %d_%s failed, the schema is dirty: %s", migration.Version, migration.Name, err)
+			}
+
+			err = migrator.exec(ctx, conn, "UPDATE "+Table+" SET dirty = "+migrator.dialect.Placeholder(1)+" WHERE version = "+migrator.dialect.Placeholder(2),
+				false, migration.Version)
+			if err != nil {
+				return err
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Down reverts up to steps applied migrations, starting with the latest one
+func (migrator *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+
+	var done []Migration
+	err := migrator.locked(ctx, func(conn *sql.Conn, applied map[int64]appliedMigration) error {
+
+		for i := len(migrator.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := migrator.migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			migrator.logger.Infof("reverting migration %d_%s", migration.Version, migration.Name)
+
+			err := migrator.exec(ctx, conn, "UPDATE "+Table+" SET dirty = "+migrator.dialect.Placeholder(1)+" WHERE version = "+migrator.dialect.Placeholder(2),
+				true, migration.Version)
+			if err != nil {
+				return err
+			}
+
+			err = migrator.run(ctx, conn, migration.Down)
+			if err != nil {
+				return fmt.Errorf("reverting migration %d_%s failed, the schema is dirty: %s", migration.Version, migration.Name, err)
+			}
+
+			err = migrator.exec(ctx, conn, "DELETE FROM "+Table+" WHERE version = "+migrator.dialect.Placeholder(1), migration.Version)
+			if err != nil {
+				return err
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, err
+}
+
+// Status returns the state of all known migrations
+func (migrator *Migrator) Status(ctx context.Context) ([]Status, error) {
+
+	applied, err := migrator.applied(ctx, migrator.db)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]Status, 0, len(migrator.migrations))
+	for _, migration := range migrator.migrations {
+		status := Status{Migration: migration}
+		if a, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.Dirty = a.dirty
+			status.AppliedAt = a.appliedAt
+		}
+		result = append(result, status)
+	}
+
+	return result, nil
+}
+
+// Check returns an error, if a migration is pending or dirty, or if the database was migrated by a newer version
+// with migrations unknown to this one
+func (migrator *Migrator) Check(ctx context.Context) error {
+
+	applied, err := migrator.applied(ctx, migrator.db)
+	if err != nil {
+		return err
+	}
+
+	known := map[int64]bool{}
+	var pending []string
+	for _, migration := range migrator.migrations {
+		known[migration.Version] = true
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, fmt.Sprintf("%d_%s", migration.Version, migration.Name))
+		}
+	}
+
+	for _, a := range applied {
+		if a.dirty {
+			return fmt.Errorf("the migration %d_%s is dirty, the schema has to be repaired manually", a.version, a.name)
+		}
+		if !known[a.version] {
+			return fmt.Errorf("the database contains the unknown migration %d_%s", a.version, a.name)
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("the schema is not migrated, pending migrations: %s", strings.Join(pending, ", "))
+	}
+
+	return nil
+}
+
+// locked runs f on a connection holding the migration lock. The applied migrations are read after the lock was acquired,
+// f is not run if a migration is dirty.
+func (migrator *Migrator) locked(ctx context.Context, f func(conn *sql.Conn, applied map[int64]appliedMigration) error) error {
+
+	conn, err := migrator.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	err = migrator.dialect.Lock(ctx, conn)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := migrator.dialect.Unlock(context.Background(), conn)
+		if err != nil {
+			migrator.logger.Errorw("error releasing the migration lock", "error", err)
+		}
+	}()
+
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS "+Table+" ("+
+		"version BIGINT NOT NULL PRIMARY KEY, "+
+		"name VARCHAR(255) NOT NULL, "+
+		"dirty BOOLEAN NOT NULL, "+
+		"applied_at TIMESTAMP NULL)")
+	if err != nil {
+		return err
+	}
+
+	applied, err := migrator.applied(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range applied {
+		if a.dirty {
+			return fmt.Errorf("the migration %d_%s is dirty, repair the schema manually and delete or update its row in %s", a.version, a.name, Table)
+		}
+	}
+
+	return f(conn, applied)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// applied reads the applied migrations, a missing table means that no migration was applied
+func (migrator *Migrator) applied(ctx context.Context, db queryer) (map[int64]appliedMigration, error) {
+
+	rows, err := db.QueryContext(ctx, "SELECT version, name, dirty, applied_at FROM "+Table)
+	if err != nil {
+		if tableMissing(ctx, db) {
+			return map[int64]appliedMigration{}, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int64]appliedMigration{}
+	for rows.Next() {
+		var a appliedMigration
+		var appliedAt sql.NullTime
+		err := rows.Scan(&a.version, &a.name, &a.dirty, &appliedAt)
+		if err != nil {
+			return nil, err
+		}
+		if appliedAt.Valid {
+			a.appliedAt = &appliedAt.Time
+		}
+		applied[a.version] = a
+	}
+
+	return applied, rows.Err()
+}
+
+// tableMissing returns true, if the migration table does not exist. It uses the connection of the failed query,
+// as a database limited to one connection would block otherwise.
+func tableMissing(ctx context.Context, db queryer) bool {
+	// selecting nothing from the table fails only, if it does not exist
+	rows, err := db.QueryContext(ctx, "SELECT 1 FROM "+Table+" WHERE 1 = 0")
+	if err != nil {
+		return true
+	}
+	_ = rows.Close()
+	return false
+}
+
+func (migrator *Migrator) run(ctx context.Context, conn *sql.Conn, script string) error {
+
+	for _, statement := range statements(script) {
+		_, err := conn.ExecContext(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (migrator *Migrator) exec(ctx context.Context, conn *sql.Conn, statement string, args ...interface{}) error {
+	_, err := conn.ExecContext(ctx, statement, args...)
+	return err
+}
+
+func (migrator *Migrator) placeholders(n int) string {
+
+	placeholders := make([]string, 0, n)
+	for i := 1; i <= n; i++ {
+		placeholders = append(placeholders, migrator.dialect.Placeholder(i))
+	}
+
+	return strings.Join(placeholders, ", ")
+}
@@ -0,0 +1,180 @@
+/*
+ *  migrator_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Name: "first", Up: "CREATE TABLE a (id INT);", Down: "DROP TABLE a;"},
+	{Version: 2, Name: "second", Up: "CREATE TABLE b (id INT);", Down: "DROP TABLE b;"},
+	{Version: 3, Name: "third", Up: "CREATE TABLE c (id INT);", Down: "DROP TABLE c;"},
+}
+
+// newTestMigrator returns a migrator on a new SQLite database
+func newTestMigrator(t *testing.T, migrations []Migration) (*Migrator, *sql.DB) {
+
+	db, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return NewMigrator(db, SQLite{}, migrations, zap.NewNop().Sugar()), db
+}
+
+func versions(migrations []Migration) []int64 {
+	var result []int64
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
+
+func TestMigrator(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		up       []int
+		down     []int
+		applied  []int64
+		reverted []int64
+		migrated []int64
+		checkErr bool
+	}{
+		"all": {
+			up:       []int{0},
+			applied:  []int64{1, 2, 3},
+			migrated: []int64{1, 2, 3},
+		},
+		"in steps": {
+			up:       []int{1, 1, 1},
+			applied:  []int64{1, 2, 3},
+			migrated: []int64{1, 2, 3},
+		},
+		"up to date": {
+			up:       []int{0, 0},
+			applied:  []int64{1, 2, 3},
+			migrated: []int64{1, 2, 3},
+		},
+		"partially": {
+			up:       []int{2},
+			applied:  []int64{1, 2},
+			migrated: []int64{1, 2},
+			checkErr: true,
+		},
+		"down": {
+			up:       []int{0},
+			down:     []int{1},
+			applied:  []int64{1, 2, 3},
+			reverted: []int64{3},
+			migrated: []int64{1, 2},
+			checkErr: true,
+		},
+		"down more than applied": {
+			up:       []int{2},
+			down:     []int{5},
+			applied:  []int64{1, 2},
+			reverted: []int64{2, 1},
+			checkErr: true,
+		},
+		"nothing applied": {
+			checkErr: true,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			migrator, _ := newTestMigrator(t, testMigrations)
+			ctx := context.Background()
+
+			var applied, reverted []Migration
+			for _, steps := range tc.up {
+				done, err := migrator.Up(ctx, steps)
+				require.NoError(t, err)
+				applied = append(applied, done...)
+			}
+			for _, steps := range tc.down {
+				done, err := migrator.Down(ctx, steps)
+				require.NoError(t, err)
+				reverted = append(reverted, done...)
+			}
+
+			assert.Equal(t, tc.applied, versions(applied))
+			assert.Equal(t, tc.reverted, versions(reverted))
+
+			err := migrator.Check(ctx)
+			if tc.checkErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			status, err := migrator.Status(ctx)
+			require.NoError(t, err)
+			require.Len(t, status, len(testMigrations))
+			var migrated []int64
+			for i, s := range status {
+				if s.Applied {
+					migrated = append(migrated, s.Version)
+				}
+				assert.False(t, s.Dirty)
+				assert.Equal(t, s.Applied, s.AppliedAt != nil)
+				assert.Equal(t, testMigrations[i].Name, s.Name)
+			}
+			assert.Equal(t, tc.migrated, migrated)
+		})
+	}
+}
+
+func TestMigratorDirty(t *testing.T) {
+	t.Parallel()
+
+	failing := append(testMigrations[:1:1], Migration{Version: 2, Name: "failing", Up: "CREATE TABLE a (id INT);", Down: "SELECT 1;"})
+	migrator, _ := newTestMigrator(t, failing)
+	ctx := context.Background()
+
+	applied, err := migrator.Up(ctx, 0)
+	assert.Error(t, err)
+	assert.Equal(t, []int64{1}, versions(applied))
+
+	status, err := migrator.Status(ctx)
+	require.NoError(t, err)
+	assert.True(t, status[1].Dirty)
+
+	// a dirty schema has to be repaired manually, before it is migrated again
+	assert.Error(t, migrator.Check(ctx))
+	_, err = migrator.Up(ctx, 0)
+	assert.Error(t, err)
+	_, err = migrator.Down(ctx, 1)
+	assert.Error(t, err)
+}
+
+func TestMigratorUnknownVersion(t *testing.T) {
+	t.Parallel()
+
+	migrator, db := newTestMigrator(t, testMigrations)
+	ctx := context.Background()
+
+	_, err := migrator.Up(ctx, 0)
+	require.NoError(t, err)
+
+	// the database was migrated by a newer version of the service
+	older := NewMigrator(db, SQLite{}, testMigrations[:2], zap.NewNop().Sugar())
+	assert.Error(t, older.Check(ctx))
+}
@@ -0,0 +1,265 @@
+/*
+ *  collection.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opencensus.io/trace"
+	verrors "sheazuzu/common/src/errors"
+	"sheazuzu/common/src/tracing"
+)
+
+// Collection reads and writes the documents of a collection as T. Every operation runs in a tracing span and is
+// limited to the timeout of the config. The errors are verrors: a missing document has the kind HttpNotFound, a
+// duplicate key HttpConflict and a timeout HttpUnavailable, the driver error is wrapped.
+// The operations take part in the transaction of the session of their context.
+type Collection[T any] struct {
+	db      *Database
+	name    string
+	idField string
+}
+
+// NewCollection returns the collection of the connected database. idField is the field FindByID filters by, e.g. "_id".
+func NewCollection[T any](db *Database, name string, idField string) *Collection[T] {
+	return &Collection[T]{db: db, name: name, idField: idField}
+}
+
+// Query selects, orders and pages the documents of Find and Iterate. A zero Limit returns all documents, a nil
+// Projection all fields.
+type Query struct {
+	Sort       bson.D
+	Skip       int64
+	Limit      int64
+	Projection bson.D
+}
+
+// Name returns the name of the collection.
+func (collection *Collection[T]) Name() string {
+	return collection.name
+}
+
+// FindByID returns the document with the id, only the fields of the projection are loaded, a nil projection loads all.
+func (collection *Collection[T]) FindByID(ctx context.Context, id interface{}, projection bson.D) (T, error) {
+	op := verrors.Op("mongo: Find by id")
+	info := verrors.Info{Name: collection.idField, Val: id}
+
+	ctx, span, cancel := collection.start(ctx, "Find by id")
+	defer cancel()
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("id", fmt.Sprint(id)))
+
+	findOptions := options.FindOne()
+	if projection != nil {
+		findOptions.SetProjection(projection)
+	}
+
+	var document T
+	err := collection.collection().FindOne(ctx, bson.D{{Key: collection.idField, Value: id}}, findOptions).Decode(&document)
+	if err != nil {
+		var zero T
+		return zero, collection.error(op, span, err, info)
+	}
+
+	return document, nil
+}
+
+// Find returns all documents matching the filter.
+func (collection *Collection[T]) Find(ctx context.Context, filter interface{}, query Query) ([]T, error) {
+	op := verrors.Op("mongo: Find")
+
+	ctx, span, cancel := collection.start(ctx, "Find")
+	defer cancel()
+	defer span.End()
+	span.AddAttributes(trace.Int64Attribute("skip", query.Skip), trace.Int64Attribute("limit", query.Limit))
+
+	cursor, err := collection.collection().Find(ctx, filter, query.options())
+	if err != nil {
+		return nil, collection.error(op, span, err)
+	}
+	defer CloseCursor(cursor, ctx)
+
+	var documents []T
+	err = cursor.All(ctx, &documents)
+	if err != nil {
+		return nil, collection.error(op, span, err)
+	}
+
+	return documents, nil
+}
+
+// Iterate calls f with every document matching the filter, without loading all documents into memory. The timeout
+// applies to every batch of documents read from the cursor, not to the whole iteration. An error of f stops the
+// iteration and is returned.
+func (collection *Collection[T]) Iterate(ctx context.Context, filter interface{}, query Query, f func(document T) error) error {
+	op := verrors.Op("mongo: Iterate")
+
+	parent := ctx
+	ctx, span := tracing.StartSpan(ctx, "MongoDB Iterate "+collection.name)
+	defer span.End()
+	span.AddAttributes(trace.StringAttribute("collection", collection.name))
+
+	timeoutCtx, cancel := context.WithTimeout(ctx, collection.timeout())
+	cursor, err := collection.collection().Find(timeoutCtx, filter, query.options())
+	cancel()
+	if err != nil {
+		return collection.error(op, span, err)
+	}
+	defer CloseCursor(cursor, parent)
+
+	documents := int64(0)
+	for {
+		timeoutCtx, cancel := context.WithTimeout(ctx, collection.timeout())
+		next := cursor.Next(timeoutCtx)
+		cancel()
+		if !next {
+			break
+		}
+
+		var document T
+		err = cursor.Decode(&document)
+		if err != nil {
+			return collection.error(op, span, err)
+		}
+
+		err = f(document)
+		if err != nil {
+			return err
+		}
+		documents++
+	}
+	span.AddAttributes(trace.Int64Attribute("documents", documents))
+
+	if cursor.Err() != nil {
+		return collection.error(op, span, cursor.Err())
+	}
+
+	return nil
+}
+
+// Count returns the number of documents matching the filter.
+func (collection *Collection[T]) Count(ctx context.Context, filter interface{}) (int64, error) {
+	op := verrors.Op("mongo: Count")
+
+	ctx, span, cancel := collection.start(ctx, "Count")
+	defer cancel()
+	defer span.End()
+
+	count, err := collection.collection().CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, collection.error(op, span, err)
+	}
+
+	return count, nil
+}
+
+// Upsert replaces the document matching the filter or inserts it, if there is none. It returns true, if the document
+// was inserted.
+func (collection *Collection[T]) Upsert(ctx context.Context, filter interface{}, document T) (bool, error) {
+	op := verrors.Op("mongo: Upsert")
+
+	ctx, span, cancel := collection.start(ctx, "Upsert")
+	defer cancel()
+	defer span.End()
+
+	result, err := collection.collection().ReplaceOne(ctx, filter, document, options.Replace().SetUpsert(true))
+	if err != nil {
+		return false, collection.error(op, span, err)
+	}
+
+	return result.UpsertedCount > 0, nil
+}
+
+// BulkWrite runs the write models in one request, ordered stops at the first failing write.
+func (collection *Collection[T]) BulkWrite(ctx context.Context, models []mongo.WriteModel, ordered bool) (*mongo.BulkWriteResult, error) {
+	op := verrors.Op("mongo: Bulk write")
+
+	ctx, span, cancel := collection.start(ctx, "Bulk write")
+	defer cancel()
+	defer span.End()
+	span.AddAttributes(trace.Int64Attribute("size", int64(len(models))), trace.BoolAttribute("ordered", ordered))
+
+	result, err := collection.collection().BulkWrite(ctx, models, options.BulkWrite().SetOrdered(ordered))
+	if err != nil {
+		return result, collection.error(op, span, err)
+	}
+
+	return result, nil
+}
+
+func (collection *Collection[T]) collection() *mongo.Collection {
+	return collection.db.Database.Collection(collection.name)
+}
+
+func (collection *Collection[T]) timeout() time.Duration {
+	return time.Duration(collection.db.Config.Timeout) * time.Second
+}
+
+// start starts the span of the operation and limits the context to the timeout
+func (collection *Collection[T]) start(ctx context.Context, name string) (context.Context, *trace.Span, context.CancelFunc) {
+
+	ctx, span := tracing.StartSpan(ctx, "MongoDB "+name+" "+collection.name)
+	span.AddAttributes(trace.StringAttribute("collection", collection.name))
+
+	ctx, cancel := context.WithTimeout(ctx, collection.timeout())
+
+	return ctx, span, cancel
+}
+
+// error maps the driver error to a verror and sets the status of the span, a missing document is no failure
+func (collection *Collection[T]) error(op verrors.Op, span *trace.Span, err error, infos ...verrors.Info) error {
+
+	mapped := mapError(op, err, append(infos, verrors.Info{Name: "collection", Val: collection.name})...)
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: err.Error()})
+	}
+
+	return mapped
+}
+
+// the code of the error of an operation exceeding its maxTimeMS
+const maxTimeMSExpired = 50
+
+func mapError(op verrors.Op, err error, infos ...verrors.Info) error {
+
+	var serverErr mongo.ServerError
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return verrors.E(op, err, verrors.HttpNotFound, infos)
+	case mongo.IsDuplicateKeyError(err):
+		return verrors.E(op, err, verrors.HttpConflict, infos)
+	case mongo.IsTimeout(err), errors.As(err, &serverErr) && serverErr.HasErrorCode(maxTimeMSExpired):
+		return verrors.E(op, err, verrors.HttpUnavailable, infos)
+	default:
+		return verrors.E(op, err, infos)
+	}
+}
+
+func (query Query) options() *options.FindOptions {
+
+	findOptions := options.Find()
+	if query.Sort != nil {
+		findOptions.SetSort(query.Sort)
+	}
+	if query.Skip > 0 {
+		findOptions.SetSkip(query.Skip)
+	}
+	if query.Limit > 0 {
+		findOptions.SetLimit(query.Limit)
+	}
+	if query.Projection != nil {
+		findOptions.SetProjection(query.Projection)
+	}
+
+	return findOptions
+}
@@ -0,0 +1,77 @@
+/*
+ *  collection_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	verrors "sheazuzu/common/src/errors"
+)
+
+func TestMapError(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		err  error
+		kind verrors.Kind
+	}{
+		"not found":         {err: mongo.ErrNoDocuments, kind: verrors.HttpNotFound},
+		"duplicate key":     {err: mongo.WriteException{WriteErrors: mongo.WriteErrors{{Code: 11000}}}, kind: verrors.HttpConflict},
+		"duplicate in bulk": {err: mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{{WriteError: mongo.WriteError{Code: 11000}}}}, kind: verrors.HttpConflict},
+		"context deadline":  {err: context.DeadlineExceeded, kind: verrors.HttpUnavailable},
+		"max time expired":  {err: mongo.CommandError{Code: 50, Name: "MaxTimeMSExpired"}, kind: verrors.HttpUnavailable},
+		"other":             {err: errors.New("other"), kind: verrors.Other},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := mapError("op", tc.err, verrors.Info{Name: "collection", Val: "c"})
+
+			assert.True(t, verrors.Is(err, verrors.Op("op"), tc.kind), err.Error())
+			assert.Equal(t, tc.err, errors.Unwrap(err))
+		})
+	}
+}
+
+func TestQuery_options(t *testing.T) {
+	t.Parallel()
+
+	sort := bson.D{{Key: "date", Value: 1}}
+	projection := bson.D{{Key: "id", Value: 1}}
+
+	cases := map[string]struct {
+		query    Query
+		expected *options.FindOptions
+	}{
+		"all documents": {
+			query:    Query{},
+			expected: options.Find(),
+		},
+		"everything set": {
+			query:    Query{Sort: sort, Skip: 10, Limit: 5, Projection: projection},
+			expected: options.Find().SetSort(sort).SetSkip(10).SetLimit(5).SetProjection(projection),
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, tc.query.options())
+		})
+	}
+}
@@ -14,13 +14,25 @@ const (
 )
 
 // Config contains properties needed for the configuration of the database connection.
+// With UseSSL the server certificate is verified against the CA bundle of SSLCAFile or, if it is empty, the system
+// roots. The client certificate is optional, its key may be encrypted with SSLClientKeyPassword. Without
+// SSLClientKeyFile the key is read from the certificate file, which holds both then. The files are reloaded, when they
+// change on disk.
 type Config struct {
-	URI               string
-	Database          string
-	Timeout           int
-	UseSSL            bool
-	SSLClientCertFile string
-	SSLClientKeyFile  string
+	URI                   string
+	Database              string
+	Timeout               int
+	UseSSL                bool
+	SSLCAFile             string
+	SSLClientCertFile     string
+	SSLClientKeyFile      string
+	SSLClientKeyPassword  string
+	SSLServerName         string
+	SSLInsecureSkipVerify bool
+	// DropUnknownIndexes drops the indexes, which are not declared, when reconciling the indexes
+	DropUnknownIndexes bool
+	// RedactCommands replaces the values in the command bodies of the tracing spans by '?'
+	RedactCommands bool
 }
 
 // BindConfig takes a Config and a FlagSet and stores the flags relevant for the mongo db connection in the corresponding config fields.
@@ -30,11 +42,18 @@ func BindConfig(config *Config, fs *flag.FlagSet) {
 	fs.StringVar(&config.Database, "mongo.database", "", "the mongodb database")
 	fs.IntVar(&config.Timeout, "mongo.timeout", DefaultTimeout, "the mongodb connection timeout in sec.")
 	fs.BoolVar(&config.UseSSL, "mongo.useSSL", false, "use SSL with mongo")
-	fs.StringVar(&config.SSLClientCertFile, "mongo.sslClientCertFile", "", "the mongodb sslClientCertFile")
-	fs.StringVar(&config.SSLClientKeyFile, "mongo.sslClientKeyFile", "", "the mongodb sslClientKeyFile")
+	fs.StringVar(&config.SSLCAFile, "mongo.sslCAFile", "", "the CA bundle (PEM) the server certificate is verified against, the system roots if empty")
+	fs.StringVar(&config.SSLClientCertFile, "mongo.sslClientCertFile", "", "the client certificate (PEM) for the authentication with the server")
+	fs.StringVar(&config.SSLClientKeyFile, "mongo.sslClientKeyFile", "", "the key (PEM) of the client certificate, read from the client certificate file if empty")
+	fs.StringVar(&config.SSLClientKeyPassword, "mongo.sslClientKeyPassword", "", "the password of the encrypted key of the client certificate")
+	fs.StringVar(&config.SSLServerName, "mongo.sslServerName", "", "the name the server certificate is verified for, the dialed host of the uri if empty")
+	fs.BoolVar(&config.SSLInsecureSkipVerify, "mongo.sslInsecureSkipVerify", false, "do not verify the server certificate, only for development")
+	fs.BoolVar(&config.DropUnknownIndexes, "mongo.dropUnknownIndexes", false, "drop the indexes of the collections, which are not declared by the service")
+	fs.BoolVar(&config.RedactCommands, "mongo.redactCommands", true, "replace the values in the command bodies of the tracing spans by '?'")
 }
 
-// IsValid checks if the config properties URI, Database and SSLClientCertFile (in case of UseSSL=true) are set.
+// IsValid checks if the config properties URI and Database are set and, in case of UseSSL=true, a key comes with its
+// client certificate.
 func (config *Config) IsValid() bool {
 
 	if config.URI == "" {
@@ -47,8 +66,8 @@ func (config *Config) IsValid() bool {
 		return false
 	}
 
-	if config.UseSSL && config.SSLClientCertFile == "" {
-		fmt.Println("please specify a path to the SSL client certificate file (PEM)")
+	if config.UseSSL && config.SSLClientKeyFile != "" && config.SSLClientCertFile == "" {
+		fmt.Println("please specify the SSL client certificate of the key")
 		return false
 	}
 
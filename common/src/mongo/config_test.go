@@ -36,6 +36,12 @@ func TestBindConfig(t *testing.T) {
 					"--mongo.useSSL",
 					"--mongo.sslClientCertFile", "myCertificate",
 					"--mongo.sslClientKeyFile", "myKey",
+					"--mongo.sslCAFile", "myCA",
+					"--mongo.sslClientKeyPassword", "myPassword",
+					"--mongo.sslServerName", "mongo.test.com",
+					"--mongo.sslInsecureSkipVerify",
+					"--mongo.dropUnknownIndexes",
+					"--mongo.redactCommands=false",
 				},
 			},
 			output: output{
@@ -47,6 +53,25 @@ func TestBindConfig(t *testing.T) {
 					assert.EqualValues(t, true, cfg.UseSSL)
 					assert.EqualValues(t, "myCertificate", cfg.SSLClientCertFile)
 					assert.EqualValues(t, "myKey", cfg.SSLClientKeyFile)
+					assert.EqualValues(t, "myCA", cfg.SSLCAFile)
+					assert.EqualValues(t, "myPassword", cfg.SSLClientKeyPassword)
+					assert.EqualValues(t, "mongo.test.com", cfg.SSLServerName)
+					assert.EqualValues(t, true, cfg.SSLInsecureSkipVerify)
+					assert.EqualValues(t, true, cfg.DropUnknownIndexes)
+					assert.EqualValues(t, false, cfg.RedactCommands)
+				},
+			},
+		},
+		"defaults": {
+			input: input{
+				args: []string{},
+			},
+			output: output{
+				err: false,
+				assert: func(cfg *Config) {
+					assert.EqualValues(t, DefaultTimeout, cfg.Timeout)
+					assert.EqualValues(t, false, cfg.UseSSL)
+					assert.EqualValues(t, true, cfg.RedactCommands)
 				},
 			},
 		},
@@ -148,6 +173,35 @@ func TestConfig_IsValid(t *testing.T) {
 				isValid: false,
 			},
 		},
+		"ssl without client certificate": {
+			input: input{
+				cfg: Config{
+					URI:       "mongodb://test.com",
+					Database:  "test",
+					Timeout:   10,
+					UseSSL:    true,
+					SSLCAFile: "myCA",
+				},
+			},
+			output: output{
+				isValid: true,
+			},
+		},
+		"success - ssl cert holding the key": {
+			input: input{
+				cfg: Config{
+					URI:               "mongodb://test.com",
+					Database:          "test",
+					Timeout:           10,
+					UseSSL:            true,
+					SSLClientCertFile: "myCertificate",
+					SSLClientKeyFile:  "",
+				},
+			},
+			output: output{
+				isValid: true,
+			},
+		},
 		"error - no ssl cert": {
 			input: input{
 				cfg: Config{
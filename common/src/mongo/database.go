@@ -7,10 +7,6 @@ package mongo
 
 import (
 	"context"
-	"crypto/tls"
-	"crypto/x509"
-	"fmt"
-	"io/ioutil"
 	"sheazuzu/common/src/utils"
 	"strings"
 	"time"
@@ -30,6 +26,7 @@ type Database struct {
 }
 
 // NewMongoDatabase creates a new mongo database with the passed options and returns a pointer to it.
+// Every command of the client is traced and the connection pool is exported as metrics, see metrics.RegisterMongoPoolViews.
 func NewMongoDatabase(config *Config, logger *zap.SugaredLogger, setOptions ...func(opts *options.ClientOptions)) (*Database, error) {
 
 	logger.Debug("Connecting to mongo db ...")
@@ -37,11 +34,16 @@ func NewMongoDatabase(config *Config, logger *zap.SugaredLogger, setOptions ...f
 	opts := options.Client().ApplyURI(config.URI)
 	if config.UseSSL {
 		logger.Debug("Using SSL certificates ...")
-		err := configureSSL(config, opts)
+		dialer, err := NewTLSDialer(config, logger)
 		if err != nil {
 			return nil, err
 		}
+		// the dialer establishes the TLS connections, so the driver must not start TLS on them again
+		opts.SetDialer(dialer)
+		opts.TLSConfig = nil
 	}
+	opts.SetMonitor(newCommandMonitor(config.RedactCommands))
+	opts.SetPoolMonitor(newPoolMonitor())
 
 	for _, opt := range setOptions {
 		opt(opts)
@@ -130,8 +132,8 @@ func (db *Database) createIndex(ctx context.Context, collectionName string, inde
 }
 
 // InstallIndex installs an index for the given collection, if it does not exist yet.
-// An already existing index will not be overwritten.
-func (db *Database) InstallIndex(collectionName string, name string, keys bson.D) error {
+// An already existing index will not be overwritten. The options of the index, e.g. uniqueness, can be set with setOptions.
+func (db *Database) InstallIndex(collectionName string, name string, keys bson.D, setOptions ...func(opts *options.IndexOptions)) error {
 
 	db.Logger.Infof("Installing mongo db index '%s' in collection '%s'...", name, collectionName)
 
@@ -150,6 +152,9 @@ func (db *Database) InstallIndex(collectionName string, name string, keys bson.D
 				Background: utils.ToBoolPtr(true), // create the index in the background to avoid any blocking
 			},
 		}
+		for _, opt := range setOptions {
+			opt(index.Options)
+		}
 
 		// new context with new timeout for this second operation
 		ctx, _ := context.WithTimeout(context.Background(), time.Duration(db.Config.Timeout)*time.Second)
@@ -162,40 +167,6 @@ func (db *Database) InstallIndex(collectionName string, name string, keys bson.D
 	return nil
 }
 
-func configureSSL(config *Config, opts *options.ClientOptions) error {
-	// User provided both certificates, so its a client and a key cert
-	if config.SSLClientCertFile != "" && config.SSLClientKeyFile != "" {
-		cert, err := tls.LoadX509KeyPair(config.SSLClientCertFile, config.SSLClientKeyFile)
-		if err != nil {
-			return errors.Wrap(err, "Error creating tls config")
-		}
-
-		opts.SetTLSConfig(&tls.Config{
-			Certificates:       []tls.Certificate{cert},
-			InsecureSkipVerify: true,
-		})
-	} else {
-		// User provided only a client certificate
-		rootPEM, err := ioutil.ReadFile(config.SSLClientCertFile)
-		if err != nil {
-			return errors.Wrap(err, "could not read ssl client cert")
-		}
-
-		roots := x509.NewCertPool()
-		ok := roots.AppendCertsFromPEM(rootPEM)
-		if !ok {
-			return fmt.Errorf("could not parse certificate")
-		}
-
-		opts.SetTLSConfig(&tls.Config{
-			ClientCAs:          roots,
-			InsecureSkipVerify: true,
-		})
-	}
-
-	return nil
-}
-
 // CloseCursor closes the cursor and ignores the error. Can be used with defer.
 func CloseCursor(cur *mongo.Cursor, ctx context.Context) {
 	_ = cur.Close(ctx)
@@ -0,0 +1,443 @@
+/*
+ *  index.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Index declares an index of a collection. A text index has the value "text" for its text fields in Keys, their
+// Weights default to 1. An index with ExpireAfter is a TTL index, its single key must be a date field.
+type Index struct {
+	Collection    string
+	Name          string
+	Keys          bson.D
+	Unique        bool
+	ExpireAfter   time.Duration
+	PartialFilter bson.D
+	Weights       bson.D
+}
+
+// IndexRegistry collects the indexes the collections declare, see Database.ReconcileIndexes.
+type IndexRegistry struct {
+	indexes []Index
+}
+
+func NewIndexRegistry() *IndexRegistry {
+	return &IndexRegistry{}
+}
+
+// Register declares the indexes. An index needs a collection, a name unique in its collection and keys.
+func (registry *IndexRegistry) Register(indexes ...Index) error {
+
+	for _, index := range indexes {
+		if index.Collection == "" || index.Name == "" || len(index.Keys) == 0 {
+			return fmt.Errorf("the index '%s' of collection '%s' needs a collection, a name and keys", index.Name, index.Collection)
+		}
+		if index.Name == idIndexName {
+			return fmt.Errorf("the index '%s' of collection '%s' is managed by MongoDB", index.Name, index.Collection)
+		}
+		for _, registered := range registry.indexes {
+			if registered.Collection == index.Collection && registered.Name == index.Name {
+				return fmt.Errorf("the index '%s' of collection '%s' is already registered", index.Name, index.Collection)
+			}
+		}
+		registry.indexes = append(registry.indexes, index)
+	}
+
+	return nil
+}
+
+// Indexes returns the declared indexes in the order of their registration.
+func (registry *IndexRegistry) Indexes() []Index {
+	return append([]Index(nil), registry.indexes...)
+}
+
+// IndexAction is the change of an existing index needed to match the declared index.
+type IndexAction string
+
+const (
+	IndexUnchanged IndexAction = "unchanged"
+	IndexCreate    IndexAction = "create"
+	IndexRecreate  IndexAction = "recreate"
+	IndexDrop      IndexAction = "drop"
+	// an existing index, which is not declared and kept
+	IndexUnknown IndexAction = "unknown"
+)
+
+// IndexChange is a line of the report of ReconcileIndexes. Existing is the name of the existing index, it differs from
+// the name of the declared index, if an index with the same keys exists under another name.
+type IndexChange struct {
+	Collection string
+	Name       string
+	Existing   string
+	Action     IndexAction
+	Reason     string
+}
+
+// ReconcileOptions controls ReconcileIndexes. DropUnknown drops the existing indexes of a collection with declared
+// indexes, which are not declared. With DryRun the report is returned without changing any index.
+type ReconcileOptions struct {
+	DropUnknown bool
+	DryRun      bool
+}
+
+// the index of the _id field, MongoDB creates it with every collection
+const idIndexName = "_id_"
+
+// ReconcileIndexes compares the declared indexes with the existing indexes of their collections. It creates the
+// missing indexes, recreates the indexes whose keys or options changed and, with DropUnknown, drops the indexes which
+// are not declared. The returned report lists the change of every index, also if an error stopped the reconciliation.
+// The indexes of collections without declared indexes are never changed.
+func (db *Database) ReconcileIndexes(ctx context.Context, registry *IndexRegistry, reconcileOptions ReconcileOptions) ([]IndexChange, error) {
+
+	declared := registry.Indexes()
+
+	var collections []string
+	byCollection := map[string][]Index{}
+	for _, index := range declared {
+		if _, ok := byCollection[index.Collection]; !ok {
+			collections = append(collections, index.Collection)
+		}
+		byCollection[index.Collection] = append(byCollection[index.Collection], index)
+	}
+
+	var report []IndexChange
+	for _, collection := range collections {
+		existing, err := db.listIndexes(ctx, collection)
+		if err != nil {
+			return report, err
+		}
+
+		changes := diffIndexes(collection, byCollection[collection], existing, reconcileOptions.DropUnknown)
+		report = append(report, changes...)
+
+		if reconcileOptions.DryRun {
+			continue
+		}
+
+		err = db.applyIndexChanges(ctx, byCollection[collection], changes)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
+
+// listIndexes returns the specifications of the indexes of the collection, an empty list if it does not exist yet
+func (db *Database) listIndexes(ctx context.Context, collection string) ([]bson.D, error) {
+
+	maxTime := time.Duration(db.Config.Timeout) * time.Second
+
+	cur, err := db.Database.Collection(collection).Indexes().List(ctx, options.ListIndexes().SetMaxTime(maxTime))
+	var commandErr mongo.CommandError
+	if errors.As(err, &commandErr) && commandErr.Name == "NamespaceNotFound" {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list the indexes in collection '%s'", collection)
+	}
+	defer CloseCursor(cur, ctx)
+
+	var indexes []bson.D
+	err = cur.All(ctx, &indexes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode the indexes in collection '%s'", collection)
+	}
+
+	return indexes, nil
+}
+
+// applyIndexChanges drops the indexes first, as an index with the same keys cannot be created under another name
+func (db *Database) applyIndexChanges(ctx context.Context, declared []Index, changes []IndexChange) error {
+
+	maxTime := time.Duration(db.Config.Timeout) * time.Second
+
+	for _, change := range changes {
+		if change.Action != IndexDrop && change.Action != IndexRecreate {
+			continue
+		}
+
+		db.Logger.Infof("Dropping mongo db index '%s' in collection '%s': %s", change.Existing, change.Collection, change.Reason)
+		_, err := db.Database.Collection(change.Collection).Indexes().DropOne(ctx, change.Existing, options.DropIndexes().SetMaxTime(maxTime))
+		var commandErr mongo.CommandError
+		if errors.As(err, &commandErr) && commandErr.Name == "IndexNotFound" {
+			// another instance dropped it concurrently
+			err = nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "failed to drop index '%s' in collection '%s'", change.Existing, change.Collection)
+		}
+	}
+
+	for _, index := range declared {
+		change := changes[indexOfChange(changes, index.Name)]
+		if change.Action != IndexCreate && change.Action != IndexRecreate {
+			continue
+		}
+
+		db.Logger.Infof("Creating mongo db index '%s' in collection '%s': %s", change.Name, change.Collection, change.Reason)
+		model := indexModel(index)
+		err := db.createIndex(ctx, change.Collection, &model)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create index '%s' in collection '%s'", change.Name, change.Collection)
+		}
+	}
+
+	return nil
+}
+
+func indexOfChange(changes []IndexChange, name string) int {
+	for i, change := range changes {
+		if change.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// diffIndexes returns the change of every declared index in the order of the declaration, followed by the existing
+// indexes which are not declared
+func diffIndexes(collection string, declared []Index, existing []bson.D, dropUnknown bool) []IndexChange {
+
+	// the existing indexes, which are not matched with a declared index yet
+	unmatched := map[string]bson.D{}
+	for _, spec := range existing {
+		name, _ := spec.Map()["name"].(string)
+		if name != idIndexName {
+			unmatched[name] = spec
+		}
+	}
+
+	var changes []IndexChange
+	for _, index := range declared {
+		expected := indexSpec(index)
+
+		spec, ok := unmatched[index.Name]
+		if ok {
+			delete(unmatched, index.Name)
+
+			change := IndexChange{Collection: collection, Name: index.Name, Existing: index.Name, Action: IndexUnchanged}
+			if reason := specDifference(expected, spec); reason != "" {
+				change.Action = IndexRecreate
+				change.Reason = reason
+			}
+			changes = append(changes, change)
+			continue
+		}
+
+		// an index with the same keys under another name prevents creating the index
+		renamed := ""
+		for name, spec := range unmatched {
+			if sameValue(spec.Map()["key"], expected.Map()["key"]) {
+				renamed = name
+				break
+			}
+		}
+		if renamed != "" {
+			delete(unmatched, renamed)
+			changes = append(changes, IndexChange{Collection: collection, Name: index.Name, Existing: renamed, Action: IndexRecreate, Reason: fmt.Sprintf("renamed from '%s'", renamed)})
+			continue
+		}
+
+		changes = append(changes, IndexChange{Collection: collection, Name: index.Name, Action: IndexCreate, Reason: "missing"})
+	}
+
+	var unknown []string
+	for name := range unmatched {
+		unknown = append(unknown, name)
+	}
+	sort.Strings(unknown)
+
+	for _, name := range unknown {
+		change := IndexChange{Collection: collection, Name: name, Existing: name, Action: IndexUnknown, Reason: "not declared"}
+		if dropUnknown {
+			change.Action = IndexDrop
+		}
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// the options of an index specification, which are compared with the declared index
+var specOptions = []string{"unique", "expireAfterSeconds", "partialFilterExpression", "weights"}
+
+// specDifference describes the first difference of the expected to the existing index specification, it is empty if
+// they are the same
+func specDifference(expected bson.D, existing bson.D) string {
+
+	expectedMap, existingMap := expected.Map(), existing.Map()
+
+	if !sameValue(expectedMap["key"], existingMap["key"]) {
+		return fmt.Sprintf("keys changed from %v to %v", existingMap["key"], expectedMap["key"])
+	}
+
+	// MongoDB does not keep the order of the weights
+	for _, spec := range []bson.M{expectedMap, existingMap} {
+		if weights, ok := spec["weights"].(bson.D); ok {
+			spec["weights"] = sortedDocument(weights)
+		}
+	}
+
+	for _, option := range specOptions {
+		if !sameValue(expectedMap[option], existingMap[option]) {
+			return fmt.Sprintf("%s changed from %v to %v", option, existingMap[option], expectedMap[option])
+		}
+	}
+
+	return ""
+}
+
+// indexSpec returns the specification of the index like MongoDB lists it. MongoDB stores the fields of a text index
+// as the keys _fts and _ftsx in place of the first text field and their weights separately.
+func indexSpec(index Index) bson.D {
+
+	keys := bson.D{}
+	weights := bson.D{}
+	for _, key := range index.Keys {
+		if key.Value != "text" {
+			keys = append(keys, key)
+			continue
+		}
+		if len(weights) == 0 {
+			keys = append(keys, bson.E{Key: "_fts", Value: "text"}, bson.E{Key: "_ftsx", Value: 1})
+		}
+		weights = append(weights, bson.E{Key: key.Key, Value: 1})
+	}
+	for _, weight := range index.Weights {
+		for i := range weights {
+			if weights[i].Key == weight.Key {
+				weights[i].Value = weight.Value
+			}
+		}
+	}
+
+	spec := bson.D{{Key: "name", Value: index.Name}, {Key: "key", Value: keys}}
+	if index.Unique {
+		spec = append(spec, bson.E{Key: "unique", Value: true})
+	}
+	if index.ExpireAfter > 0 {
+		spec = append(spec, bson.E{Key: "expireAfterSeconds", Value: int64(index.ExpireAfter / time.Second)})
+	}
+	if len(index.PartialFilter) > 0 {
+		spec = append(spec, bson.E{Key: "partialFilterExpression", Value: index.PartialFilter})
+	}
+	if len(weights) > 0 {
+		spec = append(spec, bson.E{Key: "weights", Value: weights})
+	}
+
+	return spec
+}
+
+func indexModel(index Index) mongo.IndexModel {
+
+	indexOptions := options.Index().SetName(index.Name).SetBackground(true)
+	if index.Unique {
+		indexOptions.SetUnique(true)
+	}
+	if index.ExpireAfter > 0 {
+		indexOptions.SetExpireAfterSeconds(int32(index.ExpireAfter / time.Second))
+	}
+	if len(index.PartialFilter) > 0 {
+		indexOptions.SetPartialFilterExpression(index.PartialFilter)
+	}
+	if len(index.Weights) > 0 {
+		indexOptions.SetWeights(index.Weights)
+	}
+
+	return mongo.IndexModel{Keys: index.Keys, Options: indexOptions}
+}
+
+// sameValue compares the values of index specifications. MongoDB returns the numbers in other types than they were
+// declared, e.g. 1 as int32 or float64, and a missing unique option is the same as false.
+func sameValue(a, b interface{}) bool {
+
+	if a == nil || b == nil {
+		return isZero(a) && isZero(b)
+	}
+
+	if x, ok := toFloat(a); ok {
+		y, ok := toFloat(b)
+		return ok && x == y
+	}
+
+	x, aIsDocument := a.(bson.D)
+	y, bIsDocument := b.(bson.D)
+	if aIsDocument || bIsDocument {
+		if !aIsDocument || !bIsDocument || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if x[i].Key != y[i].Key || !sameValue(x[i].Value, y[i].Value) {
+				return false
+			}
+		}
+		return true
+	}
+
+	k, aIsArray := a.(bson.A)
+	l, bIsArray := b.(bson.A)
+	if aIsArray || bIsArray {
+		if !aIsArray || !bIsArray || len(k) != len(l) {
+			return false
+		}
+		for i := range k {
+			if !sameValue(k[i], l[i]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func sortedDocument(document bson.D) bson.D {
+	sorted := append(bson.D(nil), document...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func isZero(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case bool:
+		return !v
+	case bson.D:
+		return len(v) == 0
+	default:
+		return false
+	}
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ *  index_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// existingIndex returns an index specification like MongoDB lists it, with the numbers as int32 and the version
+func existingIndex(name string, key bson.D, options ...bson.E) bson.D {
+	return append(bson.D{{Key: "v", Value: int32(2)}, {Key: "key", Value: key}, {Key: "name", Value: name}}, options...)
+}
+
+func TestDiffIndexes(t *testing.T) {
+	t.Parallel()
+
+	idIndex := existingIndex("_id_", bson.D{{Key: "_id", Value: int32(1)}})
+	unique := Index{Collection: "c", Name: "id", Keys: bson.D{{Key: "id", Value: 1}}, Unique: true}
+	compound := Index{Collection: "c", Name: "date_id", Keys: bson.D{{Key: "date", Value: 1}, {Key: "id", Value: -1}}}
+	ttl := Index{Collection: "c", Name: "expires", Keys: bson.D{{Key: "createdAt", Value: 1}}, ExpireAfter: time.Hour}
+	partial := Index{Collection: "c", Name: "played", Keys: bson.D{{Key: "date", Value: 1}}, PartialFilter: bson.D{{Key: "result", Value: bson.D{{Key: "$exists", Value: true}}}}}
+	text := Index{Collection: "c", Name: "teams", Keys: bson.D{{Key: "season", Value: 1}, {Key: "hometeam", Value: "text"}, {Key: "awayteam", Value: "text"}}, Weights: bson.D{{Key: "hometeam", Value: 2}}}
+
+	cases := map[string]struct {
+		declared    []Index
+		existing    []bson.D
+		dropUnknown bool
+		expected    []IndexChange
+	}{
+		"missing collection": {
+			declared: []Index{unique, compound},
+			expected: []IndexChange{
+				{Collection: "c", Name: "id", Action: IndexCreate, Reason: "missing"},
+				{Collection: "c", Name: "date_id", Action: IndexCreate, Reason: "missing"},
+			},
+		},
+		"unchanged": {
+			declared: []Index{unique, compound, ttl, partial, text},
+			existing: []bson.D{
+				idIndex,
+				existingIndex("id", bson.D{{Key: "id", Value: int32(1)}}, bson.E{Key: "unique", Value: true}),
+				existingIndex("date_id", bson.D{{Key: "date", Value: int32(1)}, {Key: "id", Value: int32(-1)}}),
+				existingIndex("expires", bson.D{{Key: "createdAt", Value: int32(1)}}, bson.E{Key: "expireAfterSeconds", Value: int32(3600)}),
+				existingIndex("played", bson.D{{Key: "date", Value: int32(1)}}, bson.E{Key: "partialFilterExpression", Value: bson.D{{Key: "result", Value: bson.D{{Key: "$exists", Value: true}}}}}),
+				existingIndex("teams",
+					bson.D{{Key: "season", Value: int32(1)}, {Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}},
+					bson.E{Key: "weights", Value: bson.D{{Key: "awayteam", Value: int32(1)}, {Key: "hometeam", Value: int32(2)}}},
+					bson.E{Key: "default_language", Value: "english"},
+					bson.E{Key: "textIndexVersion", Value: int32(3)},
+				),
+			},
+			expected: []IndexChange{
+				{Collection: "c", Name: "id", Existing: "id", Action: IndexUnchanged},
+				{Collection: "c", Name: "date_id", Existing: "date_id", Action: IndexUnchanged},
+				{Collection: "c", Name: "expires", Existing: "expires", Action: IndexUnchanged},
+				{Collection: "c", Name: "played", Existing: "played", Action: IndexUnchanged},
+				{Collection: "c", Name: "teams", Existing: "teams", Action: IndexUnchanged},
+			},
+		},
+		"changed keys": {
+			declared: []Index{compound},
+			existing: []bson.D{existingIndex("date_id", bson.D{{Key: "date", Value: int32(1)}, {Key: "id", Value: int32(1)}})},
+			expected: []IndexChange{
+				{Collection: "c", Name: "date_id", Existing: "date_id", Action: IndexRecreate, Reason: "keys changed from [{date 1} {id 1}] to [{date 1} {id -1}]"},
+			},
+		},
+		"changed options": {
+			declared: []Index{unique, ttl, text},
+			existing: []bson.D{
+				existingIndex("id", bson.D{{Key: "id", Value: int32(1)}}),
+				existingIndex("expires", bson.D{{Key: "createdAt", Value: int32(1)}}, bson.E{Key: "expireAfterSeconds", Value: int32(60)}),
+				existingIndex("teams",
+					bson.D{{Key: "season", Value: int32(1)}, {Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}},
+					bson.E{Key: "weights", Value: bson.D{{Key: "awayteam", Value: int32(1)}, {Key: "hometeam", Value: int32(1)}}},
+				),
+			},
+			expected: []IndexChange{
+				{Collection: "c", Name: "id", Existing: "id", Action: IndexRecreate, Reason: "unique changed from <nil> to true"},
+				{Collection: "c", Name: "expires", Existing: "expires", Action: IndexRecreate, Reason: "expireAfterSeconds changed from 60 to 3600"},
+				{Collection: "c", Name: "teams", Existing: "teams", Action: IndexRecreate, Reason: "weights changed from [{awayteam 1} {hometeam 1}] to [{awayteam 1} {hometeam 2}]"},
+			},
+		},
+		"renamed": {
+			declared: []Index{unique},
+			existing: []bson.D{idIndex, existingIndex("id_1", bson.D{{Key: "id", Value: int32(1)}}, bson.E{Key: "unique", Value: true})},
+			expected: []IndexChange{
+				{Collection: "c", Name: "id", Existing: "id_1", Action: IndexRecreate, Reason: "renamed from 'id_1'"},
+			},
+		},
+		"unknown are kept": {
+			declared: []Index{unique},
+			existing: []bson.D{
+				idIndex,
+				existingIndex("id", bson.D{{Key: "id", Value: int32(1)}}, bson.E{Key: "unique", Value: true}),
+				existingIndex("old", bson.D{{Key: "result", Value: int32(1)}}),
+			},
+			expected: []IndexChange{
+				{Collection: "c", Name: "id", Existing: "id", Action: IndexUnchanged},
+				{Collection: "c", Name: "old", Existing: "old", Action: IndexUnknown, Reason: "not declared"},
+			},
+		},
+		"unknown are dropped": {
+			declared: []Index{unique},
+			existing: []bson.D{
+				idIndex,
+				existingIndex("old", bson.D{{Key: "result", Value: int32(1)}}),
+			},
+			dropUnknown: true,
+			expected: []IndexChange{
+				{Collection: "c", Name: "id", Action: IndexCreate, Reason: "missing"},
+				{Collection: "c", Name: "old", Existing: "old", Action: IndexDrop, Reason: "not declared"},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, diffIndexes("c", tc.declared, tc.existing, tc.dropUnknown))
+		})
+	}
+}
+
+func TestIndexRegistry_Register(t *testing.T) {
+	t.Parallel()
+
+	keys := bson.D{{Key: "id", Value: 1}}
+
+	cases := map[string]struct {
+		indexes []Index
+		err     bool
+	}{
+		"valid":              {indexes: []Index{{Collection: "a", Name: "id", Keys: keys}, {Collection: "b", Name: "id", Keys: keys}}},
+		"duplicate name":     {indexes: []Index{{Collection: "a", Name: "id", Keys: keys}, {Collection: "a", Name: "id", Keys: keys}}, err: true},
+		"missing collection": {indexes: []Index{{Name: "id", Keys: keys}}, err: true},
+		"missing name":       {indexes: []Index{{Collection: "a", Keys: keys}}, err: true},
+		"missing keys":       {indexes: []Index{{Collection: "a", Name: "id"}}, err: true},
+		"id index":           {indexes: []Index{{Collection: "a", Name: "_id_", Keys: keys}}, err: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			registry := NewIndexRegistry()
+			err := registry.Register(tc.indexes...)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.indexes, registry.Indexes())
+			}
+		})
+	}
+}
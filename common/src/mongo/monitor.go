@@ -0,0 +1,212 @@
+/*
+ *  monitor.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opencensus.io/trace"
+	"sheazuzu/common/src/metrics"
+)
+
+// the maximum length of the command body added to a span, longer ones are cut
+const maxStatementLength = 2048
+
+// the fields the driver adds to every command, they are left out of the statement of the span
+var driverFields = map[string]bool{"lsid": true, "$clusterTime": true, "$db": true, "txnNumber": true}
+
+// commandTracer traces the commands sent by the driver. Every command becomes a child span of the span of the
+// context of its operation.
+type commandTracer struct {
+	redact bool
+	spans  sync.Map
+}
+
+// newCommandMonitor returns the monitor tracing the commands, with redact the values in the command bodies are
+// replaced by '?'
+func newCommandMonitor(redact bool) *event.CommandMonitor {
+
+	tracer := &commandTracer{redact: redact}
+
+	return &event.CommandMonitor{
+		Started:   tracer.started,
+		Succeeded: tracer.succeeded,
+		Failed:    tracer.failed,
+	}
+}
+
+// the request ids are unique within the client, the connection is added for safety
+type commandKey struct {
+	connectionID string
+	requestID    int64
+}
+
+func (tracer *commandTracer) started(ctx context.Context, evt *event.CommandStartedEvent) {
+
+	_, span := trace.StartSpan(ctx, "MongoDB command "+evt.CommandName, trace.WithSpanKind(trace.SpanKindClient))
+	span.AddAttributes(
+		trace.StringAttribute("command", evt.CommandName),
+		trace.StringAttribute("database", evt.DatabaseName),
+		trace.StringAttribute("connection", evt.ConnectionID),
+	)
+
+	if collection := commandCollection(evt.Command); collection != "" {
+		span.AddAttributes(trace.StringAttribute("collection", collection))
+	}
+
+	// the driver leaves the body of security relevant commands, e.g. of the authentication, empty
+	if len(evt.Command) > 0 {
+		span.AddAttributes(trace.StringAttribute("statement", commandStatement(evt.Command, tracer.redact)))
+	}
+
+	tracer.spans.Store(commandKey{connectionID: evt.ConnectionID, requestID: evt.RequestID}, span)
+}
+
+func (tracer *commandTracer) succeeded(_ context.Context, evt *event.CommandSucceededEvent) {
+
+	span := tracer.finish(evt.CommandFinishedEvent)
+	if span == nil {
+		return
+	}
+
+	span.SetStatus(trace.Status{Code: trace.StatusCodeOK})
+	span.End()
+}
+
+func (tracer *commandTracer) failed(_ context.Context, evt *event.CommandFailedEvent) {
+
+	span := tracer.finish(evt.CommandFinishedEvent)
+	if span == nil {
+		return
+	}
+
+	span.SetStatus(trace.Status{Code: trace.StatusCodeUnknown, Message: evt.Failure})
+	span.End()
+}
+
+// finish returns the span of the command with its duration, nil if the start of the command was not seen
+func (tracer *commandTracer) finish(evt event.CommandFinishedEvent) *trace.Span {
+
+	value, ok := tracer.spans.LoadAndDelete(commandKey{connectionID: evt.ConnectionID, requestID: evt.RequestID})
+	if !ok {
+		return nil
+	}
+
+	span := value.(*trace.Span)
+	span.AddAttributes(trace.Int64Attribute("duration_ms", evt.Duration.Milliseconds()))
+
+	return span
+}
+
+// commandCollection returns the collection of the command, i.e. the value of its first field, e.g. of
+// {find: "matches"}, or of its collection field, e.g. of {getMore: 1, collection: "matches"}. Commands of the
+// database, e.g. {ping: 1}, have none.
+func commandCollection(command bson.Raw) string {
+
+	elements, err := command.Elements()
+	if err != nil || len(elements) == 0 {
+		return ""
+	}
+
+	if collection, ok := elements[0].Value().StringValueOK(); ok {
+		return collection
+	}
+
+	if collection, ok := command.Lookup("collection").StringValueOK(); ok {
+		return collection
+	}
+
+	return ""
+}
+
+// commandStatement returns the command as extended JSON without the fields of the driver, with redact its values are
+// replaced by '?'
+func commandStatement(command bson.Raw, redact bool) string {
+
+	elements, err := command.Elements()
+	if err != nil {
+		return "invalid command"
+	}
+
+	document := bson.D{}
+	for i, element := range elements {
+		if driverFields[element.Key()] {
+			continue
+		}
+
+		// the first field names the command and its collection, it is kept for the span to be readable
+		if redact && i > 0 {
+			document = append(document, bson.E{Key: element.Key(), Value: redactValue(element.Value())})
+		} else {
+			document = append(document, bson.E{Key: element.Key(), Value: element.Value()})
+		}
+	}
+
+	statement, err := bson.MarshalExtJSON(document, false, false)
+	if err != nil {
+		return "invalid command"
+	}
+
+	if len(statement) > maxStatementLength {
+		return string(statement[:maxStatementLength]) + "..."
+	}
+
+	return string(statement)
+}
+
+// redactValue replaces the values of the documents and arrays by '?', the keys are kept
+func redactValue(value bson.RawValue) interface{} {
+
+	if document, ok := value.DocumentOK(); ok {
+		elements, _ := document.Elements()
+		redacted := bson.D{}
+		for _, element := range elements {
+			redacted = append(redacted, bson.E{Key: element.Key(), Value: redactValue(element.Value())})
+		}
+		return redacted
+	}
+
+	if array, ok := value.ArrayOK(); ok {
+		values, _ := array.Values()
+		redacted := bson.A{}
+		for _, value := range values {
+			redacted = append(redacted, redactValue(value))
+		}
+		return redacted
+	}
+
+	return "?"
+}
+
+// newPoolMonitor returns the monitor recording the connections and check-outs of the connection pool
+func newPoolMonitor() *event.PoolMonitor {
+
+	checkedOut := int64(0)
+
+	return &event.PoolMonitor{
+		Event: func(evt *event.PoolEvent) {
+			ctx := context.Background()
+
+			switch evt.Type {
+			case event.ConnectionCreated:
+				metrics.RecordPoolConnectionCreated(ctx)
+			case event.ConnectionClosed:
+				metrics.RecordPoolConnectionClosed(ctx, evt.Reason)
+			case event.GetSucceeded:
+				metrics.RecordPoolCheckOut(ctx, metrics.CheckOutSucceeded, evt.Duration, atomic.AddInt64(&checkedOut, 1))
+			case event.GetFailed:
+				metrics.RecordPoolCheckOut(ctx, metrics.CheckOutFailed, evt.Duration, atomic.LoadInt64(&checkedOut))
+			case event.ConnectionReturned:
+				metrics.RecordPoolCheckIn(ctx, atomic.AddInt64(&checkedOut, -1))
+			}
+		},
+	}
+}
@@ -0,0 +1,168 @@
+/*
+ *  monitor_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/event"
+	"go.opencensus.io/trace"
+)
+
+func marshal(t *testing.T, document bson.D) bson.Raw {
+	raw, err := bson.Marshal(document)
+	require.NoError(t, err)
+	return raw
+}
+
+func TestCommandCollection(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		command  bson.D
+		expected string
+	}{
+		"find":     {command: bson.D{{Key: "find", Value: "matches"}, {Key: "filter", Value: bson.D{}}}, expected: "matches"},
+		"get more": {command: bson.D{{Key: "getMore", Value: int64(42)}, {Key: "collection", Value: "matches"}}, expected: "matches"},
+		"database": {command: bson.D{{Key: "ping", Value: 1}}, expected: ""},
+		"empty":    {command: bson.D{}, expected: ""},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, commandCollection(marshal(t, tc.command)))
+		})
+	}
+}
+
+func TestCommandStatement(t *testing.T) {
+	t.Parallel()
+
+	command := bson.D{
+		{Key: "update", Value: "matches"},
+		{Key: "updates", Value: bson.A{bson.D{{Key: "q", Value: bson.D{{Key: "id", Value: 7}}}, {Key: "u", Value: bson.D{{Key: "hometeam", Value: "Wolfsburg"}}}}}},
+		{Key: "ordered", Value: true},
+		{Key: "lsid", Value: bson.D{{Key: "id", Value: "session"}}},
+		{Key: "$db", Value: "sheazuzu"},
+	}
+
+	cases := map[string]struct {
+		command  bson.D
+		redact   bool
+		expected string
+	}{
+		"plain": {
+			command:  command,
+			expected: `{"update":"matches","updates":[{"q":{"id":7},"u":{"hometeam":"Wolfsburg"}}],"ordered":true}`,
+		},
+		"redacted": {
+			command:  command,
+			redact:   true,
+			expected: `{"update":"matches","updates":[{"q":{"id":"?"},"u":{"hometeam":"?"}}],"ordered":"?"}`,
+		},
+		"cut": {
+			command:  bson.D{{Key: "insert", Value: "matches"}, {Key: "comment", Value: strings.Repeat("a", maxStatementLength)}},
+			expected: `{"insert":"matches","comment":"` + strings.Repeat("a", maxStatementLength-len(`{"insert":"matches","comment":"`)) + "...",
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.expected, commandStatement(marshal(t, tc.command), tc.redact))
+		})
+	}
+}
+
+// spanRecorder records the spans ended with the name
+type spanRecorder struct {
+	prefix string
+	mutex  sync.Mutex
+	spans  []*trace.SpanData
+}
+
+func (recorder *spanRecorder) ExportSpan(span *trace.SpanData) {
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if strings.HasPrefix(span.Name, recorder.prefix) {
+		recorder.spans = append(recorder.spans, span)
+	}
+}
+
+func TestCommandMonitor(t *testing.T) {
+
+	recorder := &spanRecorder{prefix: "MongoDB command"}
+	trace.RegisterExporter(recorder)
+	defer trace.UnregisterExporter(recorder)
+
+	// the commands are sampled with their operation
+	ctx, parent := trace.StartSpan(context.Background(), "operation", trace.WithSampler(trace.AlwaysSample()))
+	defer parent.End()
+
+	monitor := newCommandMonitor(true)
+
+	monitor.Started(ctx, &event.CommandStartedEvent{
+		Command:      marshal(t, bson.D{{Key: "find", Value: "matches"}, {Key: "filter", Value: bson.D{{Key: "id", Value: 7}}}}),
+		DatabaseName: "sheazuzu",
+		CommandName:  "find",
+		RequestID:    1,
+		ConnectionID: "mongo:27017[-1]",
+	})
+	monitor.Started(ctx, &event.CommandStartedEvent{
+		Command:      marshal(t, bson.D{{Key: "insert", Value: "matches"}}),
+		DatabaseName: "sheazuzu",
+		CommandName:  "insert",
+		RequestID:    2,
+		ConnectionID: "mongo:27017[-2]",
+	})
+
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 1, ConnectionID: "mongo:27017[-1]", Duration: 12 * time.Millisecond},
+	})
+	monitor.Failed(ctx, &event.CommandFailedEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "insert", RequestID: 2, ConnectionID: "mongo:27017[-2]", Duration: 3 * time.Millisecond},
+		Failure:              "duplicate key",
+	})
+	// a command finished without its start is ignored
+	monitor.Succeeded(ctx, &event.CommandSucceededEvent{
+		CommandFinishedEvent: event.CommandFinishedEvent{CommandName: "find", RequestID: 3, ConnectionID: "mongo:27017[-1]"},
+	})
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	require.Len(t, recorder.spans, 2)
+
+	find, insert := recorder.spans[0], recorder.spans[1]
+
+	assert.Equal(t, "MongoDB command find", find.Name)
+	assert.Equal(t, parent.SpanContext().SpanID, find.ParentSpanID)
+	assert.Equal(t, trace.SpanKindClient, find.SpanKind)
+	assert.Equal(t, int32(trace.StatusCodeOK), find.Status.Code)
+	assert.Equal(t, "matches", find.Attributes["collection"])
+	assert.Equal(t, "sheazuzu", find.Attributes["database"])
+	assert.Equal(t, `{"find":"matches","filter":{"id":"?"}}`, find.Attributes["statement"])
+	assert.Equal(t, int64(12), find.Attributes["duration_ms"])
+
+	assert.Equal(t, "MongoDB command insert", insert.Name)
+	assert.Equal(t, int32(trace.StatusCodeUnknown), insert.Status.Code)
+	assert.Equal(t, "duplicate key", insert.Status.Message)
+	assert.Equal(t, int64(3), insert.Attributes["duration_ms"])
+}
@@ -0,0 +1,299 @@
+/*
+ *  tls.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/youmark/pkcs8"
+	"go.uber.org/zap"
+)
+
+// TLSDialer dials the TLS connections to MongoDB. The server certificate is verified, unless SSLInsecureSkipVerify is
+// set, for SSLServerName or, if it is empty, the host of the dialed address, which may be an IP address. The CA bundle
+// and the client certificate are reloaded with the next handshake, after their files changed on disk, so rotated
+// certificates are used without a restart.
+// The dialer replaces the TLS of the driver, which offers no hook to verify a connection against its dialed host.
+type TLSDialer struct {
+	dialer *net.Dialer
+	config *Config
+	tls    *tls.Config
+	files  *tlsFiles
+}
+
+// NewTLSDialer returns the dialer of the TLS connections to MongoDB, the files of the config are loaded once
+func NewTLSDialer(config *Config, logger *zap.SugaredLogger) (*TLSDialer, error) {
+
+	files := &tlsFiles{config: config, logger: logger, modTimes: map[string]time.Time{}}
+	err := files.load()
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// the standard verification is replaced by an equal one, which uses the CA bundle reloaded from disk
+		InsecureSkipVerify: true,
+	}
+
+	if config.SSLClientCertFile != "" {
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return files.clientCertificate(), nil
+		}
+	}
+
+	if config.SSLInsecureSkipVerify {
+		logger.Warn("the certificate of the mongo db server is not verified")
+	}
+
+	return &TLSDialer{dialer: &net.Dialer{}, config: config, tls: tlsConfig, files: files}, nil
+}
+
+// DialContext connects to the address and completes the TLS handshake
+func (dialer *TLSDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+
+	conn, err := dialer.dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	client := dialer.Client(conn, address)
+	err = client.HandshakeContext(ctx)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// Client returns the TLS client of the connection to the address "<host>:<port>", which verifies the server for the
+// name of the config or the host
+func (dialer *TLSDialer) Client(conn net.Conn, address string) *tls.Conn {
+
+	serverName := dialer.config.SSLServerName
+	if serverName == "" {
+		serverName = address
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			serverName = host
+		}
+	}
+
+	tlsConfig := dialer.tls.Clone()
+	tlsConfig.ServerName = serverName
+	if !dialer.config.SSLInsecureSkipVerify {
+		tlsConfig.VerifyConnection = func(state tls.ConnectionState) error {
+			return dialer.files.verify(state, serverName)
+		}
+	}
+
+	return tls.Client(conn, tlsConfig)
+}
+
+// tlsFiles holds the CA bundle and the client certificate loaded from the files of the config
+type tlsFiles struct {
+	config *Config
+	logger *zap.SugaredLogger
+
+	mutex       sync.Mutex
+	modTimes    map[string]time.Time
+	roots       *x509.CertPool
+	certificate *tls.Certificate
+}
+
+// load reads the files, if one of them changed since they were read last
+func (files *tlsFiles) load() error {
+
+	files.mutex.Lock()
+	defer files.mutex.Unlock()
+
+	paths := []string{files.config.SSLCAFile, files.config.SSLClientCertFile, files.config.SSLClientKeyFile}
+
+	modTimes := map[string]time.Time{}
+	changed := false
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return errors.Wrap(err, "could not read ssl file")
+		}
+		modTimes[path] = info.ModTime()
+		changed = changed || !info.ModTime().Equal(files.modTimes[path])
+	}
+	if !changed {
+		return nil
+	}
+
+	var roots *x509.CertPool
+	if files.config.SSLCAFile != "" {
+		caPEM, err := ioutil.ReadFile(files.config.SSLCAFile)
+		if err != nil {
+			return errors.Wrap(err, "could not read ssl CA file")
+		}
+
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(caPEM) {
+			return fmt.Errorf("could not parse a certificate of the ssl CA file '%s'", files.config.SSLCAFile)
+		}
+	}
+
+	var certificate *tls.Certificate
+	if files.config.SSLClientCertFile != "" {
+		loaded, err := loadKeyPair(files.config.SSLClientCertFile, files.config.SSLClientKeyFile, files.config.SSLClientKeyPassword)
+		if err != nil {
+			return err
+		}
+		certificate = &loaded
+	}
+
+	files.roots = roots
+	files.certificate = certificate
+	files.modTimes = modTimes
+
+	return nil
+}
+
+// reload loads the changed files, a file, which cannot be loaded, e.g. as it is written at the moment, is loaded again
+// with the next handshake and the loaded files are kept until then
+func (files *tlsFiles) reload() {
+
+	err := files.load()
+	if err != nil {
+		files.logger.Warnw("error reloading the mongo db ssl files, the loaded ones are kept", "error", err)
+	}
+}
+
+func (files *tlsFiles) clientCertificate() *tls.Certificate {
+
+	files.reload()
+
+	files.mutex.Lock()
+	defer files.mutex.Unlock()
+
+	return files.certificate
+}
+
+// verify verifies the certificate chain of the server and its name like the TLS client does, but with the reloaded
+// CA bundle. The name is the one of the config, as the state leaves out IP addresses.
+func (files *tlsFiles) verify(state tls.ConnectionState, serverName string) error {
+
+	if serverName == "" {
+		return errors.New("there is no name to verify the certificate of the mongo db server for")
+	}
+
+	files.reload()
+
+	files.mutex.Lock()
+	roots := files.roots
+	files.mutex.Unlock()
+
+	if len(state.PeerCertificates) == 0 {
+		return errors.New("the mongo db server did not send a certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, certificate := range state.PeerCertificates[1:] {
+		intermediates.AddCert(certificate)
+	}
+
+	_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		DNSName:       serverName,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not verify the certificate of the mongo db server")
+	}
+
+	return nil
+}
+
+// loadKeyPair loads the certificate and its key, which may be encrypted with PKCS#8 or the legacy PEM encryption.
+// Without key file the certificate file is a combined PEM, which holds the key as well.
+func loadKeyPair(certFile string, keyFile string, password string) (tls.Certificate, error) {
+
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "could not read ssl client cert")
+	}
+
+	keyPEM := certPEM
+	if keyFile != "" {
+		keyPEM, err = ioutil.ReadFile(keyFile)
+		if err != nil {
+			return tls.Certificate{}, errors.Wrap(err, "could not read ssl client key")
+		}
+	}
+
+	keyPEM, err = decryptKey(keyPEM, password)
+	if err != nil {
+		if keyFile == "" {
+			return tls.Certificate{}, errors.Wrapf(err, "the ssl client cert '%s' holds no usable key, "+
+				"please specify the key with mongo.sslClientKeyFile or the CA bundle with mongo.sslCAFile", certFile)
+		}
+		return tls.Certificate{}, err
+	}
+
+	certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "error creating tls config")
+	}
+
+	return certificate, nil
+}
+
+// decryptKey returns the first key of the PEM as unencrypted PEM, other blocks like certificates are skipped
+func decryptKey(keyPEM []byte, password string) ([]byte, error) {
+
+	block, rest := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("could not decode the ssl client key, it is no PEM")
+	}
+	for !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, errors.New("could not find the ssl client key in the PEM")
+		}
+	}
+
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(password))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decrypt the ssl client key")
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decrypt the ssl client key")
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+
+	// the legacy encryption is insecure, but still written by older tools
+	case x509.IsEncryptedPEMBlock(block):
+		der, err := x509.DecryptPEMBlock(block, []byte(password))
+		if err != nil {
+			return nil, errors.Wrap(err, "could not decrypt the ssl client key")
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+
+	default:
+		return pem.EncodeToMemory(block), nil
+	}
+}
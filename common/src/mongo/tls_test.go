@@ -0,0 +1,285 @@
+/*
+ *  tls_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/youmark/pkcs8"
+	"go.uber.org/zap"
+)
+
+type testCertificate struct {
+	certificate *x509.Certificate
+	key         *ecdsa.PrivateKey
+	certPEM     []byte
+}
+
+// newTestCertificate issues a certificate for the DNS name or IP address, signed by the parent or self-signed without
+// parent
+func newTestCertificate(t *testing.T, name string, parent *testCertificate) *testCertificate {
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	if ip := net.ParseIP(name); ip != nil {
+		template.DNSNames, template.IPAddresses = nil, []net.IP{ip}
+	}
+
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.certificate, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	certificate, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCertificate{
+		certificate: certificate,
+		key:         key,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+}
+
+func (certificate *testCertificate) keyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(certificate.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func (certificate *testCertificate) tlsCertificate(t *testing.T) tls.Certificate {
+	pair, err := tls.X509KeyPair(certificate.certPEM, certificate.keyPEM(t))
+	require.NoError(t, err)
+	return pair
+}
+
+// writeFile writes the file with a modification time after the one before, as the file system may not notice quick writes
+func writeFile(t *testing.T, path string, content []byte) {
+	require.NoError(t, os.WriteFile(path, content, 0600))
+
+	modTime := time.Now()
+	if info, err := os.Stat(path); err == nil && info.ModTime().Before(modTime) {
+		modTime = modTime.Add(time.Second)
+	}
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+// handshake connects the dialer as if it dialed the address with a TLS server presenting the certificate. The
+// connection is a buffered TCP connection, as the alert of a failed verification may cross the writes of the server.
+func handshake(t *testing.T, dialer *TLSDialer, address string, server tls.Certificate) error {
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	go func() {
+		serverConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer serverConn.Close()
+		_ = tls.Server(serverConn, &tls.Config{Certificates: []tls.Certificate{server}}).Handshake()
+	}()
+
+	clientConn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	defer clientConn.Close()
+
+	return dialer.Client(clientConn, address).Handshake()
+}
+
+func TestTLSDialer_verification(t *testing.T) {
+	t.Parallel()
+
+	ca := newTestCertificate(t, "ca", nil)
+	otherCA := newTestCertificate(t, "other ca", nil)
+	server := newTestCertificate(t, "mongo.test", ca)
+	ipServer := newTestCertificate(t, "10.0.0.5", ca)
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "ca.pem"), ca.certPEM)
+	writeFile(t, filepath.Join(dir, "other.pem"), otherCA.certPEM)
+
+	cases := map[string]struct {
+		config  Config
+		address string
+		server  *testCertificate
+		valid   bool
+	}{
+		"verified":             {config: Config{SSLCAFile: filepath.Join(dir, "ca.pem"), SSLServerName: "mongo.test"}, valid: true},
+		"wrong server name":    {config: Config{SSLCAFile: filepath.Join(dir, "ca.pem"), SSLServerName: "other.test"}, valid: false},
+		"other CA":             {config: Config{SSLCAFile: filepath.Join(dir, "other.pem"), SSLServerName: "mongo.test"}, valid: false},
+		"system roots":         {config: Config{SSLServerName: "mongo.test"}, valid: false},
+		"verification skipped": {config: Config{SSLCAFile: filepath.Join(dir, "other.pem"), SSLInsecureSkipVerify: true}, valid: true},
+		"dialed host":          {config: Config{SSLCAFile: filepath.Join(dir, "ca.pem")}, address: "mongo.test:27017", valid: true},
+		"other dialed host":    {config: Config{SSLCAFile: filepath.Join(dir, "ca.pem")}, address: "other.test:27017", valid: false},
+		"dialed IP":            {config: Config{SSLCAFile: filepath.Join(dir, "ca.pem")}, address: "10.0.0.5:27017", server: ipServer, valid: true},
+		// the SNI of the handshake leaves out IP addresses, the name must be checked nevertheless
+		"IP with certificate of other name": {config: Config{SSLCAFile: filepath.Join(dir, "ca.pem")}, address: "10.0.0.5:27017", valid: false},
+		"other IP":                          {config: Config{SSLCAFile: filepath.Join(dir, "ca.pem")}, address: "10.0.0.6:27017", server: ipServer, valid: false},
+		"server name before dialed host":    {config: Config{SSLCAFile: filepath.Join(dir, "ca.pem"), SSLServerName: "mongo.test"}, address: "10.0.0.5:27017", valid: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dialer, err := NewTLSDialer(&tc.config, zap.NewNop().Sugar())
+			require.NoError(t, err)
+
+			address, certificate := tc.address, tc.server
+			if address == "" {
+				address = "mongo.example:27017"
+			}
+			if certificate == nil {
+				certificate = server
+			}
+
+			err = handshake(t, dialer, address, certificate.tlsCertificate(t))
+			if tc.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestTLSDialer_reload(t *testing.T) {
+	t.Parallel()
+
+	oldCA := newTestCertificate(t, "old ca", nil)
+	newCA := newTestCertificate(t, "new ca", nil)
+	oldClient := newTestCertificate(t, "client", oldCA)
+	newClient := newTestCertificate(t, "client", newCA)
+	server := newTestCertificate(t, "mongo.test", newCA)
+
+	dir := t.TempDir()
+	config := &Config{
+		SSLCAFile:         filepath.Join(dir, "ca.pem"),
+		SSLClientCertFile: filepath.Join(dir, "client.pem"),
+		SSLClientKeyFile:  filepath.Join(dir, "client.key"),
+		SSLServerName:     "mongo.test",
+	}
+	writeFile(t, config.SSLCAFile, oldCA.certPEM)
+	writeFile(t, config.SSLClientCertFile, oldClient.certPEM)
+	writeFile(t, config.SSLClientKeyFile, oldClient.keyPEM(t))
+
+	dialer, err := NewTLSDialer(config, zap.NewNop().Sugar())
+	require.NoError(t, err)
+
+	assert.Error(t, handshake(t, dialer, "mongo.example:27017", server.tlsCertificate(t)))
+	certificate, err := dialer.tls.GetClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, oldClient.certificate.Raw, certificate.Certificate[0])
+
+	// a broken file keeps the loaded certificates
+	writeFile(t, config.SSLClientCertFile, []byte("broken"))
+	certificate, err = dialer.tls.GetClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, oldClient.certificate.Raw, certificate.Certificate[0])
+
+	writeFile(t, config.SSLCAFile, newCA.certPEM)
+	writeFile(t, config.SSLClientCertFile, newClient.certPEM)
+	writeFile(t, config.SSLClientKeyFile, newClient.keyPEM(t))
+
+	assert.NoError(t, handshake(t, dialer, "mongo.example:27017", server.tlsCertificate(t)))
+	certificate, err = dialer.tls.GetClientCertificate(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, newClient.certificate.Raw, certificate.Certificate[0])
+}
+
+func TestLoadKeyPair(t *testing.T) {
+	t.Parallel()
+
+	client := newTestCertificate(t, "client", nil)
+
+	pkcs8Encrypted, err := pkcs8.ConvertPrivateKeyToPKCS8(client.key, []byte("secret"))
+	require.NoError(t, err)
+
+	ecDER, err := x509.MarshalECPrivateKey(client.key)
+	require.NoError(t, err)
+	// the legacy encryption is deprecated, but older tools still write it
+	legacyEncrypted, err := x509.EncryptPEMBlock(rand.Reader, "EC PRIVATE KEY", ecDER, []byte("secret"), x509.PEMCipherAES256)
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		keyPEM   []byte
+		password string
+		// combined writes the key into the certificate file instead of a key file
+		combined bool
+		err      bool
+	}{
+		"unencrypted":             {keyPEM: client.keyPEM(t)},
+		"combined":                {keyPEM: client.keyPEM(t), combined: true},
+		"combined encrypted":      {keyPEM: pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: pkcs8Encrypted}), password: "secret", combined: true},
+		"combined without key":    {combined: true, err: true},
+		"pkcs8 encrypted":         {keyPEM: pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: pkcs8Encrypted}), password: "secret"},
+		"pkcs8 wrong password":    {keyPEM: pem.EncodeToMemory(&pem.Block{Type: "ENCRYPTED PRIVATE KEY", Bytes: pkcs8Encrypted}), password: "wrong", err: true},
+		"legacy encrypted":        {keyPEM: pem.EncodeToMemory(legacyEncrypted), password: "secret"},
+		"legacy missing password": {keyPEM: pem.EncodeToMemory(legacyEncrypted), err: true},
+		"no PEM":                  {keyPEM: []byte("key"), err: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			keyFile := filepath.Join(dir, "client.key")
+			if tc.combined {
+				writeFile(t, filepath.Join(dir, "client.pem"), append(append([]byte{}, client.certPEM...), tc.keyPEM...))
+				keyFile = ""
+			} else {
+				writeFile(t, filepath.Join(dir, "client.pem"), client.certPEM)
+				writeFile(t, keyFile, tc.keyPEM)
+			}
+
+			certificate, err := loadKeyPair(filepath.Join(dir, "client.pem"), keyFile, tc.password)
+			if tc.combined && tc.err {
+				assert.ErrorContains(t, err, "mongo.sslClientKeyFile")
+			}
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, client.certificate.Raw, certificate.Certificate[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ *  transaction.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"sheazuzu/common/src/metrics"
+	"sheazuzu/common/src/tracing"
+)
+
+// the number of attempts of a transaction and of its commit, before the error is returned
+const transactionAttempts = 3
+
+// the error labels of the driver, which make a transaction or its commit worth a retry
+const (
+	transientTransactionError      = "TransientTransactionError"
+	unknownTransactionCommitResult = "UnknownTransactionCommitResult"
+)
+
+// transaction is the part of a mongo.Session running a transaction
+type transaction interface {
+	StartTransaction(opts ...*options.TransactionOptions) error
+	AbortTransaction(ctx context.Context) error
+	CommitTransaction(ctx context.Context) error
+}
+
+// WithTransaction runs fn in a transaction of a new session. The session is passed to fn with the context, all
+// operations with it, e.g. of a Collection, are part of the transaction. The transaction is aborted, if fn returns an
+// error or panics, and committed otherwise.
+// A transaction failing with a transient error is retried, so is a commit with an unknown result. fn must not have
+// side effects besides the operations of the transaction. A nested call runs fn in the transaction of the outer call,
+// as MongoDB has no savepoints. Transactions need a replica set.
+func (db *Database) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "MongoDB Transaction")
+	defer span.End()
+
+	return db.Client.UseSession(ctx, func(sessionCtx mongo.SessionContext) error {
+		return runTransaction(sessionCtx, sessionCtx, fn)
+	})
+}
+
+// WithSnapshot runs fn with a new session, whose reads all see the data as of the first read. The session is passed to fn
+// with the context like by WithTransaction. Snapshot reads need MongoDB 5.0 and are limited to the history the server
+// keeps, about 5 minutes by default. A nested call runs fn in the session of the outer call.
+func (db *Database) WithSnapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "MongoDB Snapshot")
+	defer span.End()
+
+	return db.Client.UseSessionWithOptions(ctx, options.Session().SetSnapshot(true), func(sessionCtx mongo.SessionContext) error {
+		return fn(sessionCtx)
+	})
+}
+
+// runTransaction runs fn in a transaction of the session, which is passed to fn with ctx
+func runTransaction(ctx context.Context, session transaction, fn func(ctx context.Context) error) (err error) {
+
+	start := time.Now()
+	result := metrics.TransactionFailed
+	defer func() {
+		metrics.RecordTransaction(ctx, result, time.Since(start))
+	}()
+
+	for attempt := 1; ; attempt++ {
+		err = session.StartTransaction()
+		if err != nil {
+			return err
+		}
+
+		// a panic of fn is recorded as aborted
+		result = metrics.TransactionAborted
+		err = runCallback(ctx, session, fn)
+		if err != nil {
+			_ = session.AbortTransaction(context.Background())
+			if hasErrorLabel(err, transientTransactionError) && attempt < transactionAttempts && ctx.Err() == nil {
+				metrics.RecordTransactionRetry(ctx)
+				continue
+			}
+			return err
+		}
+
+		result = metrics.TransactionFailed
+		err = commit(ctx, session)
+		if hasErrorLabel(err, transientTransactionError) && attempt < transactionAttempts && ctx.Err() == nil {
+			metrics.RecordTransactionRetry(ctx)
+			continue
+		}
+		if err == nil {
+			result = metrics.TransactionCommitted
+		}
+		return err
+	}
+}
+
+// runCallback aborts the transaction, if fn panics
+func runCallback(ctx context.Context, session transaction, fn func(ctx context.Context) error) error {
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = session.AbortTransaction(context.Background())
+			panic(p)
+		}
+	}()
+
+	return fn(ctx)
+}
+
+// commit commits the transaction and retries a commit with an unknown result, committing twice is safe
+func commit(ctx context.Context, session transaction) error {
+
+	for attempt := 1; ; attempt++ {
+		// the commit must not be cancelled half way, as the result would be unknown
+		err := session.CommitTransaction(context.Background())
+		if hasErrorLabel(err, unknownTransactionCommitResult) && attempt < transactionAttempts && ctx.Err() == nil {
+			metrics.RecordTransactionRetry(ctx)
+			continue
+		}
+		return err
+	}
+}
+
+func hasErrorLabel(err error, label string) bool {
+
+	var labeled interface{ HasErrorLabel(string) bool }
+	return errors.As(err, &labeled) && labeled.HasErrorLabel(label)
+}
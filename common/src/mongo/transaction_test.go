@@ -0,0 +1,132 @@
+/*
+ *  transaction_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package mongo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	verrors "sheazuzu/common/src/errors"
+)
+
+// fakeSession records the calls of the transaction, the commits return the errors of commits in order
+type fakeSession struct {
+	calls   []string
+	commits []error
+}
+
+func (session *fakeSession) StartTransaction(...*options.TransactionOptions) error {
+	session.calls = append(session.calls, "start")
+	return nil
+}
+
+func (session *fakeSession) AbortTransaction(context.Context) error {
+	session.calls = append(session.calls, "abort")
+	return nil
+}
+
+func (session *fakeSession) CommitTransaction(context.Context) error {
+	session.calls = append(session.calls, "commit")
+	if len(session.commits) == 0 {
+		return nil
+	}
+	err := session.commits[0]
+	session.commits = session.commits[1:]
+	return err
+}
+
+func labeled(label string) error {
+	return mongo.CommandError{Code: 112, Name: "WriteConflict", Labels: []string{label}}
+}
+
+func TestRunTransaction(t *testing.T) {
+	t.Parallel()
+
+	failure := errors.New("failure")
+	transient := labeled(transientTransactionError)
+	unknownCommit := labeled(unknownTransactionCommitResult)
+
+	cases := map[string]struct {
+		// the errors of the calls of fn in order, the later calls succeed
+		fnErrs  []error
+		commits []error
+		err     error
+		calls   string
+	}{
+		"committed": {
+			calls: "start fn commit",
+		},
+		"aborted": {
+			fnErrs: []error{failure},
+			err:    failure,
+			calls:  "start fn abort",
+		},
+		"transient error is retried": {
+			fnErrs: []error{verrors.E(verrors.Op("op"), transient)},
+			calls:  "start fn abort start fn commit",
+		},
+		"transient error is retried up to the attempts": {
+			fnErrs: []error{transient, transient, transient},
+			err:    transient,
+			calls:  "start fn abort start fn abort start fn abort",
+		},
+		"unknown commit result is retried": {
+			commits: []error{unknownCommit, unknownCommit},
+			calls:   "start fn commit commit commit",
+		},
+		"transient commit error retries the transaction": {
+			commits: []error{transient},
+			calls:   "start fn commit start fn commit",
+		},
+		"failed commit": {
+			commits: []error{failure},
+			err:     failure,
+			calls:   "start fn commit",
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			session := &fakeSession{commits: tc.commits}
+			fnErrs := tc.fnErrs
+
+			err := runTransaction(context.Background(), session, func(ctx context.Context) error {
+				session.calls = append(session.calls, "fn")
+				if len(fnErrs) == 0 {
+					return nil
+				}
+				err := fnErrs[0]
+				fnErrs = fnErrs[1:]
+				return err
+			})
+
+			assert.Equal(t, tc.err, err)
+			assert.Equal(t, tc.calls, strings.Join(session.calls, " "))
+		})
+	}
+}
+
+func TestRunTransaction_panic(t *testing.T) {
+	t.Parallel()
+
+	session := &fakeSession{}
+
+	assert.PanicsWithValue(t, "failure", func() {
+		_ = runTransaction(context.Background(), session, func(ctx context.Context) error {
+			panic("failure")
+		})
+	})
+	assert.Equal(t, []string{"start", "abort"}, session.calls)
+}
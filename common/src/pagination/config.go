@@ -0,0 +1,44 @@
+/*
+ *  config.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package pagination
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Config contains the properties of the cursor-based pagination.
+// CursorSecret signs the cursor tokens, all instances of a service have to share it. If it is empty, a random secret is
+// generated on startup, so the cursors become invalid with a restart.
+type Config struct {
+	CursorSecret string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// BindConfig takes a Config and a FlagSet and stores the pagination-relevant flags in the corresponding config fields.
+func BindConfig(config *Config, fs *flag.FlagSet) {
+	fs.StringVar(&config.CursorSecret, "pagination.cursorSecret", "", "secret used to sign the pagination cursors, random if empty")
+	fs.IntVar(&config.DefaultLimit, "pagination.defaultLimit", 100, "number of items on a page, if the client does not request a limit")
+	fs.IntVar(&config.MaxLimit, "pagination.maxLimit", 1000, "maximum number of items on a page")
+}
+
+// IsValid returns true, if the limits are positive and the default does not exceed the maximum.
+func (config *Config) IsValid() bool {
+
+	if config.DefaultLimit < 1 || config.MaxLimit < 1 {
+		fmt.Println("pagination limits must be positive")
+		return false
+	}
+
+	if config.DefaultLimit > config.MaxLimit {
+		fmt.Println("pagination default limit must not exceed the maximum limit")
+		return false
+	}
+
+	return true
+}
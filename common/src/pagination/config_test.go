@@ -0,0 +1,76 @@
+/*
+ *  config_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package pagination
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindConfig(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		args     []string
+		expected Config
+	}{
+		"defaults": {
+			args:     []string{},
+			expected: Config{CursorSecret: "", DefaultLimit: 100, MaxLimit: 1000},
+		},
+		"everything set": {
+			args: []string{
+				"--pagination.cursorSecret", "secret",
+				"--pagination.defaultLimit", "20",
+				"--pagination.maxLimit", "50",
+			},
+			expected: Config{CursorSecret: "secret", DefaultLimit: 20, MaxLimit: 50},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			cfg := Config{}
+
+			BindConfig(&cfg, fs)
+
+			err := fs.Parse(tc.args)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg)
+		})
+	}
+}
+
+func TestConfig_IsValid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		cfg     Config
+		isValid bool
+	}{
+		"valid":                   {cfg: Config{DefaultLimit: 10, MaxLimit: 10}, isValid: true},
+		"default above maximum":   {cfg: Config{DefaultLimit: 11, MaxLimit: 10}, isValid: false},
+		"default not positive":    {cfg: Config{DefaultLimit: 0, MaxLimit: 10}, isValid: false},
+		"maximum not positive":    {cfg: Config{DefaultLimit: 1, MaxLimit: -1}, isValid: false},
+		"secret is not mandatory": {cfg: Config{CursorSecret: "", DefaultLimit: 1, MaxLimit: 1}, isValid: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.isValid, tc.cfg.IsValid())
+		})
+	}
+}
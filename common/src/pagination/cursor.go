@@ -0,0 +1,140 @@
+/*
+ *  cursor.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+// Package pagination provides opaque, signed cursors for keyset pagination of list endpoints.
+package pagination
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	verrors "sheazuzu/common/src/errors"
+	"strconv"
+	"strings"
+)
+
+// Cursor points behind the last item of a page. Key is the sort key and Id the id of that item,
+// the id breaks the ties between items with the same sort key.
+type Cursor struct {
+	Key string `json:"k"`
+	Id  int    `json:"i"`
+}
+
+// Paginator creates and verifies the cursor tokens and limits the page sizes.
+type Paginator struct {
+	config Config
+	secret []byte
+}
+
+// NewPaginator returns a Paginator signing the cursors with the secret of the config, or a random secret if it is empty.
+func NewPaginator(config Config) (*Paginator, error) {
+
+	secret := []byte(config.CursorSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, sha256.Size)
+		_, err := rand.Read(secret)
+		if err != nil {
+			return nil, fmt.Errorf("could not generate a cursor secret: %w", err)
+		}
+	}
+
+	return &Paginator{config: config, secret: secret}, nil
+}
+
+// Limit returns the page size for the requested limit, the default limit if none was requested.
+// Limits outside of 1 and the maximum limit are rejected with an error of kind verrors.InputError.
+func (paginator *Paginator) Limit(requested *int) (int, error) {
+	op := verrors.Op("pagination: Limit")
+
+	if requested == nil {
+		return paginator.config.DefaultLimit, nil
+	}
+
+	if *requested < 1 || *requested > paginator.config.MaxLimit {
+		return 0, verrors.E(op, verrors.InputError, verrors.Info{Name: "limit", Val: *requested},
+			fmt.Sprintf("the limit must be between 1 and %d", paginator.config.MaxLimit))
+	}
+
+	return *requested, nil
+}
+
+// Encode returns the opaque token of the cursor: the base64 encoded cursor and its HMAC-SHA256 signature.
+func (paginator *Paginator) Encode(cursor Cursor) string {
+
+	payload, _ := json.Marshal(cursor) // a struct of a string and an int always marshals
+
+	return base64.RawURLEncoding.EncodeToString(payload) + "." + base64.RawURLEncoding.EncodeToString(paginator.sign(payload))
+}
+
+// Decode verifies the signature of the token and returns its cursor.
+// Malformed or manipulated tokens are rejected with an error of kind verrors.InputError.
+func (paginator *Paginator) Decode(token string) (Cursor, error) {
+	op := verrors.Op("pagination: Decode Cursor")
+
+	invalid := func(reason string) (Cursor, error) {
+		return Cursor{}, verrors.E(op, verrors.InputError, verrors.Info{Name: "cursor", Val: token}, "invalid cursor: "+reason)
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return invalid("malformed token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return invalid("malformed token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return invalid("malformed token")
+	}
+
+	if !hmac.Equal(signature, paginator.sign(payload)) {
+		return invalid("signature mismatch")
+	}
+
+	var cursor Cursor
+	err = json.Unmarshal(payload, &cursor)
+	if err != nil {
+		return invalid("malformed token")
+	}
+
+	return cursor, nil
+}
+
+func (paginator *Paginator) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, paginator.secret)
+	mac.Write(payload)
+	return mac.Sum(nil)
+}
+
+// NextLink returns the URL of the next page: the URL of the request with the cursor and limit query parameters
+// replaced, all other query parameters are kept.
+func NextLink(r *http.Request, token string, limit int) string {
+
+	next := url.URL{Path: r.URL.Path}
+
+	query := r.URL.Query()
+	query.Set("cursor", token)
+	query.Set("limit", strconv.Itoa(limit))
+	next.RawQuery = query.Encode()
+
+	return next.String()
+}
+
+// SetLinkHeader adds the Link header with the relation "next", if there is a next page.
+func SetLinkHeader(w http.ResponseWriter, r *http.Request, token string, limit int) {
+	if token == "" {
+		return
+	}
+	w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="next"`, NextLink(r, token, limit)))
+}
@@ -0,0 +1,131 @@
+/*
+ *  cursor_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package pagination
+
+import (
+	"net/http"
+	"net/http/httptest"
+	verrors "sheazuzu/common/src/errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newPaginator(t *testing.T, secret string) *Paginator {
+	paginator, err := NewPaginator(Config{CursorSecret: secret, DefaultLimit: 100, MaxLimit: 1000})
+	assert.NoError(t, err)
+	return paginator
+}
+
+func TestPaginator_Decode(t *testing.T) {
+	t.Parallel()
+
+	paginator := newPaginator(t, "secret")
+	cursor := Cursor{Key: "2021-08-14", Id: 42}
+	token := paginator.Encode(cursor)
+	payload := strings.Split(token, ".")[0]
+	signature := strings.Split(token, ".")[1]
+
+	cases := map[string]struct {
+		token string
+		valid bool
+	}{
+		"encoded cursor":        {token: token, valid: true},
+		"empty":                 {token: "", valid: false},
+		"no signature":          {token: payload, valid: false},
+		"invalid base64":        {token: "!!!." + signature, valid: false},
+		"other secret":          {token: newPaginator(t, "other").Encode(cursor), valid: false},
+		"manipulated payload":   {token: strings.Split(paginator.Encode(Cursor{Key: "2021-08-14", Id: 1}), ".")[0] + "." + signature, valid: false},
+		"random secret differs": {token: newPaginator(t, "").Encode(cursor), valid: false},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			decoded, err := paginator.Decode(tc.token)
+			if !tc.valid {
+				assert.Error(t, err)
+				assert.Equal(t, http.StatusBadRequest, verrors.HttpErrorCodeFromError(err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, cursor, decoded)
+		})
+	}
+}
+
+func TestPaginator_Limit(t *testing.T) {
+	t.Parallel()
+
+	paginator := newPaginator(t, "secret")
+	limit := func(i int) *int { return &i }
+
+	cases := map[string]struct {
+		requested *int
+		expected  int
+		valid     bool
+	}{
+		"default":       {requested: nil, expected: 100, valid: true},
+		"requested":     {requested: limit(10), expected: 10, valid: true},
+		"maximum":       {requested: limit(1000), expected: 1000, valid: true},
+		"above maximum": {requested: limit(1001), valid: false},
+		"zero":          {requested: limit(0), valid: false},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := paginator.Limit(tc.requested)
+			assert.Equal(t, tc.valid, err == nil)
+			assert.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestSetLinkHeader(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		url      string
+		token    string
+		expected string
+	}{
+		"last page": {
+			url:      "/find/allData",
+			token:    "",
+			expected: "",
+		},
+		"first page": {
+			url:      "/find/allData",
+			token:    "abc.def",
+			expected: `</find/allData?cursor=abc.def&limit=10>; rel="next"`,
+		},
+		"other parameters are kept": {
+			url:      "/api/find/allData?fields=id,result&cursor=old&limit=5",
+			token:    "abc.def",
+			expected: `</api/find/allData?cursor=abc.def&fields=id%2Cresult&limit=10>; rel="next"`,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			SetLinkHeader(w, httptest.NewRequest(http.MethodGet, tc.url, nil), tc.token, 10)
+
+			assert.Equal(t, tc.expected, w.Header().Get("Link"))
+		})
+	}
+}
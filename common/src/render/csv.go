@@ -0,0 +1,142 @@
+/*
+ *  csv.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package render
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// encodeCSV writes a slice of structs as CSV document with a header row.
+// The columns are named after the json tags of the fields, nested structs are flattened into "parent.child" columns.
+func encodeCSV(w io.Writer, v interface{}) error {
+
+	items := reflect.ValueOf(v)
+	for items.Kind() == reflect.Ptr && !items.IsNil() {
+		items = items.Elem()
+	}
+
+	if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+		return fmt.Errorf("only lists can be rendered as CSV, got %T", v)
+	}
+
+	elemType := items.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	writer := csv.NewWriter(w)
+
+	err := writer.Write(csvColumns(elemType, ""))
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < items.Len(); i++ {
+		err = writer.Write(csvValues(items.Index(i), elemType))
+		if err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// csvField returns the column name of a struct field, ok is false for fields which are not rendered
+func csvField(field reflect.StructField) (string, bool) {
+
+	if field.PkgPath != "" {
+		return "", false // unexported
+	}
+
+	name := field.Name
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		tagName := strings.Split(tag, ",")[0]
+		if tagName == "-" {
+			return "", false
+		}
+		if tagName != "" {
+			name = tagName
+		}
+	}
+
+	return name, true
+}
+
+func isStruct(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func csvColumns(t reflect.Type, prefix string) []string {
+
+	if !isStruct(t) {
+		return []string{strings.TrimSuffix(prefix, ".")}
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	var columns []string
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := csvField(t.Field(i))
+		if !ok {
+			continue
+		}
+
+		if isStruct(t.Field(i).Type) {
+			columns = append(columns, csvColumns(t.Field(i).Type, prefix+name+".")...)
+			continue
+		}
+		columns = append(columns, prefix+name)
+	}
+
+	return columns
+}
+
+// csvValues renders the fields of v in the order of csvColumns, nil pointers are rendered as empty values
+func csvValues(v reflect.Value, t reflect.Type) []string {
+
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v = reflect.Value{}
+			break
+		}
+		v = v.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		if !v.IsValid() {
+			return []string{""}
+		}
+		return []string{fmt.Sprint(v.Interface())}
+	}
+
+	var values []string
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := csvField(t.Field(i)); !ok {
+			continue
+		}
+
+		field := reflect.Value{}
+		if v.IsValid() {
+			field = v.Field(i)
+		}
+		values = append(values, csvValues(field, t.Field(i).Type)...)
+	}
+
+	return values
+}
@@ -0,0 +1,287 @@
+/*
+ *  msgpack.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package render
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+)
+
+// encodeMsgPack writes v in the MessagePack format (https://github.com/msgpack/msgpack/blob/master/spec.md).
+// The values are encoded from their Go types, so floats stay floats, even if they are whole numbers. Structs are
+// encoded as maps with the keys and omitempty rules of their json tags, like the JSON responses.
+func encodeMsgPack(w io.Writer, v interface{}) error {
+
+	var buf bytes.Buffer
+	err := writeMsgPack(&buf, reflect.ValueOf(v))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+func writeMsgPack(buf *bytes.Buffer, v reflect.Value) error {
+
+	if !v.IsValid() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		buf.WriteByte(0xc0)
+		return nil
+	}
+
+	// types with their own JSON representation, like time.Time, are encoded as in the JSON responses
+	switch {
+	case v.Type() == jsonNumberType:
+		return writeMsgPackNumber(buf, json.Number(v.String()))
+	case v.Type().Implements(textMarshalerType):
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err
+		}
+		writeMsgPackString(buf, string(text))
+		return nil
+	case v.Type().Implements(jsonMarshalerType):
+		return writeMsgPackJSON(buf, v.Interface().(json.Marshaler))
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return writeMsgPack(buf, v.Elem())
+
+	case reflect.Bool:
+		if v.Bool() {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeMsgPackInt(buf, v.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() > math.MaxInt64 {
+			buf.WriteByte(0xcf)
+			_ = binary.Write(buf, binary.BigEndian, v.Uint())
+		} else {
+			writeMsgPackInt(buf, int64(v.Uint()))
+		}
+
+	case reflect.Float32:
+		buf.WriteByte(0xca)
+		_ = binary.Write(buf, binary.BigEndian, math.Float32bits(float32(v.Float())))
+
+	case reflect.Float64:
+		buf.WriteByte(0xcb)
+		_ = binary.Write(buf, binary.BigEndian, math.Float64bits(v.Float()))
+
+	case reflect.String:
+		writeMsgPackString(buf, v.String())
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			writeMsgPackHeader(buf, v.Len(), 0, 0, 0xc4, 0xc5, 0xc6)
+			buf.Write(v.Bytes())
+			return nil
+		}
+
+		writeMsgPackHeader(buf, v.Len(), 0x90, 16, 0, 0xdc, 0xdd)
+		for i := 0; i < v.Len(); i++ {
+			err := writeMsgPack(buf, v.Index(i))
+			if err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.IsNil() {
+			buf.WriteByte(0xc0)
+			return nil
+		}
+
+		entries := make(map[string]reflect.Value, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries[fmt.Sprint(iter.Key().Interface())] = iter.Value()
+		}
+		return writeMsgPackMap(buf, entries)
+
+	case reflect.Struct:
+		entries := map[string]reflect.Value{}
+		structEntries(v, entries)
+		return writeMsgPackMap(buf, entries)
+
+	default:
+		return fmt.Errorf("type %s cannot be encoded as MessagePack", v.Type())
+	}
+
+	return nil
+}
+
+// structEntries collects the fields of the struct v by the names of their json tags. The fields of embedded structs
+// without tag are collected as if they were fields of v, as encoding/json does.
+func structEntries(v reflect.Value, entries map[string]reflect.Value) {
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, tagged := field.Tag.Lookup("json")
+		name := strings.Split(tag, ",")[0]
+
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			structEntries(v.Field(i), entries)
+			continue
+		}
+		if field.PkgPath != "" || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if tagged && strings.Contains(tag, ",omitempty") && isEmptyValue(v.Field(i)) {
+			continue
+		}
+
+		entries[name] = v.Field(i)
+	}
+}
+
+// isEmptyValue reports, if the value is omitted with omitempty, see encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// writeMsgPackMap writes the entries with sorted keys to get a deterministic encoding
+func writeMsgPackMap(buf *bytes.Buffer, entries map[string]reflect.Value) error {
+
+	keys := make([]string, 0, len(entries))
+	for key := range entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	writeMsgPackHeader(buf, len(keys), 0x80, 16, 0, 0xde, 0xdf)
+	for _, key := range keys {
+		writeMsgPackString(buf, key)
+		err := writeMsgPack(buf, entries[key])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeMsgPackJSON writes a value with its own JSON encoding as the MessagePack equivalent of its JSON document
+func writeMsgPackJSON(buf *bytes.Buffer, marshaler json.Marshaler) error {
+
+	b, err := marshaler.MarshalJSON()
+	if err != nil {
+		return err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var value interface{}
+	err = decoder.Decode(&value)
+	if err != nil {
+		return err
+	}
+
+	return writeMsgPack(buf, reflect.ValueOf(value))
+}
+
+// writeMsgPackNumber writes a number of a JSON document, whole numbers as integers
+func writeMsgPackNumber(buf *bytes.Buffer, number json.Number) error {
+
+	if i, err := number.Int64(); err == nil {
+		writeMsgPackInt(buf, i)
+		return nil
+	}
+
+	f, err := number.Float64()
+	if err != nil {
+		return err
+	}
+	buf.WriteByte(0xcb)
+	_ = binary.Write(buf, binary.BigEndian, math.Float64bits(f))
+	return nil
+}
+
+func writeMsgPackString(buf *bytes.Buffer, s string) {
+	writeMsgPackHeader(buf, len(s), 0xa0, 32, 0xd9, 0xda, 0xdb)
+	buf.WriteString(s)
+}
+
+func writeMsgPackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i < 128:
+		buf.WriteByte(byte(i)) // positive fixint
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i))) // negative fixint
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		_ = binary.Write(buf, binary.BigEndian, int32(i))
+	default:
+		buf.WriteByte(0xd3)
+		_ = binary.Write(buf, binary.BigEndian, i)
+	}
+}
+
+// writeMsgPackHeader writes the type and length of a string, binary, array or map. Lengths below fixLimit are stored in
+// the fix format, the 8 bit format is skipped, if its marker is 0, as arrays and maps do not have one.
+func writeMsgPackHeader(buf *bytes.Buffer, length int, fix byte, fixLimit int, marker8, marker16, marker32 byte) {
+	switch {
+	case length < fixLimit:
+		buf.WriteByte(fix | byte(length))
+	case marker8 != 0 && length <= math.MaxUint8:
+		buf.WriteByte(marker8)
+		buf.WriteByte(byte(length))
+	case length <= math.MaxUint16:
+		buf.WriteByte(marker16)
+		_ = binary.Write(buf, binary.BigEndian, uint16(length))
+	default:
+		buf.WriteByte(marker32)
+		_ = binary.Write(buf, binary.BigEndian, uint32(length))
+	}
+}
@@ -0,0 +1,238 @@
+/*
+ *  render.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+// Package render provides the content negotiation and encoding of HTTP responses in JSON, XML, CSV and MessagePack.
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	verrors "sheazuzu/common/src/errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeXML      = "application/xml"
+	ContentTypeCSV      = "text/csv"
+	ContentTypeMsgPack  = "application/msgpack"
+	ContentTypeXMsgPack = "application/x-msgpack"
+)
+
+// the offered content types in the order of preference, if the client accepts several with the same quality
+var offers = []string{
+	ContentTypeJSON,
+	ContentTypeXML,
+	ContentTypeMsgPack,
+	ContentTypeXMsgPack,
+	ContentTypeCSV,
+}
+
+type encoder func(w io.Writer, v interface{}) error
+
+var encoders = map[string]encoder{
+	ContentTypeJSON:     encodeJSON,
+	ContentTypeXML:      encodeXML,
+	ContentTypeMsgPack:  encodeMsgPack,
+	ContentTypeXMsgPack: encodeMsgPack,
+	ContentTypeCSV:      encodeCSV,
+}
+
+// Negotiate selects the content type of the response from the Accept header of the request.
+// A request without Accept header gets JSON. CSV is only offered, if csv is true, as only lists can be rendered as CSV.
+// If none of the offered content types is acceptable, an error of kind verrors.HttpNotAcceptable is returned.
+func Negotiate(r *http.Request, csv bool) (string, error) {
+	op := verrors.Op("render: Negotiate Content Type")
+
+	accept := r.Header.Get("Accept")
+	if strings.TrimSpace(accept) == "" {
+		return ContentTypeJSON, nil
+	}
+
+	ranges := parseAccept(accept)
+	for _, mediaRange := range ranges {
+		if mediaRange.quality <= 0 {
+			continue
+		}
+		for _, offer := range offers {
+			if offer == ContentTypeCSV && !csv {
+				continue
+			}
+			if mediaRange.matches(offer) && !excluded(ranges, offer) {
+				return offer, nil
+			}
+		}
+	}
+
+	supported := []string{ContentTypeJSON, ContentTypeXML, ContentTypeMsgPack}
+	if csv {
+		supported = append(supported, ContentTypeCSV)
+	}
+
+	return "", verrors.E(op, verrors.HttpNotAcceptable, verrors.Info{Name: "accept", Val: accept},
+		fmt.Sprintf("none of the content types %s is acceptable", strings.Join(supported, ", ")))
+}
+
+// Render writes the response v with the status and the content type negotiated from the Accept header.
+// If no supported content type is acceptable, nothing is written and the error of Negotiate is returned.
+func Render(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+
+	contentType, err := Negotiate(r, false)
+	if err != nil {
+		return err
+	}
+
+	return write(w, contentType, status, v)
+}
+
+// RenderList works like Render, but additionally offers CSV. The CSV document contains one row per element of items,
+// the other content types render the complete response v.
+func RenderList(w http.ResponseWriter, r *http.Request, status int, v interface{}, items interface{}) error {
+
+	contentType, err := Negotiate(r, true)
+	if err != nil {
+		return err
+	}
+
+	if contentType == ContentTypeCSV {
+		return write(w, contentType, status, items)
+	}
+
+	return write(w, contentType, status, v)
+}
+
+// Write writes the response v with the status in a content type returned by Negotiate. Handlers with side effects
+// negotiate the content type before they process the request, so an unacceptable request is rejected before anything
+// is changed.
+func Write(w http.ResponseWriter, contentType string, status int, v interface{}) error {
+	return write(w, contentType, status, v)
+}
+
+// RenderError writes an error response. As an error must always be delivered, it falls back to JSON,
+// if none of the supported content types is acceptable.
+func RenderError(w http.ResponseWriter, r *http.Request, status int, v interface{}) error {
+
+	contentType, err := Negotiate(r, false)
+	if err != nil {
+		contentType = ContentTypeJSON
+	}
+
+	return write(w, contentType, status, v)
+}
+
+// the response is encoded into a buffer first, so an encoding error can still be answered with an error status.
+// Once the status is sent, errors of the connection cannot be reported to the client anymore and are ignored.
+func write(w http.ResponseWriter, contentType string, status int, v interface{}) error {
+	op := verrors.Op("render: Write Response")
+
+	var buf bytes.Buffer
+	err := encoders[contentType](&buf, v)
+	if err != nil {
+		return verrors.E(op, verrors.MappingError, verrors.Info{Name: "contentType", Val: contentType}, err)
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+
+	_, _ = w.Write(buf.Bytes())
+	return nil
+}
+
+type mediaRange struct {
+	mediaType string
+	subType   string
+	quality   float64
+}
+
+func (m mediaRange) matches(contentType string) bool {
+	parts := strings.SplitN(contentType, "/", 2)
+	return (m.mediaType == "*" || m.mediaType == parts[0]) && (m.subType == "*" || m.subType == parts[1])
+}
+
+// the more specific a media range, the higher its precedence for the same quality
+func (m mediaRange) specificity() int {
+	switch {
+	case m.mediaType == "*":
+		return 0
+	case m.subType == "*":
+		return 1
+	default:
+		return 2
+	}
+}
+
+// excluded returns true, if the most specific media range matching the content type has quality 0, so
+// "*/*, application/json;q=0" excludes JSON, but "*/*;q=0, application/json" does not.
+// The ranges are ordered by quality, so of equally specific ranges the one with the lowest quality counts.
+func excluded(ranges []mediaRange, contentType string) bool {
+
+	specificity := -1
+	quality := 1.0
+	for _, mediaRange := range ranges {
+		if mediaRange.matches(contentType) && mediaRange.specificity() >= specificity {
+			specificity = mediaRange.specificity()
+			quality = mediaRange.quality
+		}
+	}
+
+	return quality <= 0
+}
+
+// parseAccept returns the media ranges ordered by quality and specificity, including the ranges with quality 0, which
+// exclude content types
+func parseAccept(accept string) []mediaRange {
+
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+		types := strings.SplitN(mediaType, "/", 2)
+		if len(types) != 2 {
+			continue
+		}
+
+		ranges = append(ranges, mediaRange{mediaType: types[0], subType: types[1], quality: quality})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].quality != ranges[j].quality {
+			return ranges[i].quality > ranges[j].quality
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+
+	return ranges
+}
+
+func encodeJSON(w io.Writer, v interface{}) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+func encodeXML(w io.Writer, v interface{}) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,269 @@
+/*
+ *  render_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package render
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	verrors "sheazuzu/common/src/errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testTeam struct {
+	Name    *string      `json:"name,omitempty"`
+	Founded int          `json:"founded"`
+	Stadium *testStadium `json:"stadium,omitempty"`
+	secret  string
+}
+
+type testStadium struct {
+	City     string `json:"city"`
+	Capacity int    `json:"capacity"`
+}
+
+type testTeamsResponse struct {
+	Teams *[]testTeam `json:"teams,omitempty"`
+}
+
+func teams() []testTeam {
+	name := "Hamburg, SV"
+	return []testTeam{
+		{Name: &name, Founded: 1887, Stadium: &testStadium{City: "Hamburg", Capacity: 57000}, secret: "x"},
+		{Founded: 1900},
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		accept      string
+		csv         bool
+		contentType string
+		err         bool
+	}{
+		"no accept header":              {accept: "", contentType: ContentTypeJSON},
+		"any":                           {accept: "*/*", contentType: ContentTypeJSON},
+		"xml":                           {accept: "application/xml", contentType: ContentTypeXML},
+		"msgpack alias":                 {accept: "application/x-msgpack", contentType: ContentTypeXMsgPack},
+		"quality":                       {accept: "application/json;q=0.5, application/xml", contentType: ContentTypeXML},
+		"specific before wildcard":      {accept: "*/*, application/msgpack", contentType: ContentTypeMsgPack},
+		"quality 0 is not acceptable":   {accept: "application/json;q=0", err: true},
+		"quality 0 excludes wildcard":   {accept: "*/*, application/json;q=0", contentType: ContentTypeXML},
+		"quality 0 excludes subtypes":   {accept: "application/*;q=0, */*", csv: true, contentType: ContentTypeCSV},
+		"specific range overrides 0":    {accept: "*/*;q=0, application/msgpack", contentType: ContentTypeMsgPack},
+		"everything excluded":           {accept: "application/*, application/*;q=0", err: true},
+		"csv for lists":                 {accept: "text/csv", csv: true, contentType: ContentTypeCSV},
+		"text wildcard for lists":       {accept: "text/*", csv: true, contentType: ContentTypeCSV},
+		"csv only for lists":            {accept: "text/csv", err: true},
+		"unsupported":                   {accept: "text/html", err: true},
+		"fallback to supported":         {accept: "text/html, application/json;q=0.1", contentType: ContentTypeJSON},
+		"invalid ranges are skipped":    {accept: "garbage;;, application/xml", contentType: ContentTypeXML},
+		"application wildcard for json": {accept: "application/*", contentType: ContentTypeJSON},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tt.accept)
+
+			contentType, err := Negotiate(r, tt.csv)
+			if tt.err {
+				assert.Error(t, err)
+				assert.Equal(t, http.StatusNotAcceptable, verrors.HttpErrorCodeFromError(err))
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.contentType, contentType)
+		})
+	}
+}
+
+func TestRenderList(t *testing.T) {
+	t.Parallel()
+
+	list := teams()
+	response := testTeamsResponse{Teams: &list}
+
+	tests := map[string]struct {
+		accept string
+		status int
+		body   string
+	}{
+		"json": {
+			accept: ContentTypeJSON,
+			status: http.StatusOK,
+			body:   `{"teams":[{"name":"Hamburg, SV","founded":1887,"stadium":{"city":"Hamburg","capacity":57000}},{"founded":1900}]}` + "\n",
+		},
+		"xml": {
+			accept: ContentTypeXML,
+			status: http.StatusOK,
+			body: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<testTeamsResponse><Teams><Name>Hamburg, SV</Name><Founded>1887</Founded><Stadium><City>Hamburg</City><Capacity>57000</Capacity></Stadium></Teams>` +
+				`<Teams><Founded>1900</Founded></Teams></testTeamsResponse>`,
+		},
+		"csv": {
+			accept: ContentTypeCSV,
+			status: http.StatusOK,
+			body:   "name,founded,stadium.city,stadium.capacity\n\"Hamburg, SV\",1887,Hamburg,57000\n,1900,,\n",
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			err := RenderList(w, r, http.StatusOK, response, list)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.accept, w.Header().Get("Content-Type"))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		render      func(w http.ResponseWriter, r *http.Request) error
+		accept      string
+		status      int
+		contentType string
+		err         bool
+	}{
+		"not acceptable writes nothing": {
+			render: func(w http.ResponseWriter, r *http.Request) error {
+				return Render(w, r, http.StatusCreated, teams()[1])
+			},
+			accept: "text/csv",
+			status: http.StatusOK, // the default of the recorder
+			err:    true,
+		},
+		"status is written": {
+			render: func(w http.ResponseWriter, r *http.Request) error {
+				return Render(w, r, http.StatusCreated, teams()[1])
+			},
+			accept:      "application/json",
+			status:      http.StatusCreated,
+			contentType: ContentTypeJSON,
+		},
+		"error falls back to json": {
+			render: func(w http.ResponseWriter, r *http.Request) error {
+				return RenderError(w, r, http.StatusNotFound, teams()[1])
+			},
+			accept:      "text/html",
+			status:      http.StatusNotFound,
+			contentType: ContentTypeJSON,
+		},
+		"error in requested type": {
+			render: func(w http.ResponseWriter, r *http.Request) error {
+				return RenderError(w, r, http.StatusNotFound, teams()[1])
+			},
+			accept:      "application/xml",
+			status:      http.StatusNotFound,
+			contentType: ContentTypeXML,
+		},
+		"csv needs a list": {
+			render: func(w http.ResponseWriter, r *http.Request) error {
+				return RenderList(w, r, http.StatusOK, teams()[1], teams()[1])
+			},
+			accept: "text/csv",
+			status: http.StatusOK,
+			err:    true,
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+
+			err := tt.render(w, r)
+
+			assert.Equal(t, tt.err, err != nil)
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+		})
+	}
+}
+
+func TestEncodeMsgPack(t *testing.T) {
+	t.Parallel()
+
+	longString := string(bytes.Repeat([]byte("a"), 40))
+
+	tests := map[string]struct {
+		value    interface{}
+		expected []byte
+	}{
+		"nil":             {value: nil, expected: []byte{0xc0}},
+		"bool":            {value: []bool{true, false}, expected: []byte{0x92, 0xc3, 0xc2}},
+		"positive fixint": {value: 7, expected: []byte{0x07}},
+		"negative fixint": {value: -3, expected: []byte{0xfd}},
+		"int32":           {value: 1887, expected: []byte{0xd2, 0x00, 0x00, 0x07, 0x5f}},
+		"int64":           {value: int64(1) << 40, expected: []byte{0xd3, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00}},
+		"float":           {value: 0.5, expected: []byte{0xcb, 0x3f, 0xe0, 0, 0, 0, 0, 0, 0}},
+		"fixstr":          {value: "HSV", expected: []byte{0xa3, 'H', 'S', 'V'}},
+		"str8":            {value: longString, expected: append([]byte{0xd9, 40}, longString...)},
+		"whole float":     {value: 2.0, expected: []byte{0xcb, 0x40, 0, 0, 0, 0, 0, 0, 0}},
+		"float32":         {value: float32(0.5), expected: []byte{0xca, 0x3f, 0, 0, 0}},
+		"uint64":          {value: uint64(math.MaxUint64), expected: []byte{0xcf, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		"bytes":           {value: []byte{1, 2}, expected: []byte{0xc4, 0x02, 0x01, 0x02}},
+		"nil slice":       {value: []string(nil), expected: []byte{0xc0}},
+		"time":            {value: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), expected: append([]byte{0xb4}, "2021-03-01T00:00:00Z"...)},
+		"map":             {value: map[string]float64{"b": 1, "a": 0.5}, expected: []byte{0x82, 0xa1, 'a', 0xcb, 0x3f, 0xe0, 0, 0, 0, 0, 0, 0, 0xa1, 'b', 0xcb, 0x3f, 0xf0, 0, 0, 0, 0, 0, 0}},
+		"map with sorted keys": {
+			value:    testStadium{City: "A", Capacity: 1},
+			expected: []byte{0x82, 0xa8, 'c', 'a', 'p', 'a', 'c', 'i', 't', 'y', 0x01, 0xa4, 'c', 'i', 't', 'y', 0xa1, 'A'},
+		},
+		"omitempty and unexported fields": {
+			value:    teams()[1],
+			expected: []byte{0x81, 0xa7, 'f', 'o', 'u', 'n', 'd', 'e', 'd', 0xd2, 0x00, 0x00, 0x07, 0x6c},
+		},
+		"embedded struct": {
+			value: struct {
+				testStadium
+				Name string `json:"name"`
+			}{testStadium: testStadium{City: "A", Capacity: 1}, Name: "B"},
+			expected: []byte{0x83, 0xa8, 'c', 'a', 'p', 'a', 'c', 'i', 't', 'y', 0x01, 0xa4, 'c', 'i', 't', 'y', 0xa1, 'A', 0xa4, 'n', 'a', 'm', 'e', 0xa1, 'B'},
+		},
+	}
+
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var buf bytes.Buffer
+			err := encodeMsgPack(&buf, tt.value)
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, buf.Bytes())
+		})
+	}
+}
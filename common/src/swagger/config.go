@@ -0,0 +1,45 @@
+/*
+ *  config.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package swagger
+
+import (
+	"flag"
+	"fmt"
+)
+
+const (
+	ResponseValidationOff  = "off"  // responses are not validated
+	ResponseValidationLog  = "log"  // responses which do not match the spec are logged, but sent unchanged
+	ResponseValidationFail = "fail" // responses which do not match the spec are replaced with a 500 error response
+)
+
+// Config contains the properties of the OpenAPI validation middleware.
+// ValidateRequests rejects requests which do not match the spec with a 400 error response.
+// ResponseValidation is one of ResponseValidationOff, ResponseValidationLog or ResponseValidationFail and is meant for tests and staging.
+type Config struct {
+	ValidateRequests   bool
+	ResponseValidation string
+}
+
+// BindConfig takes a Config and a FlagSet and stores the validation-relevant flags in the corresponding config fields.
+func BindConfig(config *Config, fs *flag.FlagSet) {
+	fs.BoolVar(&config.ValidateRequests, "swagger.validateRequests", true, "validate incoming requests against the OpenAPI spec")
+	fs.StringVar(&config.ResponseValidation, "swagger.responseValidation", ResponseValidationOff, "validate outgoing responses against the OpenAPI spec, either 'off', 'log' or 'fail'")
+}
+
+// IsValid returns true, if ResponseValidation has a valid value.
+func (config *Config) IsValid() bool {
+
+	if config.ResponseValidation != ResponseValidationOff &&
+		config.ResponseValidation != ResponseValidationLog &&
+		config.ResponseValidation != ResponseValidationFail {
+		fmt.Println("swagger response validation must either be 'off', 'log' or 'fail'")
+		return false
+	}
+
+	return true
+}
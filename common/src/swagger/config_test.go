@@ -0,0 +1,99 @@
+/*
+ *  config_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package swagger
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindConfig(t *testing.T) {
+	t.Parallel()
+
+	type input struct {
+		args []string
+	}
+
+	type output struct {
+		assert func(assert *assert.Assertions, cfg *Config)
+	}
+
+	cases := map[string]struct {
+		input  input
+		output output
+	}{
+		"defaults": {
+			input: input{
+				args: []string{},
+			},
+			output: output{
+				assert: func(assert *assert.Assertions, cfg *Config) {
+					assert.True(cfg.ValidateRequests)
+					assert.Equal(ResponseValidationOff, cfg.ResponseValidation)
+				},
+			},
+		},
+		"everything set": {
+			input: input{
+				args: []string{
+					"--swagger.validateRequests=false",
+					"--swagger.responseValidation", "fail",
+				},
+			},
+			output: output{
+				assert: func(assert *assert.Assertions, cfg *Config) {
+					assert.False(cfg.ValidateRequests)
+					assert.Equal(ResponseValidationFail, cfg.ResponseValidation)
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			cfg := Config{}
+
+			BindConfig(&cfg, fs)
+
+			err := fs.Parse(tc.input.args)
+			assert.NoError(err)
+
+			tc.output.assert(assert, &cfg)
+		})
+	}
+}
+
+func TestConfig_IsValid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		responseValidation string
+		isValid            bool
+	}{
+		"off":     {responseValidation: ResponseValidationOff, isValid: true},
+		"log":     {responseValidation: ResponseValidationLog, isValid: true},
+		"fail":    {responseValidation: ResponseValidationFail, isValid: true},
+		"unknown": {responseValidation: "sometimes", isValid: false},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := Config{ResponseValidation: tc.responseValidation}
+			assert.Equal(t, tc.isValid, cfg.IsValid())
+		})
+	}
+}
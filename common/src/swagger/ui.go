@@ -15,28 +15,59 @@ import (
 	"github.com/go-chi/chi"
 )
 
+// Spec is an API specification served by the swagger UI. The specification is served at Path + "/swagger.json" and
+// its operations are resolved relative to the context path and Path.
+type Spec struct {
+	Name string
+	Path string
+	Doc  *openapi3.Swagger
+}
+
 func RegisterSwaggerHandlers(r chi.Router, swaggerDoc *openapi3.Swagger, contextPath string) {
+	RegisterSpecHandlers(r, contextPath, Spec{Name: "default", Doc: swaggerDoc})
+}
+
+// RegisterSpecHandlers serves all given specs and a swagger UI, which offers a selection of them. The first spec is
+// shown initially.
+func RegisterSpecHandlers(r chi.Router, contextPath string, specs ...Spec) {
 
 	basePath := ""
 
 	if contextPath != "" {
-		swaggerDoc.Servers = openapi3.Servers{
-			&openapi3.Server{URL: "/" + contextPath},
+		basePath = "/" + contextPath
+	}
+
+	type specUrl struct {
+		Name string `json:"name"`
+		Url  string `json:"url"`
+	}
+	urls := make([]specUrl, 0, len(specs))
+
+	for _, spec := range specs {
+		swaggerDoc := spec.Doc
+
+		if basePath+spec.Path != "" {
+			swaggerDoc.Servers = openapi3.Servers{
+				&openapi3.Server{URL: basePath + spec.Path},
+			}
 		}
 
-		basePath = "/" + contextPath
+		r.Get(spec.Path+"/swagger.json", func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(swaggerDoc)
+		})
+
+		urls = append(urls, specUrl{Name: spec.Name, Url: basePath + spec.Path + "/swagger.json"})
 	}
 
+	urlsJson, _ := json.Marshal(urls)
+	ui := strings.ReplaceAll(UI, "{{urls}}", string(urlsJson))
+
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
 		http.Redirect(w, r, basePath+"/swagger-ui", http.StatusTemporaryRedirect)
 	})
 
-	r.Get("/swagger.json", func(w http.ResponseWriter, r *http.Request) {
-		json.NewEncoder(w).Encode(swaggerDoc)
-	})
-
 	r.Get("/swagger-ui", func(w http.ResponseWriter, r *http.Request) {
-		w.Write([]byte(strings.ReplaceAll(UI, "{{basePath}}", basePath)))
+		w.Write([]byte(ui))
 	})
 }
 
@@ -243,7 +274,7 @@ var UI = `
     window.onload = function() {
       // Begin Swagger UI call region
       const ui = SwaggerUIBundle({
-        url: "{{basePath}}/swagger.json",
+        urls: {{urls}},
         dom_id: '#swagger-ui',
         deepLinking: true,
         presets: [
@@ -7,10 +7,13 @@
 package swagger
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
 )
 
 func TestRegisterSwaggerHandlers(t *testing.T) {
@@ -20,3 +23,38 @@ func TestRegisterSwaggerHandlers(t *testing.T) {
 	RegisterSwaggerHandlers(r, &openapi3.Swagger{}, "myContextPath")
 
 }
+
+func TestRegisterSpecHandlers(t *testing.T) {
+	t.Parallel()
+
+	r := chi.NewRouter()
+	v1 := &openapi3.Swagger{Info: &openapi3.Info{Title: "v1"}}
+	v2 := &openapi3.Swagger{Info: &openapi3.Info{Title: "v2"}}
+	RegisterSpecHandlers(r, "api", Spec{Name: "v2", Path: "/v2", Doc: v2}, Spec{Name: "v1", Path: "/v1", Doc: v1})
+
+	cases := map[string]struct {
+		path     string
+		contains string
+		server   string
+	}{
+		"v1 spec":   {path: "/v1/swagger.json", contains: `"title":"v1"`, server: "/api/v1"},
+		"v2 spec":   {path: "/v2/swagger.json", contains: `"title":"v2"`, server: "/api/v2"},
+		"ui offers": {path: "/swagger-ui", contains: `urls: [{"name":"v2","url":"/api/v2/swagger.json"},{"name":"v1","url":"/api/v1/swagger.json"}]`},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tc.path, nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Contains(t, w.Body.String(), tc.contains)
+			if tc.server != "" {
+				assert.Contains(t, w.Body.String(), `"url":"`+tc.server+`"`)
+			}
+		})
+	}
+}
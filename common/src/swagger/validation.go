@@ -0,0 +1,249 @@
+/*
+ *  validation.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package swagger
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	verrors "sheazuzu/common/src/errors"
+	"sheazuzu/common/src/render"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/go-chi/chi"
+	"go.uber.org/zap"
+)
+
+// errorResponse has the same structure as the ErrorResponse schema of the service specs
+type errorResponse struct {
+	XMLName xml.Name `json:"-" xml:"ErrorResponse"`
+	Code    int32    `json:"code"`
+	Name    string   `json:"name"`
+	Message string   `json:"message"`
+	Details []string `json:"details"`
+}
+
+// ValidationHandler returns a middleware that validates the path, query, header parameters and the body of a request
+// against the operation with the given operationId. Invalid requests are answered with a 400 error response listing
+// every violation. Depending on Config.ResponseValidation the responses are validated as well.
+func ValidationHandler(swaggerDoc *openapi3.Swagger, operationId string, config Config, logger *zap.SugaredLogger) (func(http.Handler) http.Handler, error) {
+
+	route, err := findRoute(swaggerDoc, operationId)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &openapi3filter.Options{
+		MultiError:            true,
+		IncludeResponseStatus: true,
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			requestInput := &openapi3filter.RequestValidationInput{
+				Request:    r,
+				PathParams: pathParams(r),
+				Route:      route,
+				Options:    options,
+			}
+
+			if config.ValidateRequests {
+				err := openapi3filter.ValidateRequest(r.Context(), requestInput)
+				if err != nil {
+					op := verrors.Op("swagger: Validate Request")
+					writeViolations(w, r, verrors.E(op, verrors.HttpBadRequest, err), "The request does not match the API specification", Violations(err))
+					return
+				}
+			}
+
+			if config.ResponseValidation == ResponseValidationOff || config.ResponseValidation == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// the spec only describes the JSON representation of the responses, other negotiated content types are not validated
+			if mediaType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type")); mediaType != "" && mediaType != "application/json" {
+				recorder.flush()
+				return
+			}
+
+			responseInput := &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: requestInput,
+				Status:                 recorder.status,
+				Header:                 w.Header(),
+				Options:                options,
+			}
+			responseInput.SetBodyBytes(recorder.body.Bytes())
+
+			err := openapi3filter.ValidateResponse(r.Context(), responseInput)
+			if err != nil {
+				violations := Violations(err)
+				logger.Warnw("response does not match the API specification",
+					"operationId", operationId,
+					"statusCode", recorder.status,
+					"violations", violations)
+
+				if config.ResponseValidation == ResponseValidationFail {
+					op := verrors.Op("swagger: Validate Response")
+					writeViolations(w, r, verrors.E(op, verrors.HttpInternal, err), "The response does not match the API specification", violations)
+					return
+				}
+			}
+
+			recorder.flush()
+		})
+	}, nil
+}
+
+// findRoute looks up the operation with the given operationId in the spec. The case is ignored, as the spec embedded
+// by the code generator starts the operationIds with an upper case letter.
+func findRoute(swaggerDoc *openapi3.Swagger, operationId string) (*openapi3filter.Route, error) {
+
+	for path, pathItem := range swaggerDoc.Paths {
+		for method, operation := range pathItem.Operations() {
+			if strings.EqualFold(operation.OperationID, operationId) {
+				return &openapi3filter.Route{
+					Swagger:   swaggerDoc,
+					Path:      path,
+					PathItem:  pathItem,
+					Method:    method,
+					Operation: operation,
+				}, nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("operation '%s' not found in the API specification", operationId)
+}
+
+// pathParams returns the path parameters chi extracted from the request url
+func pathParams(r *http.Request) map[string]string {
+
+	params := map[string]string{}
+
+	routeContext := chi.RouteContext(r.Context())
+	if routeContext == nil {
+		return params
+	}
+
+	for i, key := range routeContext.URLParams.Keys {
+		params[key] = routeContext.URLParams.Values[i]
+	}
+
+	return params
+}
+
+// Violations flattens a validation error into one readable message per violation.
+func Violations(err error) []string {
+
+	switch err := err.(type) {
+	case nil:
+		return nil
+
+	case openapi3.MultiError:
+		var violations []string
+		for _, e := range err {
+			violations = append(violations, Violations(e)...)
+		}
+		return violations
+
+	case *openapi3filter.RequestError:
+		var prefix string
+		switch {
+		case err.Parameter != nil:
+			prefix = fmt.Sprintf("%s parameter '%s'", err.Parameter.In, err.Parameter.Name)
+		case err.RequestBody != nil:
+			prefix = "request body"
+		default:
+			prefix = "request"
+		}
+		return prefixed(prefix, err.Reason, err.Err)
+
+	case *openapi3filter.ResponseError:
+		return prefixed("response", err.Reason, err.Err)
+
+	case *openapi3filter.ParseError:
+		if err.Reason == "" || err.Value == nil {
+			return []string{err.Error()}
+		}
+		return []string{fmt.Sprintf("value %v: %s", err.Value, err.Reason)}
+
+	case *openapi3.SchemaError:
+		pointer := strings.Join(err.JSONPointer(), "/")
+		if pointer == "" {
+			return []string{err.Reason}
+		}
+		return []string{fmt.Sprintf("/%s: %s", pointer, err.Reason)}
+
+	default:
+		return []string{err.Error()}
+	}
+}
+
+// prefixed builds the violations of the cause, or the reason itself if there is no cause, with the given prefix
+func prefixed(prefix, reason string, cause error) []string {
+
+	if cause == nil {
+		return []string{fmt.Sprintf("%s: %s", prefix, reason)}
+	}
+
+	var violations []string
+	for _, violation := range Violations(cause) {
+		if strings.HasPrefix(violation, "/") {
+			violations = append(violations, prefix+" "+violation)
+		} else {
+			violations = append(violations, fmt.Sprintf("%s: %s", prefix, violation))
+		}
+	}
+
+	return violations
+}
+
+// writeViolations renders the violations in the content type negotiated from the Accept header, like the other error
+// responses, with JSON as fallback
+func writeViolations(w http.ResponseWriter, r *http.Request, err error, message string, violations []string) {
+
+	statusCode := verrors.HttpErrorCodeFromError(err)
+
+	_ = render.RenderError(w, r, statusCode, errorResponse{
+		Code:    verrors.GetErrorCode(err),
+		Name:    http.StatusText(statusCode),
+		Message: message,
+		Details: violations,
+	})
+}
+
+// responseRecorder holds back the status and body of a response until it has been validated,
+// the headers are written to the underlying http.ResponseWriter directly, as they are only sent with the status
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (recorder *responseRecorder) WriteHeader(status int) {
+	recorder.status = status
+}
+
+func (recorder *responseRecorder) Write(b []byte) (int, error) {
+	return recorder.body.Write(b)
+}
+
+// flush sends the recorded response
+func (recorder *responseRecorder) flush() {
+	recorder.ResponseWriter.WriteHeader(recorder.status)
+	_, _ = recorder.ResponseWriter.Write(recorder.body.Bytes())
+}
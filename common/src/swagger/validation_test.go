@@ -0,0 +1,249 @@
+/*
+ *  validation_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package swagger
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/go-chi/chi"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+var testSpec = []byte(`
+openapi: 3.0.1
+info:
+  title: test
+  version: '1.0'
+paths:
+  /teams/{name}:
+    post:
+      operationId: postTeam
+      parameters:
+        - name: name
+          in: path
+          required: true
+          schema:
+            type: string
+            minLength: 3
+        - name: limit
+          in: query
+          required: true
+          schema:
+            type: integer
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              required:
+                - city
+              properties:
+                city:
+                  type: string
+                founded:
+                  type: integer
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                required:
+                  - id
+                properties:
+                  id:
+                    type: integer
+`)
+
+func TestValidationHandler(t *testing.T) {
+	t.Parallel()
+
+	type input struct {
+		config   Config
+		path     string
+		body     string
+		response string
+	}
+
+	type output struct {
+		status     int
+		violations []string
+	}
+
+	cases := map[string]struct {
+		input  input
+		output output
+	}{
+		"valid request and response": {
+			input: input{
+				config:   Config{ValidateRequests: true, ResponseValidation: ResponseValidationFail},
+				path:     "/teams/foo?limit=1",
+				body:     `{"city": "Wolfsburg", "founded": 1945}`,
+				response: `{"id": 1}`,
+			},
+			output: output{
+				status: http.StatusOK,
+			},
+		},
+		"every request violation is listed": {
+			input: input{
+				config:   Config{ValidateRequests: true, ResponseValidation: ResponseValidationOff},
+				path:     "/teams/fo?limit=abc",
+				body:     `{"founded": "1945"}`,
+				response: `{"id": 1}`,
+			},
+			output: output{
+				status: http.StatusBadRequest,
+				violations: []string{
+					"path parameter 'name': Minimum string length is 3",
+					"query parameter 'limit': value abc: an invalid integer",
+					"request body /city: Property 'city' is missing",
+					"request body /founded: Field must be set to integer or not be present",
+				},
+			},
+		},
+		"request validation disabled": {
+			input: input{
+				config:   Config{ValidateRequests: false, ResponseValidation: ResponseValidationOff},
+				path:     "/teams/fo",
+				body:     `{}`,
+				response: `{"id": 1}`,
+			},
+			output: output{
+				status: http.StatusOK,
+			},
+		},
+		"invalid response is logged": {
+			input: input{
+				config:   Config{ValidateRequests: true, ResponseValidation: ResponseValidationLog},
+				path:     "/teams/foo?limit=1",
+				body:     `{"city": "Wolfsburg"}`,
+				response: `{"name": "foo"}`,
+			},
+			output: output{
+				status: http.StatusOK,
+			},
+		},
+		"invalid response fails": {
+			input: input{
+				config:   Config{ValidateRequests: true, ResponseValidation: ResponseValidationFail},
+				path:     "/teams/foo?limit=1",
+				body:     `{"city": "Wolfsburg"}`,
+				response: `{"name": "foo"}`,
+			},
+			output: output{
+				status: http.StatusInternalServerError,
+				violations: []string{
+					"response /id: Property 'id' is missing",
+				},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert := assert.New(t)
+
+			swaggerDoc, err := openapi3.NewSwaggerLoader().LoadSwaggerFromData(testSpec)
+			assert.NoError(err)
+
+			validation, err := ValidationHandler(swaggerDoc, "postTeam", tc.input.config, zap.NewNop().Sugar())
+			assert.NoError(err)
+
+			r := chi.NewRouter()
+			r.With(validation).Post("/teams/{name}", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				_, _ = w.Write([]byte(tc.input.response))
+			})
+
+			request := httptest.NewRequest(http.MethodPost, tc.input.path, strings.NewReader(tc.input.body))
+			request.Header.Set("Content-Type", "application/json")
+			recorder := httptest.NewRecorder()
+
+			r.ServeHTTP(recorder, request)
+
+			assert.Equal(tc.output.status, recorder.Code)
+			if tc.output.violations == nil {
+				assert.JSONEq(tc.input.response, recorder.Body.String())
+				return
+			}
+
+			var response errorResponse
+			assert.NoError(json.Unmarshal(recorder.Body.Bytes(), &response))
+			assert.ElementsMatch(tc.output.violations, response.Details)
+		})
+	}
+}
+
+func TestValidationHandler_contentType(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		"json":         {accept: "application/json", contentType: "application/json", body: `"details":["path parameter 'name'`},
+		"xml":          {accept: "application/xml", contentType: "application/xml", body: "<ErrorResponse><Code>400</Code>"},
+		"msgpack":      {accept: "application/msgpack", contentType: "application/msgpack", body: "\xa7details"},
+		"unacceptable": {accept: "text/html", contentType: "application/json", body: `"details":["path parameter 'name'`},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			swaggerDoc, err := openapi3.NewSwaggerLoader().LoadSwaggerFromData(testSpec)
+			assert.NoError(t, err)
+
+			validation, err := ValidationHandler(swaggerDoc, "postTeam", Config{ValidateRequests: true}, zap.NewNop().Sugar())
+			assert.NoError(t, err)
+
+			r := chi.NewRouter()
+			r.With(validation).Post("/teams/{name}", func(w http.ResponseWriter, r *http.Request) {})
+
+			request := httptest.NewRequest(http.MethodPost, "/teams/x", strings.NewReader(`{}`))
+			request.Header.Set("Content-Type", "application/json")
+			request.Header.Set("Accept", tc.accept)
+			recorder := httptest.NewRecorder()
+
+			r.ServeHTTP(recorder, request)
+
+			assert.Equal(t, http.StatusBadRequest, recorder.Code)
+			assert.Equal(t, tc.contentType, recorder.Header().Get("Content-Type"))
+			assert.Contains(t, recorder.Body.String(), tc.body)
+		})
+	}
+}
+
+func TestValidationHandler_unknownOperation(t *testing.T) {
+	t.Parallel()
+
+	_, err := ValidationHandler(&openapi3.Swagger{}, "unknown", Config{}, zap.NewNop().Sugar())
+	assert.Error(t, err)
+}
+
+func TestValidationHandler_operationIdCase(t *testing.T) {
+	t.Parallel()
+
+	swaggerDoc, err := openapi3.NewSwaggerLoader().LoadSwaggerFromData(testSpec)
+	assert.NoError(t, err)
+
+	_, err = ValidationHandler(swaggerDoc, "PostTeam", Config{}, zap.NewNop().Sugar())
+	assert.NoError(t, err)
+}
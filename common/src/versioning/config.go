@@ -0,0 +1,62 @@
+/*
+ *  config.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package versioning
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the layout of the deprecation and sunset dates in the configuration.
+const DateLayout = "2006-01-02"
+
+// Config contains the lifecycle dates of one API version. An empty date omits the corresponding header.
+// Successor is the path of the API version replacing this one, it is linked with rel="successor-version".
+type Config struct {
+	Deprecation string
+	Sunset      string
+	Successor   string
+}
+
+// BindConfig takes a Config and a FlagSet and stores the lifecycle flags of the given API version, e.g. "v1", in the
+// corresponding config fields. The defaults are used as flag defaults.
+func BindConfig(config *Config, fs *flag.FlagSet, version string, defaults Config) {
+	fs.StringVar(&config.Deprecation, "api."+version+".deprecation", defaults.Deprecation, "date (YYYY-MM-DD) since the API "+version+" is deprecated, empty if not deprecated")
+	fs.StringVar(&config.Sunset, "api."+version+".sunset", defaults.Sunset, "date (YYYY-MM-DD) when the API "+version+" will be removed, empty if not planned")
+	fs.StringVar(&config.Successor, "api."+version+".successor", defaults.Successor, "path of the API version succeeding "+version)
+}
+
+// IsValid returns true, if the dates can be parsed and the sunset is not before the deprecation.
+func (config *Config) IsValid() bool {
+
+	deprecation, err := parseDate(config.Deprecation)
+	if err != nil {
+		fmt.Printf("invalid deprecation date %q: %s\n", config.Deprecation, err)
+		return false
+	}
+
+	sunset, err := parseDate(config.Sunset)
+	if err != nil {
+		fmt.Printf("invalid sunset date %q: %s\n", config.Sunset, err)
+		return false
+	}
+
+	if !deprecation.IsZero() && !sunset.IsZero() && sunset.Before(deprecation) {
+		fmt.Println("the sunset date must not be before the deprecation date")
+		return false
+	}
+
+	return true
+}
+
+func parseDate(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(DateLayout, date)
+}
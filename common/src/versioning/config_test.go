@@ -0,0 +1,80 @@
+/*
+ *  config_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package versioning
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBindConfig(t *testing.T) {
+	t.Parallel()
+
+	defaults := Config{Deprecation: "2026-10-19", Sunset: "2027-04-19", Successor: "/v2"}
+
+	cases := map[string]struct {
+		args     []string
+		expected Config
+	}{
+		"defaults": {
+			args:     []string{},
+			expected: defaults,
+		},
+		"everything set": {
+			args: []string{
+				"--api.v1.deprecation", "2026-11-01",
+				"--api.v1.sunset", "",
+				"--api.v1.successor", "/api/v2",
+			},
+			expected: Config{Deprecation: "2026-11-01", Sunset: "", Successor: "/api/v2"},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			fs := flag.NewFlagSet("", flag.ContinueOnError)
+			cfg := Config{}
+
+			BindConfig(&cfg, fs, "v1", defaults)
+
+			err := fs.Parse(tc.args)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, cfg)
+		})
+	}
+}
+
+func TestConfig_IsValid(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		cfg     Config
+		isValid bool
+	}{
+		"valid":                      {cfg: Config{Deprecation: "2026-10-19", Sunset: "2027-04-19"}, isValid: true},
+		"not deprecated":             {cfg: Config{}, isValid: true},
+		"same day":                   {cfg: Config{Deprecation: "2026-10-19", Sunset: "2026-10-19"}, isValid: true},
+		"invalid deprecation":        {cfg: Config{Deprecation: "19.10.2026"}, isValid: false},
+		"invalid sunset":             {cfg: Config{Sunset: "tomorrow"}, isValid: false},
+		"sunset before deprecation":  {cfg: Config{Deprecation: "2026-10-19", Sunset: "2026-10-18"}, isValid: false},
+		"sunset without deprecation": {cfg: Config{Sunset: "2026-10-18"}, isValid: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tc.isValid, tc.cfg.IsValid())
+		})
+	}
+}
@@ -0,0 +1,40 @@
+/*
+ *  deprecation.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package versioning
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// DeprecationHandler returns a middleware announcing the lifecycle of an API version to the clients:
+// the Deprecation header (RFC 9745) carries the deprecation date as unix timestamp, the Sunset header (RFC 8594) the
+// date of the removal and the Link header points to the successor version. The config has to be valid.
+func DeprecationHandler(config Config) func(http.Handler) http.Handler {
+
+	deprecation, _ := parseDate(config.Deprecation)
+	sunset, _ := parseDate(config.Sunset)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+
+			if !deprecation.IsZero() {
+				w.Header().Set("Deprecation", fmt.Sprintf("@%d", deprecation.Unix()))
+			}
+
+			if !sunset.IsZero() {
+				w.Header().Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+			}
+
+			if config.Successor != "" && (!deprecation.IsZero() || !sunset.IsZero()) {
+				w.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, config.Successor))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
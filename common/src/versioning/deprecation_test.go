@@ -0,0 +1,59 @@
+/*
+ *  deprecation_test.go
+ *  Created on 19.10.2026
+ *  Copyright (C) 2026 Volkswagen AG, All rights reserved.
+ */
+
+package versioning
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecationHandler(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		cfg         Config
+		deprecation string
+		sunset      string
+		link        string
+	}{
+		"deprecated with sunset": {
+			cfg:         Config{Deprecation: "2026-10-19", Sunset: "2027-04-19", Successor: "/v2"},
+			deprecation: "@1792368000",
+			sunset:      "Mon, 19 Apr 2027 00:00:00 GMT",
+			link:        `</v2>; rel="successor-version"`,
+		},
+		"deprecated without sunset": {
+			cfg:         Config{Deprecation: "2026-10-19"},
+			deprecation: "@1792368000",
+		},
+		"not deprecated": {
+			cfg: Config{Successor: "/v2"},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			handler := DeprecationHandler(tc.cfg)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/find/allData", nil))
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tc.deprecation, w.Header().Get("Deprecation"))
+			assert.Equal(t, tc.sunset, w.Header().Get("Sunset"))
+			assert.Equal(t, tc.link, w.Header().Get("Link"))
+		})
+	}
+}
@@ -18,12 +18,33 @@ func init() {
 	VERSION = props["sheazuzu.version"]
 }
 
+// every version of the API has its own spec in the api directory and its server is generated into its own package
+var apiVersions = []struct {
+	spec        string
+	packageName string
+}{
+	{spec: "swagger.yaml", packageName: "sheazuzu"},      // v1
+	{spec: "swagger-v2.yaml", packageName: "sheazuzuv2"}, // v2
+}
+
 func Prepare() error {
-	return build.PrepareVersion(VERSION, build.GetAPIDir(MODULE)+"/swagger.yaml", build.GetTargetDir(MODULE)+"/swagger-sheazuzu.yaml")
+	for _, api := range apiVersions {
+		err := build.PrepareVersion(VERSION, build.GetAPIDir(MODULE)+"/"+api.spec, build.GetTargetDir(MODULE)+"/swagger-"+api.packageName+".yaml")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func GenerateServer() error {
-	return build.GenerateSwaggerServer(build.GetTargetDir(MODULE)+"/swagger-sheazuzu.yaml", "sheazuzu", build.GetGeneratedDir(MODULE)+"/sheazuzu")
+	for _, api := range apiVersions {
+		err := build.GenerateSwaggerServer(build.GetTargetDir(MODULE)+"/swagger-"+api.packageName+".yaml", api.packageName, build.GetGeneratedDir(MODULE)+"/"+api.packageName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func Build() error {
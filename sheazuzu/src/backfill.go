@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sheazuzu/common/src/cli"
+	"sheazuzu/common/src/logging"
+	"sheazuzu/sheazuzu/src/backfill"
+	"sheazuzu/sheazuzu/src/configuration"
+	"sheazuzu/sheazuzu/src/database"
+)
+
+// the number of ids of each kind printed by the verification
+const verifyListedIds = 20
+
+func backfillCommand(config *configuration.Configuration) cli.Command {
+
+	validate := func() bool {
+		return config.Validate() && config.Database.IsValid() && config.Mongo.IsValid()
+	}
+
+	runFlags := config.SetupFlags("sheazuzu")
+	options := backfill.Options{}
+	runFlags.IntVar(&options.BatchSize, "backfill.batchSize", backfill.DefaultBatchSize, "the number of matches copied at once")
+	runFlags.BoolVar(&options.Restart, "backfill.restart", false, "copy all matches again instead of resuming behind the last checkpoint")
+
+	verifyFlags := config.SetupFlags("sheazuzu")
+	batchSize := verifyFlags.Int("backfill.batchSize", backfill.DefaultBatchSize, "the number of matches compared at once")
+
+	return cli.Command{
+		Name:  "backfill",
+		Usage: "Copies the matches of the SQL database to MongoDB and verifies both stores",
+		SubCommands: []cli.Command{
+			{
+				Name:     "run",
+				Usage:    "Copies all matches in batches, an interrupted backfill resumes behind the last copied batch",
+				Flags:    runFlags,
+				Validate: validate,
+				Run:      BackfillMatches(config, &options),
+			},
+			{
+				Name:     "verify",
+				Usage:    "Compares both stores match by match and reports the missing, extra and differing matches of MongoDB",
+				Flags:    verifyFlags,
+				Validate: validate,
+				Run:      VerifyBackfill(config, batchSize),
+			},
+		},
+	}
+}
+
+func BackfillMatches(cfg *configuration.Configuration, options *backfill.Options) func(cmd *cli.Command, args ...string) {
+	return withBackfiller(cfg, func(backfiller *backfill.Backfiller) error {
+
+		report, err := backfiller.Run(context.Background(), *options)
+
+		fmt.Printf("copied %d matches after id %d, the last id is %d\n", report.Copied, report.ResumedAfter, report.LastId)
+		return err
+	})
+}
+
+func VerifyBackfill(cfg *configuration.Configuration, batchSize *int) func(cmd *cli.Command, args ...string) {
+	return withBackfiller(cfg, func(backfiller *backfill.Backfiller) error {
+
+		report, err := backfiller.Verify(context.Background(), *batchSize)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("compared %d matches: %d missing, %d extra, %d differing\n", report.Compared, len(report.Missing), len(report.Extra), len(report.Differing))
+		printIds("missing in MongoDB", report.Missing)
+		printIds("only in MongoDB", report.Extra)
+		for i, difference := range report.Differing {
+			if i == verifyListedIds {
+				fmt.Printf("  ... %d more differing\n", len(report.Differing)-i)
+				break
+			}
+			fmt.Printf("  differing %d: %v\n", difference.Id, difference.Fields)
+		}
+
+		if !report.Consistent() {
+			return errors.New("MongoDB differs from the SQL database")
+		}
+		return nil
+	})
+}
+
+func printIds(title string, ids []int) {
+
+	if len(ids) == 0 {
+		return
+	}
+	if len(ids) > verifyListedIds {
+		fmt.Printf("  %s: %v ... %d more\n", title, ids[:verifyListedIds], len(ids)-verifyListedIds)
+		return
+	}
+	fmt.Printf("  %s: %v\n", title, ids)
+}
+
+// withBackfiller connects to both stores and runs f with a backfiller, an error of f exits with 1
+func withBackfiller(cfg *configuration.Configuration, f func(backfiller *backfill.Backfiller) error) func(cmd *cli.Command, args ...string) {
+	return func(cmd *cli.Command, args ...string) {
+
+		logger := logging.GetLogger(cfg.Logging.Level, cfg.Logging.Format).
+			With("version", cmd.Version)
+
+		db, err := database.Connect(&cfg.Database, logger)
+		if err != nil {
+			logger.Error("error connecting to the database", "error", err)
+			os.Exit(1)
+		}
+		defer db.Close()
+
+		migrator, err := database.NewMigrator(db, logger)
+		if err == nil {
+			err = migrator.Check(context.Background())
+		}
+		if err != nil {
+			logger.Error("error checking the schema of the database, run 'migrate up' first", "error", err)
+			db.Close()
+			os.Exit(1)
+		}
+
+		mongoDatabase, disconnect, err := connectMongo(cfg, logger)
+		if err != nil {
+			logger.Error("error connecting to MongoDB", "error", err)
+			db.Close()
+			os.Exit(1)
+		}
+		defer disconnect()
+
+		err = f(backfill.NewBackfiller(db, mongoDatabase, logger))
+		if err != nil {
+			logger.Error("error running the backfill", "error", err)
+			disconnect()
+			db.Close()
+			os.Exit(1)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+// Package backfill copies the matches stored in MySQL to MongoDB and verifies, that both stores hold the same matches.
+package backfill
+
+import (
+	"context"
+	"fmt"
+	commondb "sheazuzu/common/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+)
+
+// CheckpointName is the name of the checkpoint of the backfill in MongoDB
+const CheckpointName = "backfill:matchData"
+
+// DefaultBatchSize is the number of matches read from and written to the stores at once
+const DefaultBatchSize = 500
+
+// reader returns up to limit matches with an id greater than afterId ordered by id
+type reader func(ctx context.Context, afterId int, limit int) ([]entity.MatchData, error)
+
+// target is the MongoDB database, to which the matches are copied
+type target interface {
+	PutBatch(ctx context.Context, data []entity.MatchData) error
+	FindAfterId(ctx context.Context, afterId int, limit int) ([]entity.MatchData, error)
+	Checkpoint(ctx context.Context, name string) (int, error)
+	SaveCheckpoint(ctx context.Context, name string, lastId int) error
+}
+
+// Options configure a backfill. Without Restart a backfill resumes behind the last match of the previous one.
+type Options struct {
+	BatchSize int
+	Restart   bool
+}
+
+// Report counts the matches copied by a backfill
+type Report struct {
+	// ResumedAfter is the id of the checkpoint the backfill started behind, 0 for a complete backfill
+	ResumedAfter int
+	Copied       int
+	LastId       int
+}
+
+// Backfiller copies the matches of MySQL in the order of their ids to MongoDB
+type Backfiller struct {
+	source reader
+	target target
+	logger *zap.SugaredLogger
+}
+
+func NewBackfiller(db *gorm.DB, target target, logger *zap.SugaredLogger) *Backfiller {
+	return &Backfiller{
+		source: mysqlReader(db),
+		target: target,
+		logger: logger,
+	}
+}
+
+// Run copies the matches in batches, the id of the last match of every batch is saved as checkpoint after the batch is
+// written. An interrupted backfill resumes behind the checkpoint, with Options.Restart it starts with the first match.
+// A match changed in MySQL while it is copied can be overwritten with its previous state, so a backfill next to a
+// running outbox relay should be followed by Verify.
+func (backfiller *Backfiller) Run(ctx context.Context, options Options) (Report, error) {
+
+	batchSize := options.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	report := Report{}
+	if !options.Restart {
+		lastId, err := backfiller.target.Checkpoint(ctx, CheckpointName)
+		if err != nil {
+			return report, fmt.Errorf("error reading the checkpoint: %w", err)
+		}
+		report.ResumedAfter = lastId
+		report.LastId = lastId
+	}
+
+	if report.ResumedAfter > 0 {
+		backfiller.logger.Infow("resuming the backfill", "after", report.ResumedAfter)
+	}
+
+	start := time.Now()
+	for {
+		data, err := backfiller.source(ctx, report.LastId, batchSize)
+		if err != nil {
+			return report, fmt.Errorf("error reading the matches after %d from MySQL: %w", report.LastId, err)
+		}
+		if len(data) == 0 {
+			break
+		}
+
+		err = backfiller.target.PutBatch(ctx, data)
+		if err != nil {
+			return report, fmt.Errorf("error writing the matches %d to %d to MongoDB: %w", data[0].Id, data[len(data)-1].Id, err)
+		}
+
+		lastId := data[len(data)-1].Id
+		err = backfiller.target.SaveCheckpoint(ctx, CheckpointName, lastId)
+		if err != nil {
+			return report, fmt.Errorf("error saving the checkpoint %d: %w", lastId, err)
+		}
+
+		report.Copied += len(data)
+		report.LastId = lastId
+		backfiller.logger.Infow("copied matches", "copied", report.Copied, "lastId", lastId, "elapsed", time.Since(start))
+
+		if len(data) < batchSize {
+			break
+		}
+	}
+
+	return report, nil
+}
+
+// Difference lists the fields of a match, which differ between MySQL and MongoDB
+type Difference struct {
+	Id     int
+	Fields []string
+}
+
+// VerifyReport is the result of comparing both stores, the ids are ordered
+type VerifyReport struct {
+	// Compared is the number of matches in MySQL
+	Compared int
+	// Missing are the matches of MySQL, which are not in MongoDB
+	Missing []int
+	// Extra are the matches of MongoDB, which are not in MySQL
+	Extra     []int
+	Differing []Difference
+}
+
+// Consistent returns true, if both stores hold the same matches
+func (report VerifyReport) Consistent() bool {
+	return len(report.Missing) == 0 && len(report.Extra) == 0 && len(report.Differing) == 0
+}
+
+// Verify compares the matches of both stores document by document. Both stores are read in batches in the order of
+// the ids, so they are never loaded into memory at once.
+func (backfiller *Backfiller) Verify(ctx context.Context, batchSize int) (VerifyReport, error) {
+
+	if batchSize <= 0 {
+		batchSize = DefaultBatchSize
+	}
+
+	mysql := &stream{read: backfiller.source, batchSize: batchSize}
+	mongo := &stream{read: backfiller.target.FindAfterId, batchSize: batchSize}
+
+	report := VerifyReport{}
+	for {
+		expected, err := mysql.peek(ctx)
+		if err != nil {
+			return report, fmt.Errorf("error reading the matches from MySQL: %w", err)
+		}
+		actual, err := mongo.peek(ctx)
+		if err != nil {
+			return report, fmt.Errorf("error reading the matches from MongoDB: %w", err)
+		}
+
+		switch {
+		case expected == nil && actual == nil:
+			return report, nil
+
+		case actual == nil || (expected != nil && expected.Id < actual.Id):
+			report.Compared++
+			report.Missing = append(report.Missing, expected.Id)
+			mysql.pop()
+
+		case expected == nil || actual.Id < expected.Id:
+			report.Extra = append(report.Extra, actual.Id)
+			mongo.pop()
+
+		default:
+			report.Compared++
+			if fields := Diff(*expected, *actual); len(fields) > 0 {
+				report.Differing = append(report.Differing, Difference{Id: expected.Id, Fields: fields})
+			}
+			mysql.pop()
+			mongo.pop()
+		}
+	}
+}
+
+// Diff returns the names of the fields, which differ between both matches. MongoDB stores the timestamps in UTC with
+// millisecond precision, so they are compared with it.
+func Diff(expected, actual entity.MatchData) []string {
+
+	var fields []string
+	compare := func(field string, equal bool) {
+		if !equal {
+			fields = append(fields, field)
+		}
+	}
+
+	compare("AwayTeam", expected.AwayTeam == actual.AwayTeam)
+	compare("Date", expected.Date == actual.Date)
+	compare("HomeTeam", expected.HomeTeam == actual.HomeTeam)
+	compare("MatchType", expected.MatchType == actual.MatchType)
+	compare("Result", expected.Result == actual.Result)
+
+	expectedInfo, actualInfo := expected.AdditionalInformation, actual.AdditionalInformation
+	compare("AdditionalInformation.ID", expectedInfo.ID == actualInfo.ID)
+	compare("AdditionalInformation.CreatedAt", equalTime(expectedInfo.CreatedAt, actualInfo.CreatedAt))
+	compare("AdditionalInformation.UpdatedAt", equalTime(expectedInfo.UpdatedAt, actualInfo.UpdatedAt))
+	compare("AdditionalInformation.DeletedAt", (expectedInfo.DeletedAt == nil) == (actualInfo.DeletedAt == nil) &&
+		(expectedInfo.DeletedAt == nil || equalTime(*expectedInfo.DeletedAt, *actualInfo.DeletedAt)))
+	compare("AdditionalInformation.Additional", expectedInfo.Additional == actualInfo.Additional)
+	compare("AdditionalInformation.Information", expectedInfo.Information == actualInfo.Information)
+
+	return fields
+}
+
+func equalTime(expected, actual time.Time) bool {
+	return expected.Truncate(time.Millisecond).Equal(actual.Truncate(time.Millisecond))
+}
+
+// stream reads the matches of a store batch by batch
+type stream struct {
+	read      reader
+	batchSize int
+	batch     []entity.MatchData
+	lastId    int
+	done      bool
+}
+
+// peek returns the next match without consuming it, nil after the last match
+func (stream *stream) peek(ctx context.Context) (*entity.MatchData, error) {
+
+	if len(stream.batch) == 0 && !stream.done {
+		data, err := stream.read(ctx, stream.lastId, stream.batchSize)
+		if err != nil {
+			return nil, err
+		}
+		stream.batch = data
+		stream.done = len(data) < stream.batchSize
+		if len(data) > 0 {
+			stream.lastId = data[len(data)-1].Id
+		}
+	}
+
+	if len(stream.batch) == 0 {
+		return nil, nil
+	}
+	return &stream.batch[0], nil
+}
+
+func (stream *stream) pop() {
+	stream.batch = stream.batch[1:]
+}
+
+// mysqlReader reads the matches with their AdditionalInformation from MySQL
+func mysqlReader(db *gorm.DB) reader {
+	return func(ctx context.Context, afterId int, limit int) ([]entity.MatchData, error) {
+
+		var data []entity.MatchData
+		err := commondb.WithContext(db, ctx).Preload("AdditionalInformation").
+			Where("id > ?", afterId).Order("id").Limit(limit).Find(&data).Error
+		if err != nil {
+			return nil, err
+		}
+
+		return data, nil
+	}
+}
@@ -0,0 +1,263 @@
+package backfill_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sheazuzu/sheazuzu/src/backfill"
+	"sheazuzu/sheazuzu/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/repository"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var matches = []entity.MatchData{
+	{Id: 1, HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01", Result: "2:0", MatchType: "league"},
+	{Id: 2, HomeTeam: "Mainz", AwayTeam: "Köln", Date: "2021-04-01", Result: "1:1", MatchType: "cup"},
+	{Id: 3, HomeTeam: "Wolfsburg", AwayTeam: "Bochum", Date: "2021-02-01", MatchType: "league"},
+}
+
+// newMySQL returns a migrated SQLite database holding the matches
+func newMySQL(t *testing.T, data ...entity.MatchData) *gorm.DB {
+
+	db, err := database.OpenDB("sqlite3", "file:"+filepath.Join(t.TempDir(), "sheazuzu.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	repo := repository.ProvideMySQLRepository(db, false, zap.NewNop().Sugar())
+	for _, match := range data {
+		_, _, err := repo.UpdateMatchDataInDB(context.Background(), match)
+		require.NoError(t, err)
+	}
+
+	return db
+}
+
+// fakeMongo keeps the matches and checkpoints in memory, the writes fail after failAfter batches
+type fakeMongo struct {
+	matches     map[int]entity.MatchData
+	checkpoints map[string]int
+	batches     int
+	failAfter   int
+}
+
+func newFakeMongo() *fakeMongo {
+	return &fakeMongo{matches: map[int]entity.MatchData{}, checkpoints: map[string]int{}, failAfter: -1}
+}
+
+func (mongo *fakeMongo) PutBatch(_ context.Context, data []entity.MatchData) error {
+
+	if mongo.batches == mongo.failAfter {
+		return errors.New("connection lost")
+	}
+	mongo.batches++
+
+	for _, match := range data {
+		mongo.matches[match.Id] = match
+	}
+	return nil
+}
+
+func (mongo *fakeMongo) FindAfterId(_ context.Context, afterId int, limit int) ([]entity.MatchData, error) {
+
+	var data []entity.MatchData
+	for id, match := range mongo.matches {
+		if id > afterId {
+			data = append(data, match)
+		}
+	}
+	sort.Slice(data, func(i, j int) bool { return data[i].Id < data[j].Id })
+
+	if len(data) > limit {
+		data = data[:limit]
+	}
+	return data, nil
+}
+
+func (mongo *fakeMongo) Checkpoint(_ context.Context, name string) (int, error) {
+	return mongo.checkpoints[name], nil
+}
+
+func (mongo *fakeMongo) SaveCheckpoint(_ context.Context, name string, lastId int) error {
+	mongo.checkpoints[name] = lastId
+	return nil
+}
+
+func ids(mongo *fakeMongo) []int {
+
+	var result []int
+	for id := range mongo.matches {
+		result = append(result, id)
+	}
+	sort.Ints(result)
+	return result
+}
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		checkpoint int
+		options    backfill.Options
+		report     backfill.Report
+		copied     []int
+	}{
+		"complete":          {options: backfill.Options{BatchSize: 2}, report: backfill.Report{Copied: 3, LastId: 3}, copied: []int{1, 2, 3}},
+		"exact batches":     {options: backfill.Options{BatchSize: 3}, report: backfill.Report{Copied: 3, LastId: 3}, copied: []int{1, 2, 3}},
+		"default batch":     {report: backfill.Report{Copied: 3, LastId: 3}, copied: []int{1, 2, 3}},
+		"resumed":           {checkpoint: 1, options: backfill.Options{BatchSize: 2}, report: backfill.Report{ResumedAfter: 1, Copied: 2, LastId: 3}, copied: []int{2, 3}},
+		"nothing to resume": {checkpoint: 3, report: backfill.Report{ResumedAfter: 3, LastId: 3}},
+		"restarted":         {checkpoint: 3, options: backfill.Options{Restart: true}, report: backfill.Report{Copied: 3, LastId: 3}, copied: []int{1, 2, 3}},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			mongo := newFakeMongo()
+			mongo.checkpoints[backfill.CheckpointName] = tc.checkpoint
+
+			report, err := backfill.NewBackfiller(newMySQL(t, matches...), mongo, zap.NewNop().Sugar()).
+				Run(context.Background(), tc.options)
+			require.NoError(t, err)
+			assert.Equal(t, tc.report, report)
+			assert.Equal(t, tc.copied, ids(mongo))
+			assert.Equal(t, 3, mongo.checkpoints[backfill.CheckpointName])
+		})
+	}
+}
+
+func TestRun_resumesAfterFailure(t *testing.T) {
+	t.Parallel()
+
+	mongo := newFakeMongo()
+	mongo.failAfter = 1
+	backfiller := backfill.NewBackfiller(newMySQL(t, matches...), mongo, zap.NewNop().Sugar())
+
+	report, err := backfiller.Run(context.Background(), backfill.Options{BatchSize: 2})
+	assert.ErrorContains(t, err, "error writing the matches 3 to 3 to MongoDB")
+	assert.Equal(t, backfill.Report{Copied: 2, LastId: 2}, report)
+	assert.Equal(t, 2, mongo.checkpoints[backfill.CheckpointName])
+
+	mongo.failAfter = -1
+	report, err = backfiller.Run(context.Background(), backfill.Options{BatchSize: 2})
+	require.NoError(t, err)
+	assert.Equal(t, backfill.Report{ResumedAfter: 2, Copied: 1, LastId: 3}, report)
+	assert.Equal(t, []int{1, 2, 3}, ids(mongo))
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	extra := entity.MatchData{Id: 7, HomeTeam: "Freiburg", AwayTeam: "Mainz", Date: "2021-05-01"}
+	change := func(mongo *fakeMongo) {
+		changed := mongo.matches[2]
+		changed.Result = "0:3"
+		changed.MatchType = "league"
+		mongo.matches[2] = changed
+	}
+
+	cases := map[string]struct {
+		mysql  []entity.MatchData
+		mongo  func(mongo *fakeMongo)
+		report backfill.VerifyReport
+	}{
+		"empty":      {},
+		"consistent": {mysql: matches, report: backfill.VerifyReport{Compared: 3}},
+		"missing": {
+			mysql:  matches,
+			mongo:  func(mongo *fakeMongo) { delete(mongo.matches, 1); delete(mongo.matches, 3) },
+			report: backfill.VerifyReport{Compared: 3, Missing: []int{1, 3}},
+		},
+		"extra": {
+			mysql:  matches[:2],
+			mongo:  func(mongo *fakeMongo) { mongo.matches[3] = matches[2]; mongo.matches[7] = extra },
+			report: backfill.VerifyReport{Compared: 2, Extra: []int{3, 7}},
+		},
+		"differing": {
+			mysql:  matches,
+			mongo:  change,
+			report: backfill.VerifyReport{Compared: 3, Differing: []backfill.Difference{{Id: 2, Fields: []string{"MatchType", "Result"}}}},
+		},
+		"all at once": {
+			mysql: matches[:2],
+			mongo: func(mongo *fakeMongo) { change(mongo); delete(mongo.matches, 1); mongo.matches[7] = extra },
+			report: backfill.VerifyReport{Compared: 2, Missing: []int{1}, Extra: []int{7},
+				Differing: []backfill.Difference{{Id: 2, Fields: []string{"MatchType", "Result"}}}},
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			// MongoDB starts with the matches as a backfill stores them
+			mongo := newFakeMongo()
+			backfiller := backfill.NewBackfiller(newMySQL(t, tc.mysql...), mongo, zap.NewNop().Sugar())
+			_, err := backfiller.Run(context.Background(), backfill.Options{})
+			require.NoError(t, err)
+			if tc.mongo != nil {
+				tc.mongo(mongo)
+			}
+
+			report, err := backfiller.Verify(context.Background(), 2)
+			require.NoError(t, err)
+			assert.Equal(t, tc.report, report)
+			assert.Equal(t, tc.mongo == nil, report.Consistent())
+		})
+	}
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2021, 3, 1, 12, 30, 0, 123456789, time.Local)
+	deleted := created.Add(time.Hour)
+
+	expected := entity.MatchData{Id: 1, HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01"}
+	expected.AdditionalInformation.CreatedAt = created
+	expected.AdditionalInformation.Information = "derby"
+
+	cases := map[string]struct {
+		change func(data *entity.MatchData)
+		fields []string
+	}{
+		"equal": {change: func(data *entity.MatchData) {}},
+		"time with milliseconds": {change: func(data *entity.MatchData) {
+			data.AdditionalInformation.CreatedAt = created.UTC().Truncate(time.Millisecond)
+		}},
+		"time": {change: func(data *entity.MatchData) { data.AdditionalInformation.CreatedAt = created.Add(time.Second) },
+			fields: []string{"AdditionalInformation.CreatedAt"}},
+		"deleted": {change: func(data *entity.MatchData) { data.AdditionalInformation.DeletedAt = &deleted },
+			fields: []string{"AdditionalInformation.DeletedAt"}},
+		"teams": {change: func(data *entity.MatchData) { data.HomeTeam, data.AwayTeam = data.AwayTeam, data.HomeTeam },
+			fields: []string{"AwayTeam", "HomeTeam"}},
+		"information": {change: func(data *entity.MatchData) { data.AdditionalInformation.Information = "" },
+			fields: []string{"AdditionalInformation.Information"}},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			actual := expected
+			tc.change(&actual)
+			assert.Equal(t, tc.fields, backfill.Diff(expected, actual))
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sheazuzu/common/src/cli"
+	"sheazuzu/common/src/logging"
+	"sheazuzu/sheazuzu/src/backup"
+	"sheazuzu/sheazuzu/src/configuration"
+)
+
+func backupCommand(config *configuration.Configuration) cli.Command {
+
+	fs := config.SetupFlags("sheazuzu")
+	filter := backup.Filter{}
+	fs.StringVar(&filter.Since, "backup.since", "", "back up the matches from this date on, e.g. '2021-03-01'")
+	fs.StringVar(&filter.Until, "backup.until", "", "back up the matches up to this date, e.g. '2021-03-01'")
+
+	return cli.Command{
+		Name:     "backup",
+		Usage:    "Writes all matches of the storage backend to the compressed archive given as argument",
+		Flags:    fs,
+		Validate: config.Validate,
+		Run:      BackupMatches(config, &filter),
+	}
+}
+
+func restoreCommand(config *configuration.Configuration) cli.Command {
+
+	fs := config.SetupFlags("sheazuzu")
+	options := backup.RestoreOptions{}
+	fs.StringVar(&options.Filter.Since, "restore.since", "", "restore the matches of the archive from this date on, e.g. '2021-03-01'")
+	fs.StringVar(&options.Filter.Until, "restore.until", "", "restore the matches of the archive up to this date, e.g. '2021-03-01'")
+	fs.StringVar(&options.Conflict, "restore.conflict", backup.ConflictFail, "the handling of existing matches, either 'fail', 'skip' or 'overwrite'")
+
+	validate := func() bool {
+		if options.Conflict != backup.ConflictFail && options.Conflict != backup.ConflictSkip && options.Conflict != backup.ConflictOverwrite {
+			fmt.Println("restore.conflict must either be 'fail', 'skip' or 'overwrite'")
+			return false
+		}
+		return config.Validate()
+	}
+
+	return cli.Command{
+		Name:     "restore",
+		Usage:    "Restores the matches of the archive given as argument into the storage backend",
+		Flags:    fs,
+		Validate: validate,
+		Run:      RestoreMatches(config, &options),
+	}
+}
+
+func BackupMatches(cfg *configuration.Configuration, filter *backup.Filter) func(cmd *cli.Command, args ...string) {
+	return func(cmd *cli.Command, args ...string) {
+
+		logger := logging.GetLogger(cfg.Logging.Level, cfg.Logging.Format).
+			With("version", cmd.Version)
+
+		file := cmd.Flags.Arg(0)
+		if file == "" {
+			logger.Error("please specify the file of the archive as argument")
+			os.Exit(1)
+		}
+
+		sheazuzuRepo, closeRepository, err := provideRepository(cfg, logger)
+		if err != nil {
+			logger.Error("error setting up the storage backend", "backend", cfg.Storage.Backend, "error", err)
+			os.Exit(1)
+		}
+		defer closeRepository()
+
+		// the archive is written to a temporary file first, so a failed backup does not leave a broken archive behind
+		tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*.tmp")
+		if err != nil {
+			logger.Error("error creating the archive", "file", file, "error", err)
+			closeRepository()
+			os.Exit(1)
+		}
+		defer os.Remove(tmp.Name())
+
+		count, err := backup.Backup(context.Background(), sheazuzuRepo, *filter, tmp)
+		if closeErr := tmp.Close(); err == nil {
+			err = closeErr
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), file)
+		}
+		if err != nil {
+			logger.Error("error backing up the matches", "file", file, "error", err)
+			closeRepository()
+			os.Remove(tmp.Name())
+			os.Exit(1)
+		}
+
+		fmt.Printf("backed up %d matches to %s\n", count, file)
+	}
+}
+
+func RestoreMatches(cfg *configuration.Configuration, options *backup.RestoreOptions) func(cmd *cli.Command, args ...string) {
+	return func(cmd *cli.Command, args ...string) {
+
+		logger := logging.GetLogger(cfg.Logging.Level, cfg.Logging.Format).
+			With("version", cmd.Version)
+
+		file := cmd.Flags.Arg(0)
+		if file == "" {
+			logger.Error("please specify the file of the archive as argument")
+			os.Exit(1)
+		}
+
+		f, err := os.Open(file)
+		if err != nil {
+			logger.Error("error opening the archive", "file", file, "error", err)
+			os.Exit(1)
+		}
+		archive, matches, err := backup.Read(f)
+		f.Close()
+		if err != nil {
+			logger.Error("error reading the archive", "file", file, "error", err)
+			os.Exit(1)
+		}
+		fmt.Printf("archive of %s with %d matches, schema version %d\n", archive.CreatedAt.Format("2006-01-02 15:04:05"), archive.Count, archive.SchemaVersion)
+
+		sheazuzuRepo, closeRepository, err := provideRepository(cfg, logger)
+		if err != nil {
+			logger.Error("error setting up the storage backend", "backend", cfg.Storage.Backend, "error", err)
+			os.Exit(1)
+		}
+		defer closeRepository()
+
+		report, err := backup.Restore(context.Background(), sheazuzuRepo, matches, *options)
+
+		fmt.Printf("created %d, overwritten %d, skipped %d matches\n", report.Created, report.Overwritten, report.Skipped)
+		if err != nil {
+			logger.Error("error restoring the matches", "error", err, "conflicts", report.Conflicts)
+			closeRepository()
+			os.Exit(1)
+		}
+	}
+}
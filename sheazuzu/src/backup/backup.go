@@ -0,0 +1,254 @@
+// Package backup writes the matches of a repository to a compressed archive and restores them from it.
+package backup
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/repository"
+	"sort"
+	"time"
+)
+
+// Format identifies the archives of the backups
+const Format = "sheazuzu-backup"
+
+// SchemaVersion is the version of the layout of the matches in the archive. It has to be increased with every change
+// of entity.MatchData, which older versions cannot read.
+const SchemaVersion = 1
+
+// the number of matches read from and written to the repository at once
+const batchSize = 500
+
+// the handling of matches of the archive, which already exist in the repository
+const (
+	ConflictFail      = "fail"      // nothing is restored, if a match exists
+	ConflictSkip      = "skip"      // the existing matches are kept
+	ConflictOverwrite = "overwrite" // the existing matches are replaced
+)
+
+// Archive is the content of a backup. Checksum is the SHA-256 of the matches as they are stored in the archive.
+type Archive struct {
+	Format        string          `json:"format"`
+	SchemaVersion int             `json:"schemaVersion"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	Since         string          `json:"since,omitempty"`
+	Until         string          `json:"until,omitempty"`
+	Count         int             `json:"count"`
+	Checksum      string          `json:"checksum"`
+	Matches       json.RawMessage `json:"matches"`
+}
+
+// Filter selects the matches by their date, the bounds are included and an empty bound is open.
+// The dates are compared like the matches are ordered, e.g. "2021-03-01".
+type Filter struct {
+	Since string
+	Until string
+}
+
+func (filter Filter) matches(data entity.MatchData) bool {
+	return (filter.Since == "" || data.Date >= filter.Since) && (filter.Until == "" || data.Date <= filter.Until)
+}
+
+// Backup writes the matches of the repository selected by the filter with their AdditionalInformation as gzip
+// compressed JSON archive to w and returns the number of matches. The matches are read in pages, but the archive is
+// built in memory.
+// If the repository implements repository.SnapshotReader, like the SQL databases and MongoDB, all pages are read from
+// one snapshot, so the backup is consistent, even if matches are written meanwhile. The memory backend has no
+// snapshots, a match written during its backup may be missing or contained twice.
+func Backup(ctx context.Context, repo repository.Repository, filter Filter, w io.Writer) (int, error) {
+
+	var matches []entity.MatchData
+	read := func(ctx context.Context) error {
+		matches = nil
+
+		page := entity.Page{Limit: batchSize}
+		for {
+			data, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, page)
+			if err != nil {
+				return fmt.Errorf("error reading the matches: %w", err)
+			}
+
+			for _, match := range data {
+				if filter.matches(match) {
+					matches = append(matches, match)
+				}
+			}
+
+			if len(data) < batchSize {
+				return nil
+			}
+			last := data[len(data)-1]
+			page.After = &entity.PageKey{Date: last.Date, Id: last.Id}
+		}
+	}
+
+	var err error
+	if snapshots, ok := repo.(repository.SnapshotReader); ok {
+		err = snapshots.WithinSnapshot(ctx, read)
+	} else {
+		err = read(ctx)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	encoded, err := json.Marshal(matches)
+	if err != nil {
+		return 0, fmt.Errorf("error encoding the matches: %w", err)
+	}
+
+	archive := Archive{
+		Format:        Format,
+		SchemaVersion: SchemaVersion,
+		CreatedAt:     time.Now().UTC(),
+		Since:         filter.Since,
+		Until:         filter.Until,
+		Count:         len(matches),
+		Checksum:      checksum(encoded),
+		Matches:       encoded,
+	}
+
+	zw := gzip.NewWriter(w)
+	err = json.NewEncoder(zw).Encode(archive)
+	if err != nil {
+		return 0, fmt.Errorf("error writing the archive: %w", err)
+	}
+
+	err = zw.Close()
+	if err != nil {
+		return 0, fmt.Errorf("error writing the archive: %w", err)
+	}
+
+	return len(matches), nil
+}
+
+// Read reads the archive and verifies its format, schema version and checksum, it returns the matches of the archive
+func Read(r io.Reader) (Archive, []entity.MatchData, error) {
+
+	zr, err := gzip.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return Archive{}, nil, fmt.Errorf("the archive is not gzip compressed: %w", err)
+	}
+	defer zr.Close()
+
+	var archive Archive
+	err = json.NewDecoder(zr).Decode(&archive)
+	if err != nil {
+		return Archive{}, nil, fmt.Errorf("error reading the archive: %w", err)
+	}
+
+	if archive.Format != Format {
+		return Archive{}, nil, fmt.Errorf("the file is no %s archive", Format)
+	}
+
+	if archive.SchemaVersion < 1 || archive.SchemaVersion > SchemaVersion {
+		return Archive{}, nil, fmt.Errorf("the schema version %d of the archive is not supported, the latest one is %d", archive.SchemaVersion, SchemaVersion)
+	}
+
+	if checksum(archive.Matches) != archive.Checksum {
+		return Archive{}, nil, errors.New("the checksum of the archive does not match, it is corrupted")
+	}
+
+	var matches []entity.MatchData
+	err = json.Unmarshal(archive.Matches, &matches)
+	if err != nil {
+		return Archive{}, nil, fmt.Errorf("error reading the matches of the archive: %w", err)
+	}
+
+	if len(matches) != archive.Count {
+		return Archive{}, nil, fmt.Errorf("the archive contains %d matches instead of %d", len(matches), archive.Count)
+	}
+
+	return archive, matches, nil
+}
+
+// RestoreOptions select the matches of an archive to restore and the handling of existing matches, see ConflictFail.
+type RestoreOptions struct {
+	Filter   Filter
+	Conflict string
+}
+
+// RestoreReport counts the restored matches
+type RestoreReport struct {
+	Created     int
+	Overwritten int
+	Skipped     int
+	// Conflicts are the ids of the existing matches
+	Conflicts []int
+}
+
+// Restore writes the matches selected by the filter to the repository. The existing matches are looked up first, so
+// with ConflictFail nothing is written, if one of them exists. The matches are written in batches, each in its own
+// unit of work, the report counts the matches of the batches written before a failing one.
+func Restore(ctx context.Context, repo repository.Repository, matches []entity.MatchData, options RestoreOptions) (RestoreReport, error) {
+
+	var selected []entity.MatchData
+	for _, match := range matches {
+		if options.Filter.matches(match) {
+			selected = append(selected, match)
+		}
+	}
+
+	report := RestoreReport{}
+	var restore []entity.MatchData
+	for _, match := range selected {
+		_, err := repo.FindMatchDataByIdInDB(ctx, match.Id, entity.Projection{Fields: []string{"Id"}})
+		if err != nil && !errors.Is(err, entity.ErrNotFound) {
+			return RestoreReport{}, fmt.Errorf("error looking up the match %d: %w", match.Id, err)
+		}
+		if err == nil {
+			report.Conflicts = append(report.Conflicts, match.Id)
+			if options.Conflict == ConflictSkip {
+				report.Skipped++
+				continue
+			}
+		}
+		restore = append(restore, match)
+	}
+	sort.Ints(report.Conflicts)
+
+	if len(report.Conflicts) > 0 && options.Conflict != ConflictSkip && options.Conflict != ConflictOverwrite {
+		return report, fmt.Errorf("%d matches of the archive exist already, e.g. %d", len(report.Conflicts), report.Conflicts[0])
+	}
+
+	for start := 0; start < len(restore); start += batchSize {
+		end := start + batchSize
+		if end > len(restore) {
+			end = len(restore)
+		}
+
+		results, err := repo.UpsertMatchDataBatchInDB(ctx, restore[start:end], true)
+		if err != nil {
+			return report, fmt.Errorf("error restoring the matches %d to %d: %w", start+1, end, err)
+		}
+
+		for _, result := range results {
+			if result.Err != nil {
+				return report, fmt.Errorf("error restoring the match %d, the matches %d to %d are rolled back: %w", result.Id, start+1, end, result.Err)
+			}
+		}
+
+		for _, result := range results {
+			if result.Created {
+				report.Created++
+			} else {
+				report.Overwritten++
+			}
+		}
+	}
+
+	return report, nil
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,257 @@
+package backup_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sheazuzu/sheazuzu/src/backup"
+	"sheazuzu/sheazuzu/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/repository"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var matches = []entity.MatchData{
+	{Id: 1, HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01", Result: "2:0", MatchType: "league",
+		AdditionalInformation: entity.AdditionalInformation{Additional: "1", Information: "derby"}},
+	{Id: 2, HomeTeam: "Mainz", AwayTeam: "Köln", Date: "2021-04-01", Result: "1:1", MatchType: "cup"},
+	{Id: 3, HomeTeam: "Wolfsburg", AwayTeam: "Bochum", Date: "2021-05-01", MatchType: "league"},
+}
+
+func newRepository(t *testing.T, data ...entity.MatchData) repository.Repository {
+
+	repo := repository.ProvideMemoryRepository(zap.NewNop().Sugar())
+	for _, match := range data {
+		_, _, err := repo.UpdateMatchDataInDB(context.Background(), match)
+		require.NoError(t, err)
+	}
+
+	return repo
+}
+
+func all(t *testing.T, repo repository.Repository) []entity.MatchData {
+
+	data, err := repo.FindAllMatchDataInDB(context.Background(), entity.Projection{}, entity.Page{})
+	require.NoError(t, err)
+	return data
+}
+
+func TestBackupAndRead(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		filter   backup.Filter
+		expected []entity.MatchData
+	}{
+		"all":         {expected: matches},
+		"since":       {filter: backup.Filter{Since: "2021-04-01"}, expected: matches[1:]},
+		"until":       {filter: backup.Filter{Until: "2021-04-01"}, expected: matches[:2]},
+		"since until": {filter: backup.Filter{Since: "2021-03-15", Until: "2021-04-15"}, expected: matches[1:2]},
+		"none":        {filter: backup.Filter{Since: "2022-01-01"}},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var archive bytes.Buffer
+			count, err := backup.Backup(context.Background(), newRepository(t, matches...), tc.filter, &archive)
+			require.NoError(t, err)
+			assert.Equal(t, len(tc.expected), count)
+
+			read, data, err := backup.Read(&archive)
+			require.NoError(t, err)
+			assert.Equal(t, backup.SchemaVersion, read.SchemaVersion)
+			assert.Equal(t, tc.filter.Since, read.Since)
+			assert.Equal(t, tc.filter.Until, read.Until)
+			assert.Equal(t, tc.expected, data)
+		})
+	}
+}
+
+// writeArchive writes the archive as a backup would, the checksum is taken as it is
+func writeArchive(t *testing.T, archive backup.Archive) *bytes.Buffer {
+
+	var buffer bytes.Buffer
+	zw := gzip.NewWriter(&buffer)
+	require.NoError(t, json.NewEncoder(zw).Encode(archive))
+	require.NoError(t, zw.Close())
+
+	return &buffer
+}
+
+func TestRead_invalid(t *testing.T) {
+	t.Parallel()
+
+	var valid bytes.Buffer
+	_, err := backup.Backup(context.Background(), newRepository(t, matches...), backup.Filter{}, &valid)
+	require.NoError(t, err)
+	archive, _, err := backup.Read(bytes.NewReader(valid.Bytes()))
+	require.NoError(t, err)
+
+	corrupted := archive
+	corrupted.Matches = bytes.Replace(archive.Matches, []byte("Bremen"), []byte("Berlin"), 1)
+
+	newer := archive
+	newer.SchemaVersion = backup.SchemaVersion + 1
+
+	other := archive
+	other.Format = "other"
+
+	incomplete := archive
+	incomplete.Count = len(matches) + 1
+
+	cases := map[string]struct {
+		archive *bytes.Buffer
+		err     string
+	}{
+		"not compressed":     {archive: bytes.NewBufferString(`{"format":"sheazuzu-backup"}`), err: "not gzip compressed"},
+		"corrupted":          {archive: writeArchive(t, corrupted), err: "checksum"},
+		"newer schema":       {archive: writeArchive(t, newer), err: fmt.Sprintf("schema version %d", backup.SchemaVersion+1)},
+		"other format":       {archive: writeArchive(t, other), err: "no sheazuzu-backup archive"},
+		"count not matching": {archive: writeArchive(t, incomplete), err: "contains 3 matches instead of 4"},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, _, err := backup.Read(tc.archive)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), tc.err)
+			}
+		})
+	}
+}
+
+func TestRestore(t *testing.T) {
+	t.Parallel()
+
+	changed := matches[0]
+	changed.Result = "0:3"
+
+	cases := map[string]struct {
+		existing []entity.MatchData
+		options  backup.RestoreOptions
+		report   backup.RestoreReport
+		expected []entity.MatchData
+		err      bool
+	}{
+		"empty repository": {
+			report:   backup.RestoreReport{Created: 3},
+			expected: matches,
+		},
+		"filtered": {
+			options:  backup.RestoreOptions{Filter: backup.Filter{Until: "2021-04-01"}},
+			report:   backup.RestoreReport{Created: 2},
+			expected: matches[:2],
+		},
+		"conflict fails": {
+			existing: []entity.MatchData{changed},
+			options:  backup.RestoreOptions{Conflict: backup.ConflictFail},
+			report:   backup.RestoreReport{Conflicts: []int{1}},
+			expected: []entity.MatchData{changed},
+			err:      true,
+		},
+		"conflict skipped": {
+			existing: []entity.MatchData{changed},
+			options:  backup.RestoreOptions{Conflict: backup.ConflictSkip},
+			report:   backup.RestoreReport{Created: 2, Skipped: 1, Conflicts: []int{1}},
+			expected: []entity.MatchData{changed, matches[1], matches[2]},
+		},
+		"conflict overwritten": {
+			existing: []entity.MatchData{changed},
+			options:  backup.RestoreOptions{Conflict: backup.ConflictOverwrite},
+			report:   backup.RestoreReport{Created: 2, Overwritten: 1, Conflicts: []int{1}},
+			expected: matches,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			repo := newRepository(t, tc.existing...)
+
+			report, err := backup.Restore(context.Background(), repo, matches, tc.options)
+			if tc.err {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tc.report, report)
+			assert.Equal(t, tc.expected, all(t, repo))
+		})
+	}
+}
+
+// concurrentRepository writes a match behind the first page, as soon as the first page was read
+type concurrentRepository struct {
+	*repository.MySQLRepository
+	written chan error
+}
+
+func (repo *concurrentRepository) FindAllMatchDataInDB(ctx context.Context, projection entity.Projection, page entity.Page) ([]entity.MatchData, error) {
+
+	data, err := repo.MySQLRepository.FindAllMatchDataInDB(ctx, projection, page)
+	if page.After == nil {
+		go func() {
+			_, _, err := repo.MySQLRepository.UpdateMatchDataInDB(context.Background(), entity.MatchData{HomeTeam: "Mainz", AwayTeam: "Köln", Date: "2099-01-01"})
+			repo.written <- err
+		}()
+		// give the write the chance to run between the pages
+		time.Sleep(100 * time.Millisecond)
+	}
+	return data, err
+}
+
+func TestBackup_snapshot(t *testing.T) {
+	t.Parallel()
+
+	db, err := database.OpenDB("sqlite3", "file:"+filepath.Join(t.TempDir(), "sheazuzu.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	mysql := repository.ProvideMySQLRepository(db, false, zap.NewNop().Sugar())
+	// more matches than fit on one page of the backup
+	require.NoError(t, mysql.WithinTransaction(context.Background(), func(ctx context.Context) error {
+		for i := 0; i < 501; i++ {
+			if _, _, err := mysql.UpdateMatchDataInDB(ctx, entity.MatchData{HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01"}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	repo := &concurrentRepository{MySQLRepository: mysql, written: make(chan error, 1)}
+
+	var archive bytes.Buffer
+	count, err := backup.Backup(context.Background(), repo, backup.Filter{}, &archive)
+	require.NoError(t, err)
+	// the match written during the backup is not part of its snapshot
+	assert.Equal(t, 501, count)
+	require.NoError(t, <-repo.written)
+
+	_, data, err := backup.Read(&archive)
+	require.NoError(t, err)
+	assert.Len(t, data, 501)
+	stored, err := mysql.FindAllMatchDataInDB(context.Background(), entity.Projection{}, entity.Page{Limit: 1000})
+	require.NoError(t, err)
+	assert.Len(t, stored, 502)
+}
@@ -2,17 +2,34 @@ package configuration
 
 import (
 	"flag"
+	"fmt"
+	"sheazuzu/common/src/cache"
 	"sheazuzu/common/src/database"
 	"sheazuzu/common/src/logging"
+	"sheazuzu/common/src/metrics"
 	"sheazuzu/common/src/mongo"
+	"sheazuzu/common/src/pagination"
 	"sheazuzu/common/src/server"
+	"sheazuzu/common/src/swagger"
+	"sheazuzu/common/src/versioning"
+	"sheazuzu/sheazuzu/src/outbox"
+	"sheazuzu/sheazuzu/src/prediction"
+	"sheazuzu/sheazuzu/src/repository"
 )
 
 type Configuration struct {
-	Server   server.Config
-	Logging  logging.Config
-	Database database.Config
-	Mongo    mongo.Config
+	Server     server.Config
+	Logging    logging.Config
+	Database   database.Config
+	Mongo      mongo.Config
+	Prediction prediction.Config
+	Swagger    swagger.Config
+	Pagination pagination.Config
+	APIV1      versioning.Config
+	Storage    repository.Config
+	Outbox     outbox.Config
+	Metrics    metrics.Config
+	Cache      cache.Config
 }
 
 func New() *Configuration {
@@ -26,6 +43,14 @@ func (cfg *Configuration) SetupFlags(serviceName string) *flag.FlagSet {
 	logging.BindConfig(&cfg.Logging, fs)
 	database.BindConfig(&cfg.Database, fs)
 	mongo.BindConfig(&cfg.Mongo, fs)
+	prediction.BindConfig(&cfg.Prediction, fs)
+	swagger.BindConfig(&cfg.Swagger, fs)
+	pagination.BindConfig(&cfg.Pagination, fs)
+	versioning.BindConfig(&cfg.APIV1, fs, "v1", versioning.Config{Deprecation: "2026-10-19", Sunset: "2027-04-19"})
+	repository.BindConfig(&cfg.Storage, fs)
+	outbox.BindConfig(&cfg.Outbox, fs)
+	metrics.BindConfig(&cfg.Metrics, fs, serviceName)
+	cache.BindConfig(&cfg.Cache, fs, serviceName)
 
 	return fs
 }
@@ -34,7 +59,23 @@ func (cfg *Configuration) Validate() bool {
 
 	hasErrors := false
 	hasErrors = !cfg.Logging.IsValid() || hasErrors
-	//	hasErrors = !cfg.Mongo.IsValid() || hasErrors
+	hasErrors = !cfg.Prediction.IsValid() || hasErrors
+	hasErrors = !cfg.Swagger.IsValid() || hasErrors
+	hasErrors = !cfg.Pagination.IsValid() || hasErrors
+	hasErrors = !cfg.APIV1.IsValid() || hasErrors
+	hasErrors = !cfg.Storage.IsValid() || hasErrors
+	hasErrors = !cfg.Outbox.IsValid() || hasErrors
+	hasErrors = !cfg.Cache.IsValid() || hasErrors
+	if cfg.Storage.Backend == repository.BackendMySQL {
+		hasErrors = !cfg.Database.IsValid() || hasErrors
+	}
+	if cfg.Storage.Backend == repository.BackendMongo || cfg.Outbox.Enabled {
+		hasErrors = !cfg.Mongo.IsValid() || hasErrors
+	}
+	if cfg.Outbox.Enabled && cfg.Storage.Backend != repository.BackendMySQL {
+		fmt.Println("the outbox mirrors MySQL to MongoDB, it needs the storage backend 'mysql'")
+		hasErrors = true
+	}
 
 	return !hasErrors
 }
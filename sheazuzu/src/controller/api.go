@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.uber.org/zap"
+	"net/http"
+	verrors "sheazuzu/common/src/errors"
+	"sheazuzu/common/src/fieldset"
+	"sheazuzu/common/src/logging"
+	"sheazuzu/common/src/pagination"
+	"sheazuzu/common/src/render"
+	"sheazuzu/common/src/tracing"
+	"sheazuzu/common/src/utils"
+	"sheazuzu/sheazuzu/src/generated/sheazuzu"
+)
+
+type sheazuzuService interface {
+	FindMatchDataById(ctx context.Context, id int, fields []string) (sheazuzu.MatchData, error)
+	FindAllMatchData(ctx context.Context, fields []string, after *pagination.Cursor, limit int) ([]sheazuzu.MatchData, *pagination.Cursor, error)
+	UpdateMatchData(ctx context.Context, data sheazuzu.MatchData) (string, int, error)
+	FindStatistics(ctx context.Context, fields []string) (sheazuzu.Statistics, error)
+	PredictMatch(ctx context.Context, id int, fields []string) (sheazuzu.Prediction, error)
+	UpsertMatchDataBatch(ctx context.Context, data []sheazuzu.MatchData, mode string) (sheazuzu.BatchResponse, error)
+}
+
+// api holds what the controllers of all API versions share, every version has its own spec
+type api struct {
+	service    sheazuzuService
+	swaggerDoc *openapi3.Swagger
+	paginator  *pagination.Paginator
+	logger     *zap.SugaredLogger
+}
+
+const invalidFieldsMessage = "Invalid fields, only the properties of the returned schema in the API specification can be selected"
+
+const invalidPageMessage = "Invalid cursor or limit"
+
+// parseFields validates the requested fields against the schema of the returned items
+func (api *api) parseFields(schemaName string, params *[]string) ([]string, error) {
+	op := verrors.Op("controller: Parse Fields")
+
+	if params == nil {
+		return nil, nil
+	}
+
+	schema, ok := api.swaggerDoc.Components.Schemas[schemaName]
+	if !ok {
+		return nil, verrors.E(op, verrors.Info{Name: "schema", Val: schemaName}, "schema not found in the API specification")
+	}
+
+	fields := fieldset.Parse(params)
+
+	err := fieldset.Validate(schema, fields)
+	if err != nil {
+		return nil, verrors.E(op, err)
+	}
+
+	return fields, nil
+}
+
+// readPage validates the cursor and limit of a list request, a nil cursor requests the first page
+func (api *api) readPage(cursor *string, limit *int) (*pagination.Cursor, int, error) {
+
+	pageLimit, err := api.paginator.Limit(limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if cursor == nil || *cursor == "" {
+		return nil, pageLimit, nil
+	}
+
+	after, err := api.paginator.Decode(*cursor)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return &after, pageLimit, nil
+}
+
+// nextPage returns the token of the next page for the response body and links the next page in the Link header,
+// it returns nil on the last page
+func (api *api) nextPage(w http.ResponseWriter, r *http.Request, next *pagination.Cursor, limit int) *string {
+
+	if next == nil {
+		return nil
+	}
+
+	token := api.paginator.Encode(*next)
+	pagination.SetLinkHeader(w, r, token, limit)
+
+	return &token
+}
+
+// negotiate selects the content type of the response from the Accept header before the request is processed, so
+// nothing is read or written for a client, which does not accept any of the content types. csv offers CSV for lists.
+// If no content type is acceptable, the error response is written and ok is false.
+func (api *api) negotiate(w http.ResponseWriter, r *http.Request, op verrors.Op, csv bool) (string, bool) {
+
+	contentType, err := render.Negotiate(r, csv)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, renderErrorDetails(err), api.logger)
+		return "", false
+	}
+
+	return contentType, true
+}
+
+// writeResponse renders the response in the content type returned by negotiate
+func (api *api) writeResponse(w http.ResponseWriter, r *http.Request, op verrors.Op, contentType string, status int, response interface{}) {
+
+	err := render.Write(w, contentType, status, response)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, renderErrorDetails(err), api.logger)
+	}
+}
+
+// writeListResponse works like writeResponse, but renders only the items with one row per item as CSV
+func (api *api) writeListResponse(w http.ResponseWriter, r *http.Request, op verrors.Op, contentType string, response interface{}, items interface{}) {
+
+	if contentType == render.ContentTypeCSV {
+		response = items
+	}
+
+	api.writeResponse(w, r, op, contentType, http.StatusOK, response)
+}
+
+func renderErrorDetails(err error) string {
+	if verrors.HttpErrorCodeFromError(err) == http.StatusNotAcceptable {
+		return "none of the content types in the Accept header is supported"
+	}
+	return "error while rendering the response"
+}
+
+// the error responses of all API versions have the same schema, so they are rendered from the v1 type
+func writeErrorResponse(writer http.ResponseWriter, request *http.Request, op verrors.Op, err error, details string, logger *zap.SugaredLogger) {
+	err = verrors.E(op, err)
+
+	statusCode := verrors.HttpErrorCodeFromError(err)
+	statusText := http.StatusText(statusCode)
+
+	errorCode := verrors.GetErrorCode(err)
+
+	_ = render.RenderError(writer, request, statusCode, sheazuzu.ErrorResponse{
+		Code:        &errorCode,
+		Description: &statusText,
+		Details:     &[]string{details},
+	})
+
+	logger.Errorw(err.Error(),
+		"details", details,
+		"statusCode", statusCode,
+		"errorCode", errorCode)
+}
+
+func handleError(writer http.ResponseWriter, request *http.Request, err error, message string) {
+	ctx := request.Context()
+
+	statusCode := verrors.HttpErrorCodeFromError(err)
+	errorCode := verrors.GetErrorCode(err)
+
+	if statusCode == http.StatusNoContent {
+		writer.WriteHeader(statusCode)
+		return // NoContent 204 doesn't have a response body
+	}
+
+	logging.ContextLogger(ctx).Errorw(err.Error(), "statusCode", statusCode, "errorCode", errorCode)
+
+	_ = render.RenderError(writer, request, statusCode,
+		sheazuzu.ErrorResponse{
+			Code:    utils.ToInt32Ptr(errorCode),
+			Name:    utils.ToStringPtr(http.StatusText(statusCode)),
+			Message: utils.ToStringPtrOrNil(message),
+			Details: &[]string{
+				fmt.Sprintf("TraceID: %s", tracing.TraceId(ctx)),
+			},
+		},
+	)
+}
@@ -1,119 +1,189 @@
 package controller
 
 import (
-	"context"
 	"encoding/json"
-	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
 	"go.uber.org/zap"
 	"net/http"
 	verrors "sheazuzu/common/src/errors"
-	"sheazuzu/common/src/logging"
-	"sheazuzu/common/src/tracing"
+	"sheazuzu/common/src/pagination"
 	"sheazuzu/common/src/utils"
 	"sheazuzu/sheazuzu/src/generated/sheazuzu"
 )
 
-type sheazuzuService interface {
-	FindMatchDataById(int) (sheazuzu.MatchData, error)
-	UpdateMatchData(data sheazuzu.MatchData) (string, int, error)
-}
-
+// Controller implements the v1 API
 type Controller struct {
-	service sheazuzuService
-	logger  *zap.SugaredLogger
+	api
 }
 
-func ProvideSheazuzuAPI(service sheazuzuService, logger *zap.SugaredLogger) *Controller {
+func ProvideSheazuzuAPI(service sheazuzuService, swaggerDoc *openapi3.Swagger, paginator *pagination.Paginator, logger *zap.SugaredLogger) *Controller {
 	return &Controller{
-		service: service,
-		logger:  logger,
+		api: api{
+			service:    service,
+			swaggerDoc: swaggerDoc,
+			paginator:  paginator,
+			logger:     logger,
+		},
 	}
 }
 
 func (controller *Controller) GetMatchDataByIdUsingGET(w http.ResponseWriter, r *http.Request, params sheazuzu.GetMatchDataByIdUsingGETParams) {
 	op := verrors.Op("controller: GetFindMachine")
 
-	resultList, err := controller.service.FindMatchDataById(params.Id)
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
+
+	fields, err := controller.parseFields("MatchData", (*[]string)(params.Fields))
+	if err != nil {
+		handleError(w, r, err, invalidFieldsMessage)
+		return
+	}
+
+	resultList, err := controller.service.FindMatchDataById(r.Context(), params.Id, fields)
 	if err != nil {
-		writeErrorResponse(w, op, err, "error while getting machine by id", controller.logger)
+		writeErrorResponse(w, r, op, err, "error while getting machine by id", controller.logger)
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(sheazuzu.MatchDataResponse{
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, sheazuzu.MatchDataResponse{
 		MatchData: &resultList,
 	})
 }
 
-func (controller *Controller) AllMatchDataUsingGET(w http.ResponseWriter, r *http.Request) {
-	panic("hello world")
+func (controller *Controller) AllMatchDataUsingGET(w http.ResponseWriter, r *http.Request, params sheazuzu.AllMatchDataUsingGETParams) {
+	op := verrors.Op("controller: GetAllMatchData")
+
+	contentType, ok := controller.negotiate(w, r, op, true)
+	if !ok {
+		return
+	}
+
+	fields, err := controller.parseFields("MatchData", (*[]string)(params.Fields))
+	if err != nil {
+		handleError(w, r, err, invalidFieldsMessage)
+		return
+	}
+
+	after, limit, err := controller.readPage(params.Cursor, params.Limit)
+	if err != nil {
+		handleError(w, r, err, invalidPageMessage)
+		return
+	}
+
+	resultList, next, err := controller.service.FindAllMatchData(r.Context(), fields, after, limit)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error while getting all match data", controller.logger)
+		return
+	}
+
+	// as CSV only the list itself is rendered, one row per match, the next page is only linked in the Link header
+	controller.writeListResponse(w, r, op, contentType, sheazuzu.MatchDataSetResponse{
+		MatchDataSet: &resultList,
+		NextCursor:   controller.nextPage(w, r, next, limit),
+	}, resultList)
 }
 
 func (controller *Controller) UploadMatchDataUsingPOST(w http.ResponseWriter, r *http.Request) {
 	op := verrors.Op("controller: GetFindMachine")
 
-	ctx := r.Context()
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
 
 	var requestBody sheazuzu.MatchData
 	err := json.NewDecoder(r.Body).Decode(&requestBody)
 	if err != nil {
 		msg := "Invalid request body"
-		handleError(ctx, w, verrors.E(op, verrors.HttpBadRequest, err, msg), msg)
+		handleError(w, r, verrors.E(op, verrors.HttpBadRequest, err, msg), msg)
 		return
 	}
 
-	msg, id, err := controller.service.UpdateMatchData(requestBody)
+	msg, id, err := controller.service.UpdateMatchData(r.Context(), requestBody)
 	if err != nil {
-		writeErrorResponse(w, op, err, "error updating MatchData", controller.logger)
+		writeErrorResponse(w, r, op, err, "error updating MatchData", controller.logger)
 		return
 	}
 
-	_ = json.NewEncoder(w).Encode(sheazuzu.UpdateResponse{
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, sheazuzu.UpdateResponse{
 		MatchID: utils.ToIntPtr(id),
 		Message: utils.ToStringPtr(msg),
 	})
 }
 
-func writeErrorResponse(writer http.ResponseWriter, op verrors.Op, err error, details string, logger *zap.SugaredLogger) {
-	err = verrors.E(op, err)
+func (controller *Controller) GetStatisticsUsingGET(w http.ResponseWriter, r *http.Request, params sheazuzu.GetStatisticsUsingGETParams) {
+	op := verrors.Op("controller: GetStatistics")
 
-	statusCode := verrors.HttpErrorCodeFromError(err)
-	statusText := http.StatusText(statusCode)
-	writer.WriteHeader(statusCode)
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
 
-	errorCode := verrors.GetErrorCode(err)
+	fields, err := controller.parseFields("Statistics", (*[]string)(params.Fields))
+	if err != nil {
+		handleError(w, r, err, invalidFieldsMessage)
+		return
+	}
+
+	statistics, err := controller.service.FindStatistics(r.Context(), fields)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error while getting statistics", controller.logger)
+		return
+	}
 
-	_ = json.NewEncoder(writer).Encode(sheazuzu.ErrorResponse{
-		Code:        &errorCode,
-		Description: &statusText,
-		Details:     &[]string{details},
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, sheazuzu.StatisticsResponse{
+		Statistics: &statistics,
 	})
+}
+
+func (controller *Controller) GetPredictionByIdUsingGET(w http.ResponseWriter, r *http.Request, params sheazuzu.GetPredictionByIdUsingGETParams) {
+	op := verrors.Op("controller: GetPrediction")
+
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
+
+	fields, err := controller.parseFields("Prediction", (*[]string)(params.Fields))
+	if err != nil {
+		handleError(w, r, err, invalidFieldsMessage)
+		return
+	}
 
-	logger.Errorw(err.Error(),
-		"details", details,
-		"statusCode", statusCode,
-		"errorCode", errorCode)
+	result, err := controller.service.PredictMatch(r.Context(), params.Id, fields)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error while predicting match by id", controller.logger)
+		return
+	}
+
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, sheazuzu.PredictionResponse{
+		Prediction: &result,
+	})
 }
 
-func handleError(ctx context.Context, writer http.ResponseWriter, err error, message string) {
+func (controller *Controller) UpsertMatchDataBatchUsingPOST(w http.ResponseWriter, r *http.Request, params sheazuzu.UpsertMatchDataBatchUsingPOSTParams) {
+	op := verrors.Op("controller: UpsertMatchDataBatch")
 
-	statusCode := verrors.HttpErrorCodeFromError(err)
-	writer.WriteHeader(statusCode)
-	errorCode := verrors.GetErrorCode(err)
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
 
-	if statusCode == http.StatusNoContent {
-		return // NoContent 204 doesn't have a response body
+	var requestBody []sheazuzu.MatchData
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		msg := "Invalid request body"
+		handleError(w, r, verrors.E(op, verrors.HttpBadRequest, err, msg), msg)
+		return
 	}
 
-	logging.ContextLogger(ctx).Errorw(err.Error(), "statusCode", statusCode, "errorCode", errorCode)
+	response, err := controller.service.UpsertMatchDataBatch(r.Context(), requestBody, utils.ToString(params.Mode))
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error upserting MatchData batch", controller.logger)
+		return
+	}
 
-	_ = json.NewEncoder(writer).Encode(
-		sheazuzu.ErrorResponse{
-			Code:    utils.ToInt32Ptr(errorCode),
-			Name:    utils.ToStringPtr(http.StatusText(statusCode)),
-			Message: utils.ToStringPtrOrNil(message),
-			Details: &[]string{
-				fmt.Sprintf("TraceID: %s", tracing.TraceId(ctx)),
-			},
-		},
-	)
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, response)
 }
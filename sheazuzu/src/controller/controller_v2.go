@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"encoding/json"
+	"github.com/getkin/kin-openapi/openapi3"
+	"go.uber.org/zap"
+	"net/http"
+	verrors "sheazuzu/common/src/errors"
+	"sheazuzu/common/src/pagination"
+	"sheazuzu/common/src/utils"
+	"sheazuzu/sheazuzu/src/generated/sheazuzuv2"
+	"sheazuzu/sheazuzu/src/mapper"
+)
+
+// ControllerV2 implements the v2 API on the same service as the v1 Controller
+type ControllerV2 struct {
+	api
+}
+
+func ProvideSheazuzuAPIV2(service sheazuzuService, swaggerDoc *openapi3.Swagger, paginator *pagination.Paginator, logger *zap.SugaredLogger) *ControllerV2 {
+	return &ControllerV2{
+		api: api{
+			service:    service,
+			swaggerDoc: swaggerDoc,
+			paginator:  paginator,
+			logger:     logger,
+		},
+	}
+}
+
+func (controller *ControllerV2) ListMatches(w http.ResponseWriter, r *http.Request, params sheazuzuv2.ListMatchesParams) {
+	op := verrors.Op("controller: ListMatches")
+
+	contentType, ok := controller.negotiate(w, r, op, true)
+	if !ok {
+		return
+	}
+
+	fields, err := controller.parseFields("MatchData", (*[]string)(params.Fields))
+	if err != nil {
+		handleError(w, r, err, invalidFieldsMessage)
+		return
+	}
+
+	after, limit, err := controller.readPage(params.Cursor, params.Limit)
+	if err != nil {
+		handleError(w, r, err, invalidPageMessage)
+		return
+	}
+
+	resultList, next, err := controller.service.FindAllMatchData(r.Context(), fields, after, limit)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error while listing matches", controller.logger)
+		return
+	}
+
+	matches := mapper.MatchDataListToV2(resultList)
+
+	// as CSV only the list itself is rendered, one row per match, the next page is only linked in the Link header
+	controller.writeListResponse(w, r, op, contentType, sheazuzuv2.MatchList{
+		Matches:    &matches,
+		NextCursor: controller.nextPage(w, r, next, limit),
+	}, matches)
+}
+
+func (controller *ControllerV2) CreateMatch(w http.ResponseWriter, r *http.Request) {
+	op := verrors.Op("controller: CreateMatch")
+
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
+
+	var requestBody sheazuzuv2.MatchData
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		msg := "Invalid request body"
+		handleError(w, r, verrors.E(op, verrors.HttpBadRequest, err, msg), msg)
+		return
+	}
+
+	msg, id, err := controller.service.UpdateMatchData(r.Context(), mapper.V2ToMatchData(requestBody))
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error creating match", controller.logger)
+		return
+	}
+
+	controller.writeResponse(w, r, op, contentType, http.StatusCreated, sheazuzuv2.CreatedResponse{
+		Id:      utils.ToIntPtr(id),
+		Message: utils.ToStringPtr(msg),
+	})
+}
+
+func (controller *ControllerV2) GetMatch(w http.ResponseWriter, r *http.Request, id int, params sheazuzuv2.GetMatchParams) {
+	op := verrors.Op("controller: GetMatch")
+
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
+
+	fields, err := controller.parseFields("MatchData", (*[]string)(params.Fields))
+	if err != nil {
+		handleError(w, r, err, invalidFieldsMessage)
+		return
+	}
+
+	result, err := controller.service.FindMatchDataById(r.Context(), id, fields)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error while getting match by id", controller.logger)
+		return
+	}
+
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, mapper.MatchDataToV2(result))
+}
+
+func (controller *ControllerV2) GetMatchPrediction(w http.ResponseWriter, r *http.Request, id int, params sheazuzuv2.GetMatchPredictionParams) {
+	op := verrors.Op("controller: GetMatchPrediction")
+
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
+
+	fields, err := controller.parseFields("Prediction", (*[]string)(params.Fields))
+	if err != nil {
+		handleError(w, r, err, invalidFieldsMessage)
+		return
+	}
+
+	result, err := controller.service.PredictMatch(r.Context(), id, fields)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error while predicting match by id", controller.logger)
+		return
+	}
+
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, mapper.PredictionToV2(result))
+}
+
+func (controller *ControllerV2) UpsertMatchesBatch(w http.ResponseWriter, r *http.Request, params sheazuzuv2.UpsertMatchesBatchParams) {
+	op := verrors.Op("controller: UpsertMatchesBatch")
+
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
+
+	var requestBody []sheazuzuv2.MatchData
+	err := json.NewDecoder(r.Body).Decode(&requestBody)
+	if err != nil {
+		msg := "Invalid request body"
+		handleError(w, r, verrors.E(op, verrors.HttpBadRequest, err, msg), msg)
+		return
+	}
+
+	response, err := controller.service.UpsertMatchDataBatch(r.Context(), mapper.V2ToMatchDataList(requestBody), utils.ToString(params.Mode))
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error upserting match batch", controller.logger)
+		return
+	}
+
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, mapper.BatchResponseToV2(response))
+}
+
+func (controller *ControllerV2) GetStatistics(w http.ResponseWriter, r *http.Request, params sheazuzuv2.GetStatisticsParams) {
+	op := verrors.Op("controller: GetStatistics")
+
+	contentType, ok := controller.negotiate(w, r, op, false)
+	if !ok {
+		return
+	}
+
+	fields, err := controller.parseFields("Statistics", (*[]string)(params.Fields))
+	if err != nil {
+		handleError(w, r, err, invalidFieldsMessage)
+		return
+	}
+
+	statistics, err := controller.service.FindStatistics(r.Context(), fields)
+	if err != nil {
+		writeErrorResponse(w, r, op, err, "error while getting statistics", controller.logger)
+		return
+	}
+
+	controller.writeResponse(w, r, op, contentType, http.StatusOK, mapper.StatisticsToV2(statistics))
+}
@@ -1,21 +1,133 @@
 package database
 
 import (
+	"context"
+	"embed"
+	"fmt"
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/jinzhu/gorm"
-	"sheazuzu/sheazuzu/src/entity"
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+	"go.uber.org/zap"
+	"path"
+	commondb "sheazuzu/common/src/database"
+	"sheazuzu/common/src/migrate"
+	"time"
 )
 
-func InitDB(conString string) *gorm.DB {
-	db, err := gorm.Open("mysql", conString)
+// Connect connects to the database of the config with its driver, SSL options, pool settings and query log. The queries
+// are traced, measured and logged if slow, see commondb.RegisterCallbacks.
+func Connect(config *commondb.Config, logger *zap.SugaredLogger) (*gorm.DB, error) {
+
+	err := config.RegisterTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := OpenDB(config.GormDialect(), config.GetDatabaseConn())
+	if err != nil {
+		return nil, err
+	}
+
+	config.ConfigurePool(db.DB())
+	commondb.RegisterCallbacks(db, config.SlowQueryThreshold, logger)
+
+	// gorm logs failed queries by default
+	switch config.LogLevel {
+	case commondb.LogOff:
+		db.LogMode(false)
+	case commondb.LogAll:
+		db.LogMode(true)
+	}
+
+	return db, nil
+}
+
+// ConnectReplicas connects to the read replicas of the config like Connect and returns their router with the primary,
+// nil without replicas. The health of the replicas is checked once, ReplicaRouter.Run keeps checking it.
+func ConnectReplicas(config *commondb.Config, primary *gorm.DB, logger *zap.SugaredLogger) (*commondb.ReplicaRouter, error) {
+
+	if len(config.Replicas) == 0 {
+		return nil, nil
+	}
+
+	var replicas []commondb.Replica
+	closeReplicas := func() {
+		for _, replica := range replicas {
+			replica.DB.Close()
+		}
+	}
+
+	for _, endpoint := range config.Replicas {
+		replicaConfig, err := config.ReplicaConfig(endpoint)
+		if err != nil {
+			closeReplicas()
+			return nil, err
+		}
+
+		db, err := Connect(&replicaConfig, logger.With("replica", endpoint))
+		if err != nil {
+			closeReplicas()
+			return nil, fmt.Errorf("error connecting to the replica %s: %w", endpoint, err)
+		}
+		replicas = append(replicas, commondb.Replica{Endpoint: endpoint, DB: db})
+	}
+
+	router := commondb.NewReplicaRouter(primary, replicas, config.ReadYourWritesWindow, logger)
+	router.CheckHealth(context.Background(), config.ReplicaHealthInterval)
+
+	return router, nil
+}
+
+// OpenDB connects to MySQL, PostgreSQL or SQLite, dialect is the gorm dialect "mysql", "postgres" or "sqlite3".
+// The schema is not changed, it is managed by the migrations.
+func OpenDB(dialect string, conString string) (*gorm.DB, error) {
+
+	db, err := gorm.Open(dialect, conString)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
-	db.AutoMigrate(
-		&entity.MatchData{},
-		&entity.AdditionalInformation{},
-	)
-	return db
+
+	// SQLite allows one writer at a time, so a single connection avoids busy errors. It also keeps an in-memory
+	// database alive, which exists only as long as its connection.
+	if dialect == "sqlite3" {
+		db.DB().SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
+
+// the migrations of every driver are in a directory named like the driver, they have the same versions
+//
+//go:embed migrations/mysql/*.sql migrations/postgres/*.sql migrations/sqlite/*.sql
+var migrations embed.FS
+
+// the MySQL lock and the PostgreSQL advisory lock held while migrating
+const (
+	migrationLock   = "sheazuzu_schema_migrations"
+	migrationLockID = 7305729465
+)
+
+// NewMigrator returns the migrator of the schema with the migrations embedded into the binary
+func NewMigrator(db *gorm.DB, logger *zap.SugaredLogger) (*migrate.Migrator, error) {
+
+	var dialect migrate.Dialect = migrate.MySQL{LockName: migrationLock, LockTimeout: time.Minute}
+	dir := commondb.DriverMySQL
+	switch db.Dialect().GetName() {
+	case "postgres":
+		dialect = migrate.Postgres{LockID: migrationLockID, LockTimeout: time.Minute}
+		dir = commondb.DriverPostgres
+	case "sqlite3":
+		dialect = migrate.SQLite{}
+		dir = commondb.DriverSQLite
+	}
+
+	loaded, err := migrate.Load(migrations, path.Join("migrations", dir))
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.NewMigrator(db.DB(), dialect, loaded, logger), nil
 }
 
 // "root:455279980@/atb?charset=utf8&parseTime=True&loc=Local"
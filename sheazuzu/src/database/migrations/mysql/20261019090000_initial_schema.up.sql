@@ -0,0 +1,22 @@
+-- the schema created by gorm AutoMigrate before the migrations were introduced,
+-- IF NOT EXISTS lets existing databases adopt the migrations without changes
+CREATE TABLE IF NOT EXISTS match_data (
+  id int AUTO_INCREMENT,
+  away_team varchar(255),
+  date varchar(255),
+  home_team varchar(255),
+  match_type varchar(255),
+  result varchar(255),
+  PRIMARY KEY (id)
+);
+
+CREATE TABLE IF NOT EXISTS additional_informations (
+  id int unsigned AUTO_INCREMENT,
+  created_at DATETIME NULL,
+  updated_at DATETIME NULL,
+  deleted_at DATETIME NULL,
+  additional varchar(255),
+  information varchar(255),
+  PRIMARY KEY (id),
+  INDEX idx_additional_informations_deleted_at (deleted_at)
+);
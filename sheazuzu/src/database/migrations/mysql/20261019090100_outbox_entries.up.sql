@@ -0,0 +1,12 @@
+-- the matches written to MySQL, which still have to be mirrored to MongoDB
+CREATE TABLE IF NOT EXISTS outbox_entries (
+  id int unsigned AUTO_INCREMENT,
+  match_id int,
+  payload text,
+  created_at DATETIME NULL,
+  attempts int,
+  next_attempt_at DATETIME NULL,
+  last_error text,
+  PRIMARY KEY (id),
+  INDEX idx_outbox_entries_match_id (match_id)
+);
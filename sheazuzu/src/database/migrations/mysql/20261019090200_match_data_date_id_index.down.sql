@@ -0,0 +1 @@
+DROP INDEX idx_match_data_date_id ON match_data;
@@ -0,0 +1,8 @@
+-- the index of the keyset pagination of the matches. It is created on its own, as the initial schema leaves the table
+-- of existing AutoMigrate databases unchanged. Databases, which got the index with an earlier initial schema, keep it.
+SET @index_exists = (SELECT COUNT(*) FROM information_schema.statistics
+  WHERE table_schema = DATABASE() AND table_name = 'match_data' AND index_name = 'idx_match_data_date_id');
+SET @create_index = IF(@index_exists = 0, 'CREATE INDEX idx_match_data_date_id ON match_data (date, id)', 'DO 0');
+PREPARE create_index FROM @create_index;
+EXECUTE create_index;
+DEALLOCATE PREPARE create_index;
@@ -0,0 +1,20 @@
+-- the schema of the matches, the same as the MySQL schema of this version
+CREATE TABLE IF NOT EXISTS match_data (
+  id serial PRIMARY KEY,
+  away_team varchar(255),
+  date varchar(255),
+  home_team varchar(255),
+  match_type varchar(255),
+  result varchar(255)
+);
+
+CREATE TABLE IF NOT EXISTS additional_informations (
+  id serial PRIMARY KEY,
+  created_at timestamp with time zone,
+  updated_at timestamp with time zone,
+  deleted_at timestamp with time zone,
+  additional varchar(255),
+  information varchar(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_additional_informations_deleted_at ON additional_informations (deleted_at);
@@ -0,0 +1,12 @@
+-- the matches written to PostgreSQL, which still have to be mirrored to MongoDB
+CREATE TABLE IF NOT EXISTS outbox_entries (
+  id serial PRIMARY KEY,
+  match_id integer,
+  payload text,
+  created_at timestamp with time zone,
+  attempts integer,
+  next_attempt_at timestamp with time zone,
+  last_error text
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_entries_match_id ON outbox_entries (match_id);
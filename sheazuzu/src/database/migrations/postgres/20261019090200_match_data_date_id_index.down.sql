@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_match_data_date_id;
@@ -0,0 +1,3 @@
+-- the index of the keyset pagination of the matches. It is created on its own, as the initial schema leaves the table
+-- of existing AutoMigrate databases unchanged.
+CREATE INDEX IF NOT EXISTS idx_match_data_date_id ON match_data (date, id);
@@ -0,0 +1,2 @@
+DROP TABLE additional_informations;
+DROP TABLE match_data;
@@ -0,0 +1,20 @@
+-- the schema of the matches, the same as the MySQL schema of this version
+CREATE TABLE IF NOT EXISTS match_data (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  away_team varchar(255),
+  date varchar(255),
+  home_team varchar(255),
+  match_type varchar(255),
+  result varchar(255)
+);
+
+CREATE TABLE IF NOT EXISTS additional_informations (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  created_at datetime,
+  updated_at datetime,
+  deleted_at datetime,
+  additional varchar(255),
+  information varchar(255)
+);
+
+CREATE INDEX IF NOT EXISTS idx_additional_informations_deleted_at ON additional_informations (deleted_at);
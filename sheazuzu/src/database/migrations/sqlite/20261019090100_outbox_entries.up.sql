@@ -0,0 +1,12 @@
+-- the matches written to SQLite, which still have to be mirrored to MongoDB
+CREATE TABLE IF NOT EXISTS outbox_entries (
+  id integer PRIMARY KEY AUTOINCREMENT,
+  match_id integer,
+  payload text,
+  created_at datetime,
+  attempts integer,
+  next_attempt_at datetime,
+  last_error text
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_entries_match_id ON outbox_entries (match_id);
@@ -4,33 +4,62 @@ import (
 	"context"
 	"go.mongodb.org/mongo-driver/bson"
 	mongoClient "go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.opencensus.io/trace"
 	verrors "sheazuzu/common/src/errors"
 	"sheazuzu/common/src/mongo"
 	"sheazuzu/common/src/tracing"
 	"sheazuzu/sheazuzu/src/entity"
-	"sheazuzu/sheazuzu/src/generated/sheazuzu"
+	"strings"
+	"time"
 )
 
 type MongoDatabase struct {
-	mongo *mongo.Database
+	mongo     *mongo.Database
+	matchData *mongo.Collection[entity.MatchData]
 }
 
-func NewMongoDatabase(mongo *mongo.Database) *MongoDatabase {
-	return &MongoDatabase{mongo: mongo}
+func NewMongoDatabase(database *mongo.Database) *MongoDatabase {
+	return &MongoDatabase{
+		mongo:     database,
+		matchData: mongo.NewCollection[entity.MatchData](database, matchDataSet, "id"),
+	}
 }
 
-const matchDataSet = "matchData"
+const (
+	matchDataSet  = "matchData"
+	counterSet    = "counters"
+	checkpointSet = "checkpoints"
+)
 
-func (database *MongoDatabase) Save(ctx context.Context, matchData entity.MatchData) error {
+// Save inserts the match. A match without id gets the next id of the match data counter, like an auto increment column.
+func (database *MongoDatabase) Save(ctx context.Context, matchData *entity.MatchData) error {
 	op := verrors.Op("MongoDB: Save MatchData")
 
 	ctx, span := tracing.StartSpan(ctx, "Save Match Data")
-	span.AddAttributes(trace.Int64Attribute("Id", int64(matchData.Id)))
 	defer span.End()
 
-	_, err := database.mongo.Database.Collection(matchDataSet).InsertOne(ctx, matchData)
+	id, err := database.nextId(ctx, matchData.Id)
+	if err != nil {
+		return verrors.E(op, err)
+	}
+	matchData.Id = id
+	span.AddAttributes(trace.Int64Attribute("Id", int64(matchData.Id)))
+
+	_, err = database.mongo.Database.Collection(matchDataSet).InsertOne(ctx, matchData)
+	if err != nil {
+		return verrors.E(op, err)
+	}
+
+	return nil
+}
+
+// Put stores the match under its id, an existing match with the same id is replaced.
+// It mirrors the matches of MySQL, so the id counter is not used.
+func (database *MongoDatabase) Put(ctx context.Context, matchData entity.MatchData) error {
+	op := verrors.Op("MongoDB: Put MatchData")
 
+	_, err := database.matchData.Upsert(ctx, bson.D{{Key: "id", Value: matchData.Id}}, matchData)
 	if err != nil {
 		return verrors.E(op, err)
 	}
@@ -38,41 +67,435 @@ func (database *MongoDatabase) Save(ctx context.Context, matchData entity.MatchD
 	return nil
 }
 
-func (database *MongoDatabase) FindByID(ctx context.Context, id int) (sheazuzu.MatchData, error) {
-	op := verrors.Op("MongoDB: Fetch Storage Entry by MarketingCode")
-	info := []verrors.Info{
-		{
-			Name: "id",
-			Val:  id,
-		},
+// PutBatch stores the matches under their ids in one bulk write, existing matches with the same ids are replaced.
+// Like Put it mirrors the matches of MySQL, the id counter is only moved behind the highest id.
+func (database *MongoDatabase) PutBatch(ctx context.Context, data []entity.MatchData) error {
+	op := verrors.Op("MongoDB: Put MatchData batch")
+
+	if len(data) == 0 {
+		return nil
 	}
 
-	ctx, span := tracing.StartSpan(ctx, "Fetch match data by id")
-	span.AddAttributes(trace.StringAttribute("id", string(id)))
-	defer span.End()
+	highest := 0
+	models := make([]mongoClient.WriteModel, 0, len(data))
+	for _, matchData := range data {
+		models = append(models, mongoClient.NewReplaceOneModel().
+			SetFilter(bson.D{{Key: "id", Value: matchData.Id}}).
+			SetReplacement(matchData).
+			SetUpsert(true))
+		if matchData.Id > highest {
+			highest = matchData.Id
+		}
+	}
+
+	_, err := database.matchData.BulkWrite(ctx, models, false)
+	if err != nil {
+		return verrors.E(op, err)
+	}
+
+	_, err = database.nextId(ctx, highest)
+	if err != nil {
+		return verrors.E(op, err)
+	}
+
+	return nil
+}
 
-	// option 'i' makes search case-insensitive
-	filter := bson.D{
-		{
-			Key:   "_id",
-			Value: id,
-		},
+// FindAfterId returns up to limit matches with an id greater than afterId ordered by id
+func (database *MongoDatabase) FindAfterId(ctx context.Context, afterId int, limit int) ([]entity.MatchData, error) {
+	op := verrors.Op("MongoDB: Fetch match data after id")
+
+	filter := bson.D{{Key: "id", Value: bson.D{{Key: "$gt", Value: afterId}}}}
+	query := mongo.Query{Sort: bson.D{{Key: "id", Value: 1}}, Limit: int64(limit)}
+
+	data, err := database.matchData.Find(ctx, filter, query)
+	if err != nil {
+		return nil, verrors.E(op, err)
 	}
 
-	result := database.mongo.Database.Collection(matchDataSet).FindOne(ctx, filter)
-	err := result.Err()
+	return data, nil
+}
+
+// Checkpoint returns the last id saved with SaveCheckpoint under the name, 0 if there is none
+func (database *MongoDatabase) Checkpoint(ctx context.Context, name string) (int, error) {
+	op := verrors.Op("MongoDB: Fetch checkpoint")
+
+	var checkpoint struct {
+		LastId int `bson:"lastId"`
+	}
+	err := database.mongo.Database.Collection(checkpointSet).
+		FindOne(ctx, bson.D{{Key: "_id", Value: name}}).
+		Decode(&checkpoint)
+	if err == mongoClient.ErrNoDocuments {
+		return 0, nil
+	}
 	if err != nil {
-		if err == mongoClient.ErrNoDocuments {
-			return sheazuzu.MatchData{}, nil
+		return 0, verrors.E(op, err)
+	}
+
+	return checkpoint.LastId, nil
+}
+
+// SaveCheckpoint saves the last id processed by a resumable job under its name
+func (database *MongoDatabase) SaveCheckpoint(ctx context.Context, name string, lastId int) error {
+	op := verrors.Op("MongoDB: Save checkpoint")
+
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "lastId", Value: lastId}, {Key: "updatedAt", Value: time.Now().UTC()}}}}
+	_, err := database.mongo.Database.Collection(checkpointSet).
+		UpdateOne(ctx, bson.D{{Key: "_id", Value: name}}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return verrors.E(op, err)
+	}
+
+	return nil
+}
+
+// nextId returns the next free id of the match data counter. If the id is already set, the counter is moved behind it,
+// so later matches without id do not collide with it.
+func (database *MongoDatabase) nextId(ctx context.Context, id int) (int, error) {
+
+	filter := bson.D{{Key: "_id", Value: matchDataSet}}
+	update := bson.D{{Key: "$inc", Value: bson.D{{Key: "seq", Value: 1}}}}
+	if id != 0 {
+		update = bson.D{{Key: "$max", Value: bson.D{{Key: "seq", Value: id}}}}
+	}
+
+	var counter struct {
+		Seq int `bson:"seq"`
+	}
+	err := database.mongo.Database.Collection(counterSet).
+		FindOneAndUpdate(ctx, filter, update, options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)).
+		Decode(&counter)
+	if err != nil {
+		return 0, err
+	}
+
+	if id != 0 {
+		return id, nil
+	}
+	return counter.Seq, nil
+}
+
+// FindByID returns the match with the given id, only the fields of the projection are loaded.
+// entity.ErrNotFound is returned, if there is no such match.
+func (database *MongoDatabase) FindByID(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error) {
+	op := verrors.Op("MongoDB: Fetch match data by id")
+
+	var projectionDoc bson.D
+	if !projection.All() {
+		projectionDoc = projectionDocument(projection)
+	}
+
+	data, err := database.matchData.FindByID(ctx, id, projectionDoc)
+	if verrors.Is(err, verrors.HttpNotFound) {
+		return entity.MatchData{}, entity.ErrNotFound
+	}
+	if err != nil {
+		return entity.MatchData{}, verrors.E(op, err)
+	}
+
+	return data, nil
+}
+
+const (
+	// the index of the range queries of FindAll
+	dateIdIndex = "date_id"
+	// the ids are unique, like the primary key in MySQL
+	idIndex = "id"
+)
+
+// RegisterIndexes declares the indexes of the match data collection, they are created by reconciling the registry
+func RegisterIndexes(registry *mongo.IndexRegistry) error {
+	return registry.Register(
+		mongo.Index{Collection: matchDataSet, Name: idIndex, Keys: bson.D{{Key: "id", Value: 1}}, Unique: true},
+		mongo.Index{Collection: matchDataSet, Name: dateIdIndex, Keys: bson.D{{Key: "date", Value: 1}, {Key: "id", Value: 1}}},
+	)
+}
+
+// ReconcileIndexes creates or recreates the indexes of all collections, which differ from their declaration
+func (database *MongoDatabase) ReconcileIndexes(ctx context.Context, reconcileOptions mongo.ReconcileOptions) ([]mongo.IndexChange, error) {
+
+	registry := mongo.NewIndexRegistry()
+	err := RegisterIndexes(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	return database.mongo.ReconcileIndexes(ctx, registry, reconcileOptions)
+}
+
+// FindPlayed returns all matches with a result ordered by date
+func (database *MongoDatabase) FindPlayed(ctx context.Context) ([]entity.MatchData, error) {
+	op := verrors.Op("MongoDB: Fetch played match data")
+
+	filter := bson.D{{Key: "result", Value: bson.D{{Key: "$regex", Value: ":"}}}}
+	query := mongo.Query{Sort: bson.D{{Key: "date", Value: 1}, {Key: "id", Value: 1}}}
+
+	data, err := database.matchData.Find(ctx, filter, query)
+	if err != nil {
+		return nil, verrors.E(op, err)
+	}
+
+	return data, nil
+}
+
+// UpsertBatch creates or updates all matches and returns one result per match, like the MySQL repository.
+// With allOrNothing all matches are written in one transaction, which needs a replica set.
+func (database *MongoDatabase) UpsertBatch(ctx context.Context, data []entity.MatchData, allOrNothing bool) ([]entity.UpsertResult, error) {
+	op := verrors.Op("MongoDB: Upsert match data batch")
+
+	ctx, span := tracing.StartSpan(ctx, "Upsert match data batch")
+	span.AddAttributes(trace.Int64Attribute("size", int64(len(data))), trace.BoolAttribute("allOrNothing", allOrNothing))
+	defer span.End()
+
+	results := make([]entity.UpsertResult, len(data))
+
+	if !allOrNothing {
+		for i := range data {
+			created, err := database.upsert(ctx, &data[i])
+			results[i] = entity.UpsertResult{Id: data[i].Id, Created: created, Attempted: true, Err: err}
+		}
+		return results, nil
+	}
+
+	failed := false
+	err := database.WithinTransaction(ctx, func(ctx context.Context) error {
+
+		// a retried transaction starts with the matches of the caller again
+		failed = false
+		batch := append([]entity.MatchData(nil), data...)
+
+		for i := range batch {
+			created, err := database.upsert(ctx, &batch[i])
+			results[i] = entity.UpsertResult{Id: batch[i].Id, Created: created, Attempted: true, Err: err}
+			if err == nil {
+				continue
+			}
+
+			failed = true
+			for j := 0; j < i; j++ {
+				results[j].RolledBack = true
+			}
+			return err
 		}
-		return sheazuzu.MatchData{}, verrors.E(err, op, info)
+
+		return nil
+	})
+	if failed && mongoClient.SessionFromContext(ctx) == nil {
+		return results, nil
+	}
+	// MongoDB has no savepoints, so within an outer transaction the written matches are only rolled back,
+	// if the error makes the outer transaction fail
+	if err != nil {
+		return nil, verrors.E(op, err)
+	}
+
+	return results, nil
+}
+
+// WithinTransaction runs f in a transaction, all operations with the context passed to f are part of it.
+// See mongo.Database.WithTransaction, a transaction with a transient error is retried.
+func (database *MongoDatabase) WithinTransaction(ctx context.Context, f func(ctx context.Context) error) error {
+	return database.mongo.WithTransaction(ctx, f)
+}
+
+// WithinSnapshot runs f with a session reading from one snapshot, see mongo.Database.WithSnapshot.
+func (database *MongoDatabase) WithinSnapshot(ctx context.Context, f func(ctx context.Context) error) error {
+	return database.mongo.WithSnapshot(ctx, f)
+}
+
+// upsert replaces the match with the same id or, if no id is set, with the same teams and date.
+// If there is no such match, it is created. The returned bool is true, if the match was created.
+func (database *MongoDatabase) upsert(ctx context.Context, data *entity.MatchData) (bool, error) {
+
+	filter := bson.D{{Key: "id", Value: data.Id}}
+	if data.Id == 0 {
+		filter = bson.D{{Key: "hometeam", Value: data.HomeTeam}, {Key: "awayteam", Value: data.AwayTeam}, {Key: "date", Value: data.Date}}
+	}
+
+	var existing entity.MatchData
+	err := database.mongo.Database.Collection(matchDataSet).
+		FindOne(ctx, filter, options.FindOne().SetProjection(bson.D{{Key: "id", Value: 1}})).
+		Decode(&existing)
+	if err == mongoClient.ErrNoDocuments {
+		return true, database.Save(ctx, data)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	data.Id = existing.Id
+	_, err = database.mongo.Database.Collection(matchDataSet).ReplaceOne(ctx, bson.D{{Key: "id", Value: data.Id}}, data)
+	return false, err
+}
+
+// FindAll returns the page of matches ordered by date and id, only the fields of the projection are loaded.
+// The page is read with a range query on the date_id index.
+func (database *MongoDatabase) FindAll(ctx context.Context, projection entity.Projection, page entity.Page) ([]entity.MatchData, error) {
+	op := verrors.Op("MongoDB: Fetch all match data")
+
+	filter := bson.D{}
+	if page.After != nil {
+		filter = bson.D{{Key: "$or", Value: bson.A{
+			bson.D{{Key: "date", Value: bson.D{{Key: "$gt", Value: page.After.Date}}}},
+			bson.D{{Key: "date", Value: page.After.Date}, {Key: "id", Value: bson.D{{Key: "$gt", Value: page.After.Id}}}},
+		}}}
 	}
 
-	var data sheazuzu.MatchData
-	err = result.Decode(&data)
+	query := mongo.Query{Sort: bson.D{{Key: "date", Value: 1}, {Key: "id", Value: 1}}, Limit: int64(page.Limit)}
+	if !projection.All() {
+		query.Projection = projectionDocument(projection)
+	}
+
+	data, err := database.matchData.Find(ctx, filter, query)
 	if err != nil {
-		return sheazuzu.MatchData{}, verrors.E(op, info, err)
+		return nil, verrors.E(op, err)
 	}
 
 	return data, nil
 }
+
+// projectionDocument translates the projection into a MongoDB projection,
+// the documents are stored with the lowercase entity field names
+func projectionDocument(projection entity.Projection) bson.D {
+
+	document := bson.D{{Key: "id", Value: 1}, {Key: "date", Value: 1}}
+	for _, field := range projection.Fields {
+		switch {
+		case field == "Id" || field == "Date":
+			continue
+		case field == "AdditionalInformation" && len(projection.AdditionalInformation) > 0:
+			// the keys of the additional information are always loaded, like in MySQL
+			document = append(document,
+				bson.E{Key: "additionalinformation.model", Value: 1},
+				bson.E{Key: "additionalinformation.additional", Value: 1},
+			)
+			for _, nested := range projection.AdditionalInformation {
+				if nested != "Additional" {
+					document = append(document, bson.E{Key: "additionalinformation." + strings.ToLower(nested), Value: 1})
+				}
+			}
+		default:
+			document = append(document, bson.E{Key: strings.ToLower(field), Value: 1})
+		}
+	}
+
+	return document
+}
+
+const topScorelines = 10
+
+// outcomesGroup sums up the outcomes of all matches sharing the same key, see entity.OutcomeCount
+func outcomesGroup(key interface{}) bson.D {
+	return bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: key},
+		{Key: "matches", Value: bson.D{{Key: "$sum", Value: 1}}},
+		{Key: "homeWins", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$gt", Value: bson.A{"$homeGoals", "$awayGoals"}}}, 1, 0}}}}}},
+		{Key: "draws", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$eq", Value: bson.A{"$homeGoals", "$awayGoals"}}}, 1, 0}}}}}},
+		{Key: "awayWins", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$lt", Value: bson.A{"$homeGoals", "$awayGoals"}}}, 1, 0}}}}}},
+		{Key: "goals", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$add", Value: bson.A{"$homeGoals", "$awayGoals"}}}}}},
+	}}}
+}
+
+// goals converts the n-th part of the result "<home goals>:<away goals>" into a number
+func goals(n int) bson.D {
+	return toInt(bson.D{{Key: "$arrayElemAt", Value: bson.A{bson.D{{Key: "$split", Value: bson.A{"$result", ":"}}}, n}}})
+}
+
+// toInt converts the expression into a number, which is null if the expression is no number
+func toInt(expression interface{}) bson.D {
+	return bson.D{{Key: "$convert", Value: bson.D{
+		{Key: "input", Value: expression},
+		{Key: "to", Value: "int"},
+		{Key: "onError", Value: nil},
+		{Key: "onNull", Value: nil},
+	}}}
+}
+
+// cleanSheets unwinds each match into its home and away side and counts the matches in which a team did not concede a goal
+func cleanSheets() bson.A {
+	return bson.A{
+		bson.D{{Key: "$project", Value: bson.D{{Key: "sides", Value: bson.A{
+			bson.D{{Key: "team", Value: "$hometeam"}, {Key: "conceded", Value: "$awayGoals"}},
+			bson.D{{Key: "team", Value: "$awayteam"}, {Key: "conceded", Value: "$homeGoals"}},
+		}}}}},
+		bson.D{{Key: "$unwind", Value: "$sides"}},
+		bson.D{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: "$sides.team"},
+			{Key: "cleanSheets", Value: bson.D{{Key: "$sum", Value: bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$eq", Value: bson.A{"$sides.conceded", 0}}}, 1, 0}}}}}},
+		}}},
+		bson.D{{Key: "$sort", Value: bson.D{{Key: "cleanSheets", Value: -1}, {Key: "_id", Value: 1}}}},
+	}
+}
+
+func (database *MongoDatabase) FindStatistics(ctx context.Context) (entity.Statistics, error) {
+	op := verrors.Op("MongoDB: Aggregate Statistics")
+
+	ctx, span := tracing.StartSpan(ctx, "Aggregate match statistics")
+	defer span.End()
+
+	// a season starts in July, e.g. a match on 2021-03-01 belongs to the season 2020/2021
+	// a malformed date has no season instead of failing the aggregation
+	year := toInt(bson.D{{Key: "$substrCP", Value: bson.A{"$date", 0, 4}}})
+	month := toInt(bson.D{{Key: "$substrCP", Value: bson.A{"$date", 5, 2}}})
+	seasonStart := bson.D{{Key: "$cond", Value: bson.A{bson.D{{Key: "$gte", Value: bson.A{month, 7}}}, year, bson.D{{Key: "$subtract", Value: bson.A{year, 1}}}}}}
+	season := bson.D{{Key: "$concat", Value: bson.A{
+		bson.D{{Key: "$toString", Value: seasonStart}},
+		"/",
+		bson.D{{Key: "$toString", Value: bson.D{{Key: "$add", Value: bson.A{seasonStart, 1}}}}},
+	}}}
+
+	pipeline := mongoClient.Pipeline{
+		// only well-formed results reach the conversion of the goals, the others are ignored like by the other backends
+		{{Key: "$match", Value: bson.D{{Key: "result", Value: bson.D{{Key: "$regex", Value: "^[0-9]+:[0-9]+$"}}}}}},
+		{{Key: "$addFields", Value: bson.D{{Key: "homeGoals", Value: goals(0)}, {Key: "awayGoals", Value: goals(1)}}}},
+		{{Key: "$facet", Value: bson.D{
+			{Key: "overall", Value: bson.A{outcomesGroup(nil)}},
+			{Key: "byMatchType", Value: bson.A{outcomesGroup("$matchtype"), bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}}}},
+			{Key: "bySeason", Value: bson.A{outcomesGroup(season), bson.D{{Key: "$sort", Value: bson.D{{Key: "_id", Value: 1}}}}}},
+			{Key: "scorelines", Value: bson.A{
+				bson.D{{Key: "$group", Value: bson.D{
+					{Key: "_id", Value: bson.D{{Key: "$concat", Value: bson.A{bson.D{{Key: "$toString", Value: "$homeGoals"}}, ":", bson.D{{Key: "$toString", Value: "$awayGoals"}}}}}},
+					{Key: "count", Value: bson.D{{Key: "$sum", Value: 1}}},
+				}}},
+				bson.D{{Key: "$sort", Value: bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}}},
+				bson.D{{Key: "$limit", Value: topScorelines}},
+			}},
+			{Key: "cleanSheets", Value: cleanSheets()},
+		}}},
+	}
+
+	cursor, err := database.mongo.Database.Collection(matchDataSet).Aggregate(ctx, pipeline)
+	if err != nil {
+		return entity.Statistics{}, verrors.E(op, err)
+	}
+	defer mongo.CloseCursor(cursor, ctx)
+
+	var facets []struct {
+		Overall     []entity.OutcomeCount    `bson:"overall"`
+		ByMatchType []entity.OutcomeCount    `bson:"byMatchType"`
+		BySeason    []entity.OutcomeCount    `bson:"bySeason"`
+		Scorelines  []entity.ScorelineCount  `bson:"scorelines"`
+		CleanSheets []entity.CleanSheetCount `bson:"cleanSheets"`
+	}
+	err = cursor.All(ctx, &facets)
+	if err != nil {
+		return entity.Statistics{}, verrors.E(op, err)
+	}
+
+	statistics := entity.Statistics{}
+	if len(facets) == 0 {
+		return statistics, nil
+	}
+
+	facet := facets[0]
+	if len(facet.Overall) > 0 {
+		statistics.Overall = facet.Overall[0]
+	}
+	statistics.ByMatchType = facet.ByMatchType
+	statistics.BySeason = facet.BySeason
+	statistics.Scorelines = facet.Scorelines
+	statistics.CleanSheets = facet.CleanSheets
+
+	return statistics, nil
+}
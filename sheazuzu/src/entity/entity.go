@@ -1,13 +1,20 @@
 package entity
 
-import "github.com/jinzhu/gorm"
+import (
+	"errors"
+	"github.com/jinzhu/gorm"
+	"time"
+)
+
+// ErrNotFound is returned by all repositories, if the requested match does not exist
+var ErrNotFound = errors.New("match data not found")
 
 type MatchData struct {
 	AdditionalInformation AdditionalInformation `gorm:"foreignKey:additional;association_foreignKey:id"`
 	AwayTeam              string
-	Date                  string
+	Date                  string `gorm:"index:idx_match_data_date_id"`
 	HomeTeam              string
-	Id                    int `gorm:"column:id;primary_key:yes"`
+	Id                    int `gorm:"column:id;primary_key:yes;index:idx_match_data_date_id"`
 	MatchType             string
 	Result                string
 }
@@ -17,3 +24,92 @@ type AdditionalInformation struct {
 	Additional  string
 	Information string
 }
+
+// Projection selects the fields of MatchData loaded from the database by their Go names. An empty Fields loads all
+// fields, an empty AdditionalInformation all fields of the AdditionalInformation, if it is selected at all.
+type Projection struct {
+	Fields                []string
+	AdditionalInformation []string
+}
+
+// All returns true, if the projection loads all fields.
+func (projection Projection) All() bool {
+	return len(projection.Fields) == 0
+}
+
+// Has returns true, if the MatchData field is loaded.
+func (projection Projection) Has(field string) bool {
+	if projection.All() {
+		return true
+	}
+	for _, f := range projection.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Page selects up to Limit matches in the order of date and id. Without After the page starts with the first match,
+// otherwise with the first match behind After.
+type Page struct {
+	After *PageKey
+	Limit int
+}
+
+// PageKey is the position of a match in the order of date and id.
+type PageKey struct {
+	Date string
+	Id   int
+}
+
+// OutcomeCount holds the aggregated outcomes of all matches sharing the same Name.
+type OutcomeCount struct {
+	Name     string `bson:"_id"`
+	Matches  int    `bson:"matches"`
+	HomeWins int    `bson:"homeWins"`
+	Draws    int    `bson:"draws"`
+	AwayWins int    `bson:"awayWins"`
+	Goals    int    `bson:"goals"`
+}
+
+type ScorelineCount struct {
+	Scoreline string `bson:"_id"`
+	Count     int    `bson:"count"`
+}
+
+type CleanSheetCount struct {
+	Team        string `bson:"_id"`
+	CleanSheets int    `bson:"cleanSheets"`
+}
+
+type Statistics struct {
+	Overall     OutcomeCount
+	Scorelines  []ScorelineCount
+	CleanSheets []CleanSheetCount
+	ByMatchType []OutcomeCount
+	BySeason    []OutcomeCount
+}
+
+// UpsertResult is the outcome of storing one match of a batch.
+// Attempted is false, if the match was never written because an earlier match of an all-or-nothing batch failed.
+type UpsertResult struct {
+	Id         int
+	Created    bool
+	Attempted  bool
+	RolledBack bool
+	Err        error
+}
+
+// OutboxEntry is a write of a match to MySQL, which still has to be mirrored to MongoDB. It is stored in the same
+// transaction as the match, so no write gets lost, if MongoDB is not available. Payload is the match as JSON.
+// The entries of a match are applied in the order of their ids and deleted afterwards.
+type OutboxEntry struct {
+	Id            uint   `gorm:"primary_key"`
+	MatchId       int    `gorm:"index"`
+	Payload       string `gorm:"type:text"`
+	CreatedAt     time.Time
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string `gorm:"type:text"`
+}
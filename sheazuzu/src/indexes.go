@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sheazuzu/common/src/cli"
+	"sheazuzu/common/src/logging"
+	"sheazuzu/common/src/mongo"
+	"sheazuzu/sheazuzu/src/configuration"
+	"sheazuzu/sheazuzu/src/database"
+)
+
+func indexesCommand(config *configuration.Configuration) cli.Command {
+
+	validate := func() bool {
+		return config.Validate() && config.Mongo.IsValid()
+	}
+
+	return cli.Command{
+		Name:  "indexes",
+		Usage: "Manages the declared indexes of the MongoDB collections",
+		SubCommands: []cli.Command{
+			{
+				Name:     "plan",
+				Usage:    "Reports the changes of the indexes needed to match their declaration without changing them",
+				Flags:    config.SetupFlags("sheazuzu"),
+				Validate: validate,
+				Run:      ReconcileIndexes(config, true),
+			},
+			{
+				Name:     "reconcile",
+				Usage:    "Creates the missing and recreates the changed indexes, with mongo.dropUnknownIndexes it drops the unknown ones",
+				Flags:    config.SetupFlags("sheazuzu"),
+				Validate: validate,
+				Run:      ReconcileIndexes(config, false),
+			},
+		},
+	}
+}
+
+func ReconcileIndexes(cfg *configuration.Configuration, dryRun bool) func(cmd *cli.Command, args ...string) {
+	return func(cmd *cli.Command, args ...string) {
+
+		logger := logging.GetLogger(cfg.Logging.Level, cfg.Logging.Format).
+			With("version", cmd.Version)
+
+		mongoDatabase, err := mongo.NewMongoDatabase(&cfg.Mongo, logger)
+		if err == nil {
+			err = mongoDatabase.Connect(context.Background())
+		}
+		if err != nil {
+			logger.Error("error connecting to MongoDB", "error", err)
+			os.Exit(1)
+			return
+		}
+		defer mongoDatabase.Disconnect(context.Background())
+
+		report, err := database.NewMongoDatabase(mongoDatabase).
+			ReconcileIndexes(context.Background(), mongo.ReconcileOptions{DropUnknown: cfg.Mongo.DropUnknownIndexes, DryRun: dryRun})
+
+		fmt.Println("  collection       index            action     reason")
+		for _, change := range report {
+			fmt.Printf("  %-16s %-16s %-10s %s\n", change.Collection, change.Name, change.Action, change.Reason)
+		}
+
+		if err != nil {
+			logger.Error("error reconciling the indexes", "error", err)
+			mongoDatabase.Disconnect(context.Background())
+			os.Exit(1)
+		}
+	}
+}
@@ -3,22 +3,29 @@ package main
 import (
 	"context"
 	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/go-chi/chi"
 	"go.uber.org/zap"
 	"net/http"
 	"os"
 	"os/signal"
+	"sheazuzu/common/src/cache"
 	"sheazuzu/common/src/cli"
+	commondb "sheazuzu/common/src/database"
 	verrors "sheazuzu/common/src/errors"
 	"sheazuzu/common/src/logging"
 	"sheazuzu/common/src/metrics"
 	"sheazuzu/common/src/mongo"
+	"sheazuzu/common/src/pagination"
 	"sheazuzu/common/src/swagger"
 	"sheazuzu/common/src/tracing"
+	"sheazuzu/common/src/versioning"
 	"sheazuzu/sheazuzu/src/configuration"
 	"sheazuzu/sheazuzu/src/controller"
 	"sheazuzu/sheazuzu/src/database"
 	"sheazuzu/sheazuzu/src/generated/sheazuzu"
+	"sheazuzu/sheazuzu/src/generated/sheazuzuv2"
+	"sheazuzu/sheazuzu/src/outbox"
 	"sheazuzu/sheazuzu/src/repository"
 	"sheazuzu/sheazuzu/src/service"
 	"sync"
@@ -41,6 +48,20 @@ func main() {
 		Flags:    config.SetupFlags("sheazuzu"),
 		Validate: config.Validate,
 		Run:      Run(config),
+		SubCommands: []cli.Command{
+			{
+				Name:     "backtest",
+				Usage:    "Reports the Brier score and calibration of the match predictions against all played matches",
+				Flags:    config.SetupFlags("sheazuzu"),
+				Validate: config.Validate,
+				Run:      Backtest(config),
+			},
+			migrateCommand(config),
+			indexesCommand(config),
+			backupCommand(config),
+			restoreCommand(config),
+			backfillCommand(config),
+		},
 	}
 
 	app.Execute(os.Args[1:]...)
@@ -54,48 +75,185 @@ func Run(cfg *configuration.Configuration) func(cmd *cli.Command, args ...string
 
 		handleSigterm(logger)
 
-		// setup MySQL connection
-		db := database.InitDB(cfg.Database.GetDatabaseConn())
-		db.LogMode(true) //gorm log model
-		defer db.Close()
+		sheazuzuRepo, closeRepository, err := provideRepository(cfg, logger)
+		if err != nil {
+			logger.Error("error setting up the storage backend", "backend", cfg.Storage.Backend, "error", err)
+			os.Exit(1)
+			return
+		}
+		defer closeRepository()
 
-		var mongoRepository *database.MongoDatabase
-		if cfg.Mongo.URI != "" {
-			// connect to MongoDB client
-			mongoDatabase, err := mongo.NewMongoDatabase(&cfg.Mongo, logger)
+		// the outbox relay mirrors the matches written to MySQL to MongoDB
+		var relay *outbox.Relay
+		if cfg.Outbox.Enabled {
+			mongoRepository, disconnect, err := connectMongo(cfg, logger)
 			if err != nil {
-				logger.Error("error creating MongoDB client", "error", err)
+				logger.Error("error connecting to MongoDB for the outbox relay", "error", err)
 				os.Exit(1)
 				return
 			}
+			defer disconnect()
 
-			err = mongoDatabase.Connect(context.Background())
-			if err != nil {
-				logger.Error("error connecting to MongoDB", "error", err)
-				os.Exit(1)
-				return
-			}
+			relay = outbox.NewRelay(sheazuzuRepo.(*repository.MySQLRepository).DB, mongoRepository, cfg.Outbox, logger)
+			go relay.Run(context.Background())
+		}
+
+		cachedRepo, closeCache, err := provideCache(cfg, sheazuzuRepo, logger)
+		if err != nil {
+			logger.Error("error setting up the cache", "backend", cfg.Cache.Backend, "error", err)
+			os.Exit(1)
+			return
+		}
+		defer closeCache()
+
+		sheazuzuSerivce := service.ProvideSheazuzuService(cachedRepo, cfg.Prediction, logger)
+
+		swaggerDoc, err := sheazuzu.GetSwagger()
+		if err != nil {
+			logger.Error("error loading the API specification", "error", err)
+			os.Exit(1)
+			return
+		}
+
+		swaggerDocV2, err := sheazuzuv2.GetSwagger()
+		if err != nil {
+			logger.Error("error loading the API v2 specification", "error", err)
+			os.Exit(1)
+			return
+		}
 
-			mongoRepository = database.NewMongoDatabase(mongoDatabase)
+		paginator, err := pagination.NewPaginator(cfg.Pagination)
+		if err != nil {
+			logger.Error("error creating the paginator", "error", err)
+			os.Exit(1)
+			return
+		}
+		if cfg.Pagination.CursorSecret == "" {
+			logger.Warn("no pagination cursor secret configured, the cursors become invalid with a restart")
 		}
 
-		sheazuzuRepo := repository.ProvideSheazuzuRepository(db, mongoRepository, logger)
-		sheazuzuSerivce := service.ProvideSheazuzuService(sheazuzuRepo, logger)
-		sheazuzuApi := controller.ProvideSheazuzuAPI(sheazuzuSerivce, logger)
+		// both API versions share the service layer
+		sheazuzuApi := controller.ProvideSheazuzuAPI(sheazuzuSerivce, swaggerDoc, paginator, logger)
+		sheazuzuApiV2 := controller.ProvideSheazuzuAPIV2(sheazuzuSerivce, swaggerDocV2, paginator, logger)
+
+		middleWareChain := func(swaggerDoc *openapi3.Swagger) func(endpoint, operationId string) chi.Middlewares {
+			return func(endpoint, operationId string) chi.Middlewares {
+				chain, err := getMiddleWareChain(endpoint, operationId, swaggerDoc, cfg.Swagger, logger)
+				if err != nil {
+					logger.Panicf("Could not create middleware chain for %s: %s", operationId, err)
+				}
+				return chain
+			}
+		}
 
+		v1Chain := middleWareChain(swaggerDoc)
 		serverWithMiddleware := sheazuzu.NewServerWithMiddleware(sheazuzuApi)
-		serverWithMiddleware.GetMatchDataByIdUsingGETMiddlewares = getMiddleWareChain("machineByIdUsingGET", logger)
-		serverWithMiddleware.AllMatchDataUsingGETMiddlewares = getMiddleWareChain("allMachinesUsingGET", logger)
-		serverWithMiddleware.UploadMatchDataUsingPOSTMiddlewares = getMiddleWareChain("uploadMatchDataUsingPOST", logger)
+		serverWithMiddleware.GetMatchDataByIdUsingGETMiddlewares = v1Chain("machineByIdUsingGET", "getMatchDataByIdUsingGET")
+		serverWithMiddleware.AllMatchDataUsingGETMiddlewares = v1Chain("allMachinesUsingGET", "allMatchDataUsingGET")
+		serverWithMiddleware.UploadMatchDataUsingPOSTMiddlewares = v1Chain("uploadMatchDataUsingPOST", "uploadMatchDataUsingPOST")
+		serverWithMiddleware.GetStatisticsUsingGETMiddlewares = v1Chain("getStatisticsUsingGET", "getStatisticsUsingGET")
+		serverWithMiddleware.GetPredictionByIdUsingGETMiddlewares = v1Chain("getPredictionByIdUsingGET", "getPredictionByIdUsingGET")
+		serverWithMiddleware.UpsertMatchDataBatchUsingPOSTMiddlewares = v1Chain("upsertMatchDataBatchUsingPOST", "upsertMatchDataBatchUsingPOST")
+
+		v2Chain := middleWareChain(swaggerDocV2)
+		serverV2WithMiddleware := sheazuzuv2.NewServerWithMiddleware(sheazuzuApiV2)
+		serverV2WithMiddleware.ListMatchesMiddlewares = v2Chain("v2_listMatches", "listMatches")
+		serverV2WithMiddleware.CreateMatchMiddlewares = v2Chain("v2_createMatch", "createMatch")
+		serverV2WithMiddleware.GetMatchMiddlewares = v2Chain("v2_getMatch", "getMatch")
+		serverV2WithMiddleware.GetMatchPredictionMiddlewares = v2Chain("v2_getMatchPrediction", "getMatchPrediction")
+		serverV2WithMiddleware.UpsertMatchesBatchMiddlewares = v2Chain("v2_upsertMatchesBatch", "upsertMatchesBatch")
+		serverV2WithMiddleware.GetStatisticsMiddlewares = v2Chain("v2_getStatistics", "getStatistics")
 
 		contextPath := cfg.Server.GetContextPath()
 
+		basePath := ""
+		if contextPath != "" {
+			basePath = "/" + contextPath
+		}
+
+		v1Lifecycle := cfg.APIV1
+		if v1Lifecycle.Successor == "" {
+			v1Lifecycle.Successor = basePath + "/v2"
+		}
+		deprecationHandler := versioning.DeprecationHandler(v1Lifecycle)
+
 		router := chi.NewRouter()
 
+		if cfg.Metrics.Enabled {
+			err = metrics.RegisterHandler(cfg.Metrics.ServiceName, router)
+			if err != nil {
+				logger.Error("error registering the metrics handler", "error", err)
+				os.Exit(1)
+				return
+			}
+
+			if sqlRepository, ok := sheazuzuRepo.(*repository.MySQLRepository); ok {
+				err = metrics.ReportDBStats(context.Background(), sqlRepository.DB.DB())
+				if err == nil {
+					err = metrics.RegisterQueryViews()
+				}
+				if err != nil {
+					logger.Error("error registering the database metrics", "error", err)
+					os.Exit(1)
+					return
+				}
+			}
+
+			if cfg.Storage.Backend == repository.BackendMongo {
+				err = metrics.RegisterTransactionViews()
+				if err != nil {
+					logger.Error("error registering the transaction metrics", "error", err)
+					os.Exit(1)
+					return
+				}
+			}
+
+			if cfg.Storage.Backend == repository.BackendMongo || cfg.Outbox.Enabled {
+				err = metrics.RegisterMongoPoolViews()
+				if err != nil {
+					logger.Error("error registering the mongo pool metrics", "error", err)
+					os.Exit(1)
+					return
+				}
+			}
+
+			if cfg.Cache.Backend != cache.BackendNone {
+				err = metrics.RegisterCacheViews()
+				if err != nil {
+					logger.Error("error registering the cache metrics", "error", err)
+					os.Exit(1)
+					return
+				}
+			}
+		}
+
 		router.Route("/"+contextPath, func(r chi.Router) {
-			swaggerDoc, _ := sheazuzu.GetSwagger()
-			swagger.RegisterSwaggerHandlers(r, swaggerDoc, contextPath)
-			sheazuzu.HandlerFromMux(serverWithMiddleware, r)
+			// the reads of a client go to the primary for the read your writes window after its writes
+			r.Use(commondb.SessionHandler(cfg.Database.ReadYourWritesWindow))
+
+			swagger.RegisterSpecHandlers(r, contextPath,
+				swagger.Spec{Name: "v2", Path: "/v2", Doc: swaggerDocV2},
+				swagger.Spec{Name: "v1 (deprecated)", Path: "/v1", Doc: swaggerDoc},
+			)
+
+			r.Route("/v2", func(r chi.Router) {
+				sheazuzuv2.HandlerFromMux(serverV2WithMiddleware, r)
+			})
+
+			r.Route("/v1", func(r chi.Router) {
+				r.Use(deprecationHandler)
+				sheazuzu.HandlerFromMux(serverWithMiddleware, r)
+			})
+
+			if relay != nil {
+				r.Get("/outbox/status", relay.StatusHandler)
+			}
+
+			// v1 stays available without the version prefix for the existing clients
+			r.Group(func(r chi.Router) {
+				r.Use(deprecationHandler)
+				sheazuzu.HandlerFromMux(serverWithMiddleware, r)
+			})
 		})
 
 		logger.Infof("Starting HTTP service at %v", cfg.Server.Port)
@@ -116,18 +274,163 @@ func Run(cfg *configuration.Configuration) func(cmd *cli.Command, args ...string
 	}
 }
 
-func getMiddleWareChain(endpoint string, logger *zap.SugaredLogger) chi.Middlewares {
+func Backtest(cfg *configuration.Configuration) func(cmd *cli.Command, args ...string) {
+	return func(cmd *cli.Command, args ...string) {
+
+		logger := logging.GetLogger(cfg.Logging.Level, cfg.Logging.Format).
+			With("version", cmd.Version)
+
+		sheazuzuRepo, closeRepository, err := provideRepository(cfg, logger)
+		if err != nil {
+			logger.Error("error setting up the storage backend", "backend", cfg.Storage.Backend, "error", err)
+			os.Exit(1)
+			return
+		}
+		defer closeRepository()
+
+		sheazuzuSerivce := service.ProvideSheazuzuService(sheazuzuRepo, cfg.Prediction, logger)
+
+		report, err := sheazuzuSerivce.Backtest(context.Background())
+		if err != nil {
+			logger.Error("error running the backtest", "error", err)
+			os.Exit(1)
+			return
+		}
+
+		fmt.Printf("Matches:     %d\n", report.Matches)
+		fmt.Printf("Brier score: %.4f\n", report.BrierScore)
+		fmt.Printf("Log loss:    %.4f\n", report.LogLoss)
+		fmt.Println()
+		fmt.Println("CALIBRATION")
+		fmt.Println("  predicted     count   mean prob.   observed")
+		for _, bin := range report.Calibration {
+			fmt.Printf("  %.1f - %.1f %9d %12.3f %10.3f\n", bin.Lower, bin.Upper, bin.Predictions, bin.MeanProbability, bin.ObservedFrequency)
+		}
+	}
+}
+
+// provideRepository connects to the configured storage backend. The returned function closes the connection.
+func provideRepository(cfg *configuration.Configuration, logger *zap.SugaredLogger) (repository.Repository, func(), error) {
+
+	switch cfg.Storage.Backend {
+	case repository.BackendMemory:
+		logger.Warn("storing the matches in memory, they are lost with a restart")
+		return repository.ProvideMemoryRepository(logger), func() {}, nil
+
+	case repository.BackendMongo:
+		mongoRepository, disconnect, err := connectMongo(cfg, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return repository.ProvideMongoRepository(mongoRepository, logger), disconnect, nil
+
+	default:
+		// setup the SQL connection, MySQL, PostgreSQL or SQLite
+		db, err := database.Connect(&cfg.Database, logger)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// the service must not run against a schema it does not know, an in-memory database is always empty
+		migrator, err := database.NewMigrator(db, logger)
+		if err == nil && cfg.Database.InMemory() {
+			_, err = migrator.Up(context.Background(), 0)
+		}
+		if err == nil {
+			err = migrator.Check(context.Background())
+		}
+		if err != nil {
+			db.Close()
+			return nil, nil, fmt.Errorf("%s, run 'migrate up' first", err)
+		}
+
+		// the reads are spread over the read replicas, if there are any
+		replicas, err := database.ConnectReplicas(&cfg.Database, db, logger)
+		if err != nil {
+			db.Close()
+			return nil, nil, err
+		}
+
+		mysqlRepository := repository.ProvideMySQLRepository(db, cfg.Outbox.Enabled, logger)
+		if replicas == nil {
+			return mysqlRepository, func() {
+				db.Close()
+			}, nil
+		}
+
+		ctx, stopHealthChecks := context.WithCancel(context.Background())
+		go replicas.Run(ctx, cfg.Database.ReplicaHealthInterval)
+
+		return mysqlRepository.WithReplicas(replicas), func() {
+			stopHealthChecks()
+			replicas.Close()
+			db.Close()
+		}, nil
+	}
+}
+
+// provideCache puts the configured cache in front of the repository. The returned function closes the cache.
+func provideCache(cfg *configuration.Configuration, sheazuzuRepo repository.Repository, logger *zap.SugaredLogger) (repository.Repository, func(), error) {
+
+	matchCache, err := cache.New(cfg.Cache)
+	if err != nil {
+		return nil, nil, err
+	}
+	if matchCache == nil {
+		return sheazuzuRepo, func() {}, nil
+	}
+
+	return repository.ProvideCachedRepository(sheazuzuRepo, matchCache, cfg.Cache.LoadTimeout, logger), func() {
+		matchCache.Close()
+	}, nil
+}
+
+// connectMongo connects to MongoDB and reconciles the indexes. The returned function disconnects.
+func connectMongo(cfg *configuration.Configuration, logger *zap.SugaredLogger) (*database.MongoDatabase, func(), error) {
+
+	// connect to MongoDB client
+	mongoDatabase, err := mongo.NewMongoDatabase(&cfg.Mongo, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = mongoDatabase.Connect(context.Background())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mongoRepository := database.NewMongoDatabase(mongoDatabase)
+
+	_, err = mongoRepository.ReconcileIndexes(context.Background(), mongo.ReconcileOptions{DropUnknown: cfg.Mongo.DropUnknownIndexes})
+	if err != nil {
+		mongoDatabase.Disconnect(context.Background())
+		return nil, nil, err
+	}
+
+	return mongoRepository, func() {
+		mongoDatabase.Disconnect(context.Background())
+	}, nil
+}
+
+func getMiddleWareChain(endpoint, operationId string, swaggerDoc *openapi3.Swagger, swaggerConfig swagger.Config, logger *zap.SugaredLogger) (chi.Middlewares, error) {
+
+	validationHandler, err := swagger.ValidationHandler(swaggerDoc, operationId, swaggerConfig, logger)
+	if err != nil {
+		return nil, err
+	}
+
 	return chi.Chain(
-		jsonContentTypeHeaderHandler,
+		corsHeaderHandler,
 		metrics.GetMetricsRecordingHandlerForEndpoint(endpoint),
 		tracing.TraceHandler(logger, endpoint),
-	)
+		validationHandler,
+	), nil
 }
 
-var jsonContentTypeHeaderHandler = func(next http.Handler) http.Handler {
+// the Content-Type is set by the render package, depending on the Accept header of the request
+var corsHeaderHandler = func(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		fmt.Println("attaching header")
-		w.Header().Add("Content-Type", "application/json")
 		// for local frontend accessing the url with ip address
 		w.Header().Add("Access-Control-Allow-Origin", "*")
 
@@ -1,9 +1,11 @@
 package mapper
 
 import (
+	"sheazuzu/common/src/fieldset"
 	"sheazuzu/common/src/utils"
 	"sheazuzu/sheazuzu/src/entity"
 	"sheazuzu/sheazuzu/src/generated/sheazuzu"
+	"sort"
 )
 
 func BoToMatchData(data sheazuzu.MatchData) entity.MatchData {
@@ -17,3 +19,51 @@ func BoToMatchData(data sheazuzu.MatchData) entity.MatchData {
 		Result:                utils.ToString(data.Result),
 	}
 }
+
+// the json names of the MatchData and AdditionalInformation properties mapped to the entity fields
+var (
+	matchDataFields = map[string]string{
+		"id":                      "Id",
+		"date":                    "Date",
+		"home_team":               "HomeTeam",
+		"away_team":               "AwayTeam",
+		"match_type":              "MatchType",
+		"result":                  "Result",
+		"additional_informations": "AdditionalInformation",
+	}
+	additionalInformationFields = map[string]string{
+		"additional":  "Additional",
+		"information": "Information",
+	}
+)
+
+// FieldsToProjection maps the requested MatchData properties to the entity fields which have to be loaded
+func FieldsToProjection(fields []string) entity.Projection {
+
+	selection := fieldset.Select(fields)
+	if selection == nil {
+		return entity.Projection{}
+	}
+
+	projection := entity.Projection{}
+	for name, field := range matchDataFields {
+		if selection.Has(name) {
+			projection.Fields = append(projection.Fields, field)
+		}
+	}
+
+	if selection.Has("additional_informations") {
+		nested := selection.Nested("additional_informations")
+		for name, field := range additionalInformationFields {
+			if nested.Has(name) {
+				projection.AdditionalInformation = append(projection.AdditionalInformation, field)
+			}
+		}
+	}
+
+	// the maps are iterated in random order, sorting keeps the generated queries stable
+	sort.Strings(projection.Fields)
+	sort.Strings(projection.AdditionalInformation)
+
+	return projection
+}
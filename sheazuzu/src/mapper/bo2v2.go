@@ -0,0 +1,134 @@
+package mapper
+
+import (
+	"sheazuzu/sheazuzu/src/generated/sheazuzu"
+	"sheazuzu/sheazuzu/src/generated/sheazuzuv2"
+)
+
+// The payload schemas of the v2 API are the schemas of v1, only the envelopes and paths changed. The services work
+// with the v1 types, so the v2 types are mapped here. Types without nested schemas have the same fields in both
+// versions and are converted directly.
+
+func MatchDataToV2(data sheazuzu.MatchData) sheazuzuv2.MatchData {
+	return sheazuzuv2.MatchData{
+		AdditionalInformations: (*sheazuzuv2.AdditionalInformation)(data.AdditionalInformations),
+		AwayTeam:               data.AwayTeam,
+		Date:                   data.Date,
+		HomeTeam:               data.HomeTeam,
+		Id:                     data.Id,
+		MatchType:              data.MatchType,
+		Result:                 data.Result,
+	}
+}
+
+func MatchDataListToV2(data []sheazuzu.MatchData) []sheazuzuv2.MatchData {
+	result := make([]sheazuzuv2.MatchData, 0, len(data))
+	for _, matchData := range data {
+		result = append(result, MatchDataToV2(matchData))
+	}
+	return result
+}
+
+func V2ToMatchData(data sheazuzuv2.MatchData) sheazuzu.MatchData {
+	return sheazuzu.MatchData{
+		AdditionalInformations: (*sheazuzu.AdditionalInformation)(data.AdditionalInformations),
+		AwayTeam:               data.AwayTeam,
+		Date:                   data.Date,
+		HomeTeam:               data.HomeTeam,
+		Id:                     data.Id,
+		MatchType:              data.MatchType,
+		Result:                 data.Result,
+	}
+}
+
+func V2ToMatchDataList(data []sheazuzuv2.MatchData) []sheazuzu.MatchData {
+	result := make([]sheazuzu.MatchData, 0, len(data))
+	for _, matchData := range data {
+		result = append(result, V2ToMatchData(matchData))
+	}
+	return result
+}
+
+func StatisticsToV2(data sheazuzu.Statistics) sheazuzuv2.Statistics {
+
+	result := sheazuzuv2.Statistics{
+		Overall:     (*sheazuzuv2.OutcomeDistribution)(data.Overall),
+		ByMatchType: outcomeDistributionsToV2(data.ByMatchType),
+		BySeason:    outcomeDistributionsToV2(data.BySeason),
+	}
+
+	if data.Scorelines != nil {
+		scorelines := make([]sheazuzuv2.ScorelineCount, 0, len(*data.Scorelines))
+		for _, scoreline := range *data.Scorelines {
+			scorelines = append(scorelines, sheazuzuv2.ScorelineCount(scoreline))
+		}
+		result.Scorelines = &scorelines
+	}
+
+	if data.CleanSheets != nil {
+		cleanSheets := make([]sheazuzuv2.CleanSheetCount, 0, len(*data.CleanSheets))
+		for _, cleanSheet := range *data.CleanSheets {
+			cleanSheets = append(cleanSheets, sheazuzuv2.CleanSheetCount(cleanSheet))
+		}
+		result.CleanSheets = &cleanSheets
+	}
+
+	return result
+}
+
+func outcomeDistributionsToV2(data *[]sheazuzu.OutcomeDistribution) *[]sheazuzuv2.OutcomeDistribution {
+
+	if data == nil {
+		return nil
+	}
+
+	result := make([]sheazuzuv2.OutcomeDistribution, 0, len(*data))
+	for _, distribution := range *data {
+		result = append(result, sheazuzuv2.OutcomeDistribution(distribution))
+	}
+
+	return &result
+}
+
+func PredictionToV2(data sheazuzu.Prediction) sheazuzuv2.Prediction {
+
+	result := sheazuzuv2.Prediction{
+		MatchId:           data.MatchId,
+		HomeTeam:          data.HomeTeam,
+		AwayTeam:          data.AwayTeam,
+		ExpectedHomeGoals: data.ExpectedHomeGoals,
+		ExpectedAwayGoals: data.ExpectedAwayGoals,
+		HomeWin:           data.HomeWin,
+		Draw:              data.Draw,
+		AwayWin:           data.AwayWin,
+	}
+
+	if data.Scorelines != nil {
+		scorelines := make([]sheazuzuv2.ScorelineProbability, 0, len(*data.Scorelines))
+		for _, scoreline := range *data.Scorelines {
+			scorelines = append(scorelines, sheazuzuv2.ScorelineProbability(scoreline))
+		}
+		result.Scorelines = &scorelines
+	}
+
+	return result
+}
+
+func BatchResponseToV2(data sheazuzu.BatchResponse) sheazuzuv2.BatchResponse {
+
+	result := sheazuzuv2.BatchResponse{
+		Mode:      data.Mode,
+		Succeeded: data.Succeeded,
+		Failed:    data.Failed,
+	}
+
+	if data.Results != nil {
+		results := make([]sheazuzuv2.BatchItemResult, 0, len(*data.Results))
+		for _, item := range *data.Results {
+			results = append(results, sheazuzuv2.BatchItemResult(item))
+		}
+		result.Results = &results
+	}
+
+	return result
+}
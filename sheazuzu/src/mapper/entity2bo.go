@@ -1,14 +1,16 @@
 package mapper
 
 import (
+	"fmt"
 	"sheazuzu/common/src/utils"
 	"sheazuzu/sheazuzu/src/entity"
 	"sheazuzu/sheazuzu/src/generated/sheazuzu"
+	"sheazuzu/sheazuzu/src/prediction"
 )
 
 func MatchDataToBo(data entity.MatchData) sheazuzu.MatchData {
 	return sheazuzu.MatchData{
-		AdditionalInformations: nil,
+		AdditionalInformations: additionalInformationToBo(data.AdditionalInformation),
 		AwayTeam:               utils.ToStringPtr(data.AwayTeam),
 		Date:                   utils.ToStringPtr(data.Date),
 		HomeTeam:               utils.ToStringPtr(data.HomeTeam),
@@ -17,3 +19,104 @@ func MatchDataToBo(data entity.MatchData) sheazuzu.MatchData {
 		Result:                 utils.ToStringPtr(data.Result),
 	}
 }
+
+// additionalInformationToBo returns nil, if the match has no additional information or it was not loaded
+func additionalInformationToBo(data entity.AdditionalInformation) *sheazuzu.AdditionalInformation {
+
+	if data.ID == 0 {
+		return nil
+	}
+
+	return &sheazuzu.AdditionalInformation{
+		Additional:  utils.ToStringPtr(data.Additional),
+		Information: utils.ToStringPtr(data.Information),
+	}
+}
+
+func StatisticsToBo(data entity.Statistics) sheazuzu.Statistics {
+
+	scorelines := make([]sheazuzu.ScorelineCount, 0, len(data.Scorelines))
+	for _, scoreline := range data.Scorelines {
+		scorelines = append(scorelines, sheazuzu.ScorelineCount{
+			Scoreline: utils.ToStringPtr(scoreline.Scoreline),
+			Count:     utils.ToIntPtr(scoreline.Count),
+		})
+	}
+
+	cleanSheets := make([]sheazuzu.CleanSheetCount, 0, len(data.CleanSheets))
+	for _, cleanSheet := range data.CleanSheets {
+		cleanSheets = append(cleanSheets, sheazuzu.CleanSheetCount{
+			Team:        utils.ToStringPtr(cleanSheet.Team),
+			CleanSheets: utils.ToIntPtr(cleanSheet.CleanSheets),
+		})
+	}
+
+	overall := outcomeCountToBo(data.Overall)
+	overall.Key = nil
+	byMatchType := outcomeCountsToBo(data.ByMatchType)
+	bySeason := outcomeCountsToBo(data.BySeason)
+
+	return sheazuzu.Statistics{
+		Overall:     &overall,
+		Scorelines:  &scorelines,
+		CleanSheets: &cleanSheets,
+		ByMatchType: &byMatchType,
+		BySeason:    &bySeason,
+	}
+}
+
+func outcomeCountToBo(data entity.OutcomeCount) sheazuzu.OutcomeDistribution {
+	return sheazuzu.OutcomeDistribution{
+		Key:          utils.ToStringPtr(data.Name),
+		Matches:      utils.ToIntPtr(data.Matches),
+		HomeWinRatio: utils.ToFloat64Ptr(ratio(data.HomeWins, data.Matches)),
+		DrawRatio:    utils.ToFloat64Ptr(ratio(data.Draws, data.Matches)),
+		AwayWinRatio: utils.ToFloat64Ptr(ratio(data.AwayWins, data.Matches)),
+		AverageGoals: utils.ToFloat64Ptr(ratio(data.Goals, data.Matches)),
+	}
+}
+
+func outcomeCountsToBo(data []entity.OutcomeCount) []sheazuzu.OutcomeDistribution {
+	distributions := make([]sheazuzu.OutcomeDistribution, 0, len(data))
+	for _, count := range data {
+		distributions = append(distributions, outcomeCountToBo(count))
+	}
+	return distributions
+}
+
+// ratio returns 0 instead of NaN if there is nothing to divide by
+func ratio(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total)
+}
+
+// number of the most likely scorelines returned with a prediction
+const predictedScorelines = 10
+
+func PredictionToBo(data entity.MatchData, result prediction.Prediction) sheazuzu.Prediction {
+
+	scorelines := make([]sheazuzu.ScorelineProbability, 0, predictedScorelines)
+	for i, scoreline := range result.Scorelines {
+		if i >= predictedScorelines {
+			break
+		}
+		scorelines = append(scorelines, sheazuzu.ScorelineProbability{
+			Scoreline:   utils.ToStringPtr(fmt.Sprintf("%d:%d", scoreline.HomeGoals, scoreline.AwayGoals)),
+			Probability: utils.ToFloat64Ptr(scoreline.Probability),
+		})
+	}
+
+	return sheazuzu.Prediction{
+		MatchId:           utils.ToIntPtr(data.Id),
+		HomeTeam:          utils.ToStringPtr(data.HomeTeam),
+		AwayTeam:          utils.ToStringPtr(data.AwayTeam),
+		ExpectedHomeGoals: utils.ToFloat64Ptr(result.ExpectedHomeGoals),
+		ExpectedAwayGoals: utils.ToFloat64Ptr(result.ExpectedAwayGoals),
+		HomeWin:           utils.ToFloat64Ptr(result.HomeWin),
+		Draw:              utils.ToFloat64Ptr(result.Draw),
+		AwayWin:           utils.ToFloat64Ptr(result.AwayWin),
+		Scorelines:        &scorelines,
+	}
+}
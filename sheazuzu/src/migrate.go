@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sheazuzu/common/src/cli"
+	commondb "sheazuzu/common/src/database"
+	"sheazuzu/common/src/logging"
+	"sheazuzu/common/src/migrate"
+	"sheazuzu/sheazuzu/src/configuration"
+	"sheazuzu/sheazuzu/src/database"
+	"strconv"
+	"time"
+)
+
+// the directory of the migrations in the repository, they are embedded into the binary by the database package
+const migrationsDir = "sheazuzu/src/database/migrations"
+
+// every driver has its own migrations with the same versions in a subdirectory of the migrations
+var migrationDrivers = []string{commondb.DriverMySQL, commondb.DriverPostgres, commondb.DriverSQLite}
+
+func migrateCommand(config *configuration.Configuration) cli.Command {
+
+	validate := func() bool {
+		return config.Validate() && config.Database.IsValid()
+	}
+
+	createFlags := flag.NewFlagSet("", flag.ContinueOnError)
+	dir := createFlags.String("migrate.dir", migrationsDir, "the directory of the migrations")
+
+	return cli.Command{
+		Name:  "migrate",
+		Usage: "Manages the versioned schema migrations of the SQL database",
+		SubCommands: []cli.Command{
+			{
+				Name:     "up",
+				Usage:    "Applies the pending migrations, all or the number given as argument",
+				Flags:    config.SetupFlags("sheazuzu"),
+				Validate: validate,
+				Run:      MigrateUp(config),
+			},
+			{
+				Name:     "down",
+				Usage:    "Reverts the latest migration or the number of migrations given as argument",
+				Flags:    config.SetupFlags("sheazuzu"),
+				Validate: validate,
+				Run:      MigrateDown(config),
+			},
+			{
+				Name:     "status",
+				Usage:    "Lists all migrations and whether they are applied",
+				Flags:    config.SetupFlags("sheazuzu"),
+				Validate: validate,
+				Run:      MigrateStatus(config),
+			},
+			{
+				Name:  "create",
+				Usage: "Creates the empty up and down files of a new migration with the name given as argument for every driver",
+				Flags: createFlags,
+				Run: func(cmd *cli.Command, args ...string) {
+					now := time.Now().UTC()
+					for _, driver := range migrationDrivers {
+						up, down, err := migrate.Create(filepath.Join(*dir, driver), cmd.Flags.Arg(0), now)
+						if err != nil {
+							fmt.Printf("error creating the migration: %s\n", err)
+							os.Exit(1)
+						}
+						fmt.Printf("created %s\ncreated %s\n", up, down)
+					}
+				},
+			},
+		},
+	}
+}
+
+func MigrateUp(cfg *configuration.Configuration) func(cmd *cli.Command, args ...string) {
+	return withMigrator(cfg, func(cmd *cli.Command, migrator *migrate.Migrator) error {
+
+		steps, err := steps(cmd, 0)
+		if err != nil {
+			return err
+		}
+
+		applied, err := migrator.Up(context.Background(), steps)
+		for _, migration := range applied {
+			fmt.Printf("applied  %d_%s\n", migration.Version, migration.Name)
+		}
+		if err == nil && len(applied) == 0 {
+			fmt.Println("the schema is up to date")
+		}
+		return err
+	})
+}
+
+func MigrateDown(cfg *configuration.Configuration) func(cmd *cli.Command, args ...string) {
+	return withMigrator(cfg, func(cmd *cli.Command, migrator *migrate.Migrator) error {
+
+		steps, err := steps(cmd, 1)
+		if err != nil {
+			return err
+		}
+
+		reverted, err := migrator.Down(context.Background(), steps)
+		for _, migration := range reverted {
+			fmt.Printf("reverted %d_%s\n", migration.Version, migration.Name)
+		}
+		return err
+	})
+}
+
+func MigrateStatus(cfg *configuration.Configuration) func(cmd *cli.Command, args ...string) {
+	return withMigrator(cfg, func(cmd *cli.Command, migrator *migrate.Migrator) error {
+
+		status, err := migrator.Status(context.Background())
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("  version          state     applied at             name")
+		for _, s := range status {
+			state, appliedAt := "pending", ""
+			if s.Applied {
+				state = "applied"
+			}
+			if s.Dirty {
+				state = "dirty"
+			}
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.UTC().Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("  %-16d %-9s %-22s %s\n", s.Version, state, appliedAt, s.Name)
+		}
+
+		return migrator.Check(context.Background())
+	})
+}
+
+// withMigrator connects to the database and runs f with the migrator of the schema, an error exits with 1
+func withMigrator(cfg *configuration.Configuration, f func(cmd *cli.Command, migrator *migrate.Migrator) error) func(cmd *cli.Command, args ...string) {
+	return func(cmd *cli.Command, args ...string) {
+
+		logger := logging.GetLogger(cfg.Logging.Level, cfg.Logging.Format).
+			With("version", cmd.Version)
+
+		db, err := database.Connect(&cfg.Database, logger)
+		if err != nil {
+			logger.Error("error connecting to the database", "error", err)
+			os.Exit(1)
+			return
+		}
+		defer db.Close()
+
+		migrator, err := database.NewMigrator(db, logger)
+		if err == nil {
+			err = f(cmd, migrator)
+		}
+		if err != nil {
+			logger.Error("error migrating the schema", "error", err)
+			db.Close()
+			os.Exit(1)
+		}
+	}
+}
+
+// steps reads the optional number of migrations from the first argument
+func steps(cmd *cli.Command, defaultSteps int) (int, error) {
+
+	if cmd.Flags.NArg() == 0 {
+		return defaultSteps, nil
+	}
+
+	steps, err := strconv.Atoi(cmd.Flags.Arg(0))
+	if err != nil || steps < 1 {
+		return 0, fmt.Errorf("the number of migrations must be a positive number, got '%s'", cmd.Flags.Arg(0))
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,47 @@
+package outbox
+
+import (
+	"flag"
+	"fmt"
+	"time"
+)
+
+// Config contains the parameters of the outbox relay, which mirrors the matches written to MySQL to MongoDB.
+// A failing entry is retried after PollInterval, the delay doubles with every attempt up to MaxBackoff.
+type Config struct {
+	Enabled      bool
+	PollInterval time.Duration
+	BatchSize    int
+	MaxBackoff   time.Duration
+}
+
+func BindConfig(config *Config, fs *flag.FlagSet) {
+	fs.BoolVar(&config.Enabled, "outbox.enabled", false, "mirror the matches written to MySQL to MongoDB with a transactional outbox")
+	fs.DurationVar(&config.PollInterval, "outbox.pollInterval", time.Second, "the interval in which the outbox is polled for new entries")
+	fs.IntVar(&config.BatchSize, "outbox.batchSize", 100, "the maximum number of outbox entries applied per poll")
+	fs.DurationVar(&config.MaxBackoff, "outbox.maxBackoff", 5*time.Minute, "the maximum delay before a failed outbox entry is retried")
+}
+
+func (config *Config) IsValid() bool {
+
+	if !config.Enabled {
+		return true
+	}
+
+	if config.PollInterval <= 0 {
+		fmt.Println("the outbox poll interval must be positive")
+		return false
+	}
+
+	if config.BatchSize < 1 {
+		fmt.Println("the outbox batch size must be at least 1")
+		return false
+	}
+
+	if config.MaxBackoff < config.PollInterval {
+		fmt.Println("the outbox max backoff must not be shorter than the poll interval")
+		return false
+	}
+
+	return true
+}
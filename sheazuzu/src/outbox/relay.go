@@ -0,0 +1,264 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/jinzhu/gorm"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.uber.org/zap"
+	"sheazuzu/sheazuzu/src/entity"
+	"sync"
+	"time"
+)
+
+var (
+	mRelayed    = stats.Int64("outbox_relayed", "The count of outbox entries applied to MongoDB", "")
+	relayedView = &view.View{
+		Name:        "outbox_relayed",
+		Measure:     mRelayed,
+		Description: "The count of outbox entries applied to MongoDB",
+		Aggregation: view.Count(),
+	}
+
+	mFailures    = stats.Int64("outbox_failures", "The count of failed attempts to apply an outbox entry", "")
+	failuresView = &view.View{
+		Name:        "outbox_failures",
+		Measure:     mFailures,
+		Description: "The count of failed attempts to apply an outbox entry",
+		Aggregation: view.Count(),
+	}
+
+	mPending    = stats.Int64("outbox_pending", "The number of outbox entries not applied yet", "")
+	pendingView = &view.View{
+		Name:        "outbox_pending",
+		Measure:     mPending,
+		Description: "The number of outbox entries not applied yet",
+		Aggregation: view.LastValue(),
+	}
+
+	mLag    = stats.Float64("outbox_lag_seconds", "The age of the oldest outbox entry not applied yet", "s")
+	lagView = &view.View{
+		Name:        "outbox_lag_seconds",
+		Measure:     mLag,
+		Description: "The age of the oldest outbox entry not applied yet",
+		Aggregation: view.LastValue(),
+	}
+)
+
+// target is the MongoDB database, to which the matches are mirrored
+type target interface {
+	Put(ctx context.Context, matchData entity.MatchData) error
+}
+
+// Relay applies the outbox entries to MongoDB. Only the oldest entry of a match is applied, so the writes of a match
+// are mirrored in their order. An entry is claimed with a row lock for the time it is applied and deleted in the same
+// transaction, so with several instances polling the same outbox every entry is applied by one instance at a time and
+// a later entry of the match only becomes the oldest, once the previous one is applied. A failed entry blocks the later
+// entries of its match until its retry succeeds.
+type Relay struct {
+	db     *gorm.DB
+	target target
+	config Config
+	logger *zap.SugaredLogger
+
+	mutex         sync.Mutex
+	lastRelayedAt *time.Time
+	lastError     string
+}
+
+func NewRelay(db *gorm.DB, target target, config Config, logger *zap.SugaredLogger) *Relay {
+	return &Relay{
+		db:     db,
+		target: target,
+		config: config,
+		logger: logger,
+	}
+}
+
+// Run polls the outbox until the context is done
+func (relay *Relay) Run(ctx context.Context) {
+
+	err := view.Register(relayedView, failuresView, pendingView, lagView)
+	if err != nil {
+		relay.logger.Errorw("error registering the outbox metric views", "error", err)
+	}
+
+	ticker := time.NewTicker(relay.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		// the next entries of a match become due as soon as the previous one is applied, so the outbox is drained
+		// before waiting for the next tick
+		for {
+			relayed, err := relay.RelayPending(ctx)
+			if err != nil {
+				relay.logger.Errorw("error relaying the outbox", "error", err)
+			}
+			if relayed == 0 || err != nil || ctx.Err() != nil {
+				break
+			}
+		}
+
+		relay.recordStatus(ctx)
+	}
+}
+
+// RelayPending applies the due head entries of all matches and returns the number of applied entries
+func (relay *Relay) RelayPending(ctx context.Context) (int, error) {
+
+	table := relay.db.NewScope(&entity.OutboxEntry{}).TableName()
+	heads := relay.db.Table(table).Select("MIN(id)").Group("match_id").SubQuery()
+
+	var entries []entity.OutboxEntry
+	err := relay.db.Where("id IN (?)", heads).Where("next_attempt_at <= ?", time.Now()).
+		Order("id").Limit(relay.config.BatchSize).Find(&entries).Error
+	if err != nil {
+		return 0, err
+	}
+
+	relayed := 0
+	for _, outboxEntry := range entries {
+		applied, err := relay.relay(ctx, outboxEntry.Id)
+		if err != nil {
+			return relayed, err
+		}
+		if applied {
+			relayed++
+		}
+	}
+
+	return relayed, nil
+}
+
+// relay claims the entry and applies it in one transaction. An entry, which another instance is applying, which was
+// applied already or which was rescheduled in the meantime, is skipped and false is returned.
+func (relay *Relay) relay(ctx context.Context, id uint) (bool, error) {
+
+	tx := relay.db.Begin()
+	if tx.Error != nil {
+		return false, tx.Error
+	}
+
+	outboxEntry, claimed, err := relay.claim(tx, id)
+	if err != nil || !claimed {
+		tx.Rollback()
+		return false, err
+	}
+
+	cause := relay.apply(ctx, tx, outboxEntry)
+	if cause != nil {
+		err = relay.retryLater(ctx, tx, outboxEntry, cause)
+		if err != nil {
+			tx.Rollback()
+			return false, err
+		}
+		return false, tx.Commit().Error
+	}
+
+	// if the commit fails, the entry is applied again, which is harmless, as it is still the oldest of its match
+	err = tx.Commit().Error
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	relay.mutex.Lock()
+	relay.lastRelayedAt = &now
+	relay.mutex.Unlock()
+
+	stats.Record(ctx, mRelayed.M(1))
+
+	return true, nil
+}
+
+// claim locks the due entry in the transaction. MySQL and PostgreSQL skip entries locked by other instances,
+// SQLite locks the whole database for a write transaction, so it needs no row locks.
+func (relay *Relay) claim(tx *gorm.DB, id uint) (entity.OutboxEntry, bool, error) {
+
+	query := tx.Where("id = ? AND next_attempt_at <= ?", id, time.Now())
+	if dialect := tx.Dialect().GetName(); dialect == "mysql" || dialect == "postgres" {
+		query = query.Set("gorm:query_option", "FOR UPDATE SKIP LOCKED")
+	}
+
+	var outboxEntry entity.OutboxEntry
+	result := query.First(&outboxEntry)
+	if result.RecordNotFound() {
+		return entity.OutboxEntry{}, false, nil
+	}
+	if result.Error != nil {
+		return entity.OutboxEntry{}, false, result.Error
+	}
+
+	return outboxEntry, true, nil
+}
+
+func (relay *Relay) apply(ctx context.Context, tx *gorm.DB, outboxEntry entity.OutboxEntry) error {
+
+	var matchData entity.MatchData
+	err := json.Unmarshal([]byte(outboxEntry.Payload), &matchData)
+	if err != nil {
+		return err
+	}
+
+	err = relay.target.Put(ctx, matchData)
+	if err != nil {
+		return err
+	}
+
+	return tx.Delete(&outboxEntry).Error
+}
+
+func (relay *Relay) retryLater(ctx context.Context, tx *gorm.DB, outboxEntry entity.OutboxEntry, cause error) error {
+
+	stats.Record(ctx, mFailures.M(1))
+	relay.logger.Warnw("error applying outbox entry, retrying later",
+		"id", outboxEntry.Id, "matchId", outboxEntry.MatchId, "attempts", outboxEntry.Attempts+1, "error", cause)
+
+	relay.mutex.Lock()
+	relay.lastError = cause.Error()
+	relay.mutex.Unlock()
+
+	attempts := outboxEntry.Attempts + 1
+	err := tx.Model(&outboxEntry).Updates(map[string]interface{}{
+		"attempts":        attempts,
+		"next_attempt_at": time.Now().Add(relay.backoff(attempts)),
+		"last_error":      cause.Error(),
+	}).Error
+	if err != nil {
+		relay.logger.Errorw("error scheduling the retry of an outbox entry", "id", outboxEntry.Id, "error", err)
+	}
+
+	return err
+}
+
+// backoff returns the delay before the next attempt, it doubles with every attempt up to the max backoff
+func (relay *Relay) backoff(attempts int) time.Duration {
+
+	delay := relay.config.PollInterval
+	for i := 1; i < attempts && delay < relay.config.MaxBackoff; i++ {
+		delay *= 2
+	}
+
+	if delay > relay.config.MaxBackoff {
+		return relay.config.MaxBackoff
+	}
+	return delay
+}
+
+func (relay *Relay) recordStatus(ctx context.Context) {
+
+	status, err := relay.Status()
+	if err != nil {
+		relay.logger.Errorw("error reading the outbox status", "error", err)
+		return
+	}
+
+	stats.Record(ctx, mPending.M(int64(status.Pending)), mLag.M(status.LagSeconds))
+}
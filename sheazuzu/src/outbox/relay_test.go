@@ -0,0 +1,208 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sheazuzu/sheazuzu/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/outbox"
+	"sheazuzu/sheazuzu/src/repository"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var config = outbox.Config{Enabled: true, PollInterval: time.Second, BatchSize: 10, MaxBackoff: 4 * time.Second}
+
+// newOutbox returns a migrated SQLite database and a repository, which writes the outbox entries into it
+func newOutbox(t *testing.T) (*gorm.DB, *repository.MySQLRepository) {
+
+	db, err := database.OpenDB("sqlite3", "file:"+filepath.Join(t.TempDir(), "sheazuzu.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	return db, repository.ProvideMySQLRepository(db, true, zap.NewNop().Sugar())
+}
+
+// write stores the match and updates it with the results, every write adds an outbox entry
+func write(t *testing.T, repo *repository.MySQLRepository, homeTeam string, results ...string) int {
+
+	_, id, err := repo.UpdateMatchDataInDB(context.Background(), entity.MatchData{HomeTeam: homeTeam, AwayTeam: "Hamburg", Date: "2021-03-01"})
+	require.NoError(t, err)
+
+	for _, result := range results {
+		upserted, err := repo.UpsertMatchDataBatchInDB(context.Background(),
+			[]entity.MatchData{{Id: id, HomeTeam: homeTeam, AwayTeam: "Hamburg", Date: "2021-03-01", Result: result}}, true)
+		require.NoError(t, err)
+		require.NoError(t, upserted[0].Err)
+	}
+
+	return id
+}
+
+// fakeMongo records the results put per match, the puts of the matches in failing fail
+type fakeMongo struct {
+	mutex   sync.Mutex
+	puts    map[int][]string
+	failing map[int]bool
+}
+
+func newFakeMongo() *fakeMongo {
+	return &fakeMongo{puts: map[int][]string{}, failing: map[int]bool{}}
+}
+
+func (mongo *fakeMongo) Put(_ context.Context, data entity.MatchData) error {
+
+	mongo.mutex.Lock()
+	defer mongo.mutex.Unlock()
+
+	if mongo.failing[data.Id] {
+		return errors.New("connection lost")
+	}
+	mongo.puts[data.Id] = append(mongo.puts[data.Id], data.Result)
+	return nil
+}
+
+func TestRelay_order(t *testing.T) {
+	t.Parallel()
+
+	db, repo := newOutbox(t)
+	bremen := write(t, repo, "Bremen", "1:0", "2:0")
+	mainz := write(t, repo, "Mainz")
+
+	mongo := newFakeMongo()
+	relay := outbox.NewRelay(db, mongo, config, zap.NewNop().Sugar())
+
+	// only the oldest entry of every match is applied per call
+	for _, expected := range []int{2, 1, 1, 0} {
+		relayed, err := relay.RelayPending(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, expected, relayed)
+	}
+
+	assert.Equal(t, map[int][]string{bremen: {"", "1:0", "2:0"}, mainz: {""}}, mongo.puts)
+}
+
+func TestRelay_concurrent(t *testing.T) {
+	t.Parallel()
+
+	db, repo := newOutbox(t)
+	bremen := write(t, repo, "Bremen", "1:0", "2:0", "3:0")
+	mainz := write(t, repo, "Mainz", "0:1")
+
+	// both instances relay until the outbox is empty, every entry must be applied once and in the order of its match
+	mongo := newFakeMongo()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		relay := outbox.NewRelay(db, mongo, config, zap.NewNop().Sugar())
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				relayed, err := relay.RelayPending(context.Background())
+				status, statusErr := relay.Status()
+				if !assert.NoError(t, err) || !assert.NoError(t, statusErr) || relayed == 0 && status.Pending == 0 {
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, map[int][]string{bremen: {"", "1:0", "2:0", "3:0"}, mainz: {"", "0:1"}}, mongo.puts)
+}
+
+func TestRelay_retry(t *testing.T) {
+	t.Parallel()
+
+	db, repo := newOutbox(t)
+	bremen := write(t, repo, "Bremen", "1:0")
+	mainz := write(t, repo, "Mainz")
+
+	mongo := newFakeMongo()
+	mongo.failing[bremen] = true
+	relay := outbox.NewRelay(db, mongo, config, zap.NewNop().Sugar())
+
+	// the delay doubles with every attempt up to the max backoff
+	for attempts, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 4 * time.Second} {
+		before := time.Now()
+		_, err := relay.RelayPending(context.Background())
+		require.NoError(t, err)
+
+		var head entity.OutboxEntry
+		require.NoError(t, db.Where("match_id = ?", bremen).Order("id").First(&head).Error)
+		assert.Equal(t, attempts+1, head.Attempts)
+		assert.Equal(t, "connection lost", head.LastError)
+		assert.WithinDuration(t, before.Add(delay), head.NextAttemptAt, time.Second/2, "attempt %d", attempts+1)
+
+		// the failed entry blocks the later entry of its match until it is due again
+		relayed, err := relay.RelayPending(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, relayed)
+
+		require.NoError(t, db.Model(&head).Update("next_attempt_at", time.Now().Add(-time.Second)).Error)
+	}
+	assert.Equal(t, map[int][]string{mainz: {""}}, mongo.puts)
+
+	status, err := relay.Status()
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Pending)
+	assert.Equal(t, 1, status.Failing)
+	assert.Equal(t, "connection lost", status.LastError)
+
+	mongo.failing[bremen] = false
+	for _, expected := range []int{1, 1, 0} {
+		relayed, err := relay.RelayPending(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, expected, relayed)
+	}
+	assert.Equal(t, []string{"", "1:0"}, mongo.puts[bremen])
+}
+
+func TestRelay_Status(t *testing.T) {
+	t.Parallel()
+
+	db, repo := newOutbox(t)
+	relay := outbox.NewRelay(db, newFakeMongo(), config, zap.NewNop().Sugar())
+
+	status, err := relay.Status()
+	require.NoError(t, err)
+	assert.Equal(t, outbox.Status{}, status)
+
+	write(t, repo, "Bremen", "1:0")
+	write(t, repo, "Mainz")
+	// the oldest entry was written a minute ago
+	require.NoError(t, db.Model(&entity.OutboxEntry{}).Where("id = (SELECT MIN(id) FROM outbox_entries)").
+		Update("created_at", time.Now().Add(-time.Minute)).Error)
+
+	status, err = relay.Status()
+	require.NoError(t, err)
+	assert.Equal(t, 3, status.Pending)
+	assert.Zero(t, status.Failing)
+	assert.InDelta(t, 60, status.LagSeconds, 5)
+	assert.Nil(t, status.LastRelayedAt)
+
+	before := time.Now()
+	_, err = relay.RelayPending(context.Background())
+	require.NoError(t, err)
+
+	status, err = relay.Status()
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Pending)
+	assert.Less(t, status.LagSeconds, 5.0)
+	if assert.NotNil(t, status.LastRelayedAt) {
+		assert.False(t, status.LastRelayedAt.Before(before))
+	}
+	assert.Empty(t, status.LastError)
+}
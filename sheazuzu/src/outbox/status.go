@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"net/http"
+	verrors "sheazuzu/common/src/errors"
+	"sheazuzu/common/src/render"
+	"sheazuzu/sheazuzu/src/entity"
+	"time"
+)
+
+// Status describes how far the MongoDB mirror is behind MySQL.
+// LagSeconds is the age of the oldest entry not applied yet, Failing the number of entries with failed attempts.
+type Status struct {
+	Pending       int        `json:"pending"`
+	Failing       int        `json:"failing"`
+	LagSeconds    float64    `json:"lag_seconds"`
+	LastRelayedAt *time.Time `json:"last_relayed_at,omitempty"`
+	LastError     string     `json:"last_error,omitempty"`
+}
+
+func (relay *Relay) Status() (Status, error) {
+
+	status := Status{}
+
+	err := relay.db.Model(&entity.OutboxEntry{}).Count(&status.Pending).Error
+	if err != nil {
+		return Status{}, err
+	}
+
+	err = relay.db.Model(&entity.OutboxEntry{}).Where("attempts > 0").Count(&status.Failing).Error
+	if err != nil {
+		return Status{}, err
+	}
+
+	if status.Pending > 0 {
+		var oldest entity.OutboxEntry
+		err = relay.db.Order("id").First(&oldest).Error
+		if err != nil {
+			return Status{}, err
+		}
+		status.LagSeconds = time.Since(oldest.CreatedAt).Seconds()
+	}
+
+	relay.mutex.Lock()
+	status.LastRelayedAt = relay.lastRelayedAt
+	status.LastError = relay.lastError
+	relay.mutex.Unlock()
+
+	return status, nil
+}
+
+type statusError struct {
+	Message string `json:"message"`
+}
+
+// StatusHandler responds with the Status of the outbox
+func (relay *Relay) StatusHandler(w http.ResponseWriter, r *http.Request) {
+
+	status, err := relay.Status()
+	if err != nil {
+		relay.logger.Errorw("error reading the outbox status", "error", err)
+		_ = render.RenderError(w, r, http.StatusInternalServerError, statusError{Message: "error reading the outbox status"})
+		return
+	}
+
+	err = render.Render(w, r, http.StatusOK, status)
+	if err != nil {
+		_ = render.RenderError(w, r, verrors.HttpErrorCodeFromError(err), statusError{Message: err.Error()})
+	}
+}
@@ -0,0 +1,113 @@
+package prediction
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// number of equally wide probability bins used for the calibration table
+const calibrationBins = 10
+
+type CalibrationBin struct {
+	Lower             float64
+	Upper             float64
+	Predictions       int
+	MeanProbability   float64
+	ObservedFrequency float64
+}
+
+type BacktestReport struct {
+	Matches int
+	// BrierScore is the mean of the squared differences between the predicted home win/draw/away win probabilities
+	// and the actual outcome, 0 is a perfect and 2 the worst possible score
+	BrierScore float64
+	LogLoss    float64
+	// Calibration compares every predicted outcome probability with how often the outcome actually happened
+	Calibration []CalibrationBin
+}
+
+// Backtest predicts every result from the results played before it and compares the predictions with the actual outcomes.
+// Results with less than minHistory earlier results are skipped, as there is not enough data to fit the model.
+func Backtest(config Config, results []Result, minHistory int) BacktestReport {
+
+	sorted := make([]Result, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Date.Before(sorted[j].Date)
+	})
+
+	report := BacktestReport{}
+	bins := make([]CalibrationBin, calibrationBins)
+	for i := range bins {
+		bins[i].Lower = float64(i) / calibrationBins
+		bins[i].Upper = float64(i+1) / calibrationBins
+	}
+
+	// all matches of a day are predicted with the same model, so it only has to be fitted once per day
+	var model *Model
+	var fittedAt time.Time
+	played := 0
+
+	for i, result := range sorted {
+		if !result.Date.Equal(fittedAt) {
+			for played < i && sorted[played].Date.Before(result.Date) {
+				played++
+			}
+			model = nil
+		}
+
+		if played < minHistory {
+			continue
+		}
+
+		if model == nil {
+			model = Fit(config, sorted[:played], result.Date)
+			fittedAt = result.Date
+		}
+
+		prediction := model.Predict(result.HomeTeam, result.AwayTeam)
+		probabilities := []float64{prediction.HomeWin, prediction.Draw, prediction.AwayWin}
+		outcomes := []float64{0, 0, 0}
+		switch {
+		case result.HomeGoals > result.AwayGoals:
+			outcomes[0] = 1
+		case result.HomeGoals == result.AwayGoals:
+			outcomes[1] = 1
+		default:
+			outcomes[2] = 1
+		}
+
+		report.Matches++
+		for k, probability := range probabilities {
+			report.BrierScore += math.Pow(probability-outcomes[k], 2)
+			if outcomes[k] == 1 {
+				// avoid an infinite loss for outcomes predicted with a probability of 0
+				report.LogLoss -= math.Log(math.Max(probability, 1e-15))
+			}
+
+			bin := int(probability * calibrationBins)
+			if bin >= calibrationBins {
+				bin = calibrationBins - 1
+			}
+			bins[bin].Predictions++
+			bins[bin].MeanProbability += probability
+			bins[bin].ObservedFrequency += outcomes[k]
+		}
+	}
+
+	if report.Matches > 0 {
+		report.BrierScore /= float64(report.Matches)
+		report.LogLoss /= float64(report.Matches)
+	}
+
+	for i := range bins {
+		if bins[i].Predictions > 0 {
+			bins[i].MeanProbability /= float64(bins[i].Predictions)
+			bins[i].ObservedFrequency /= float64(bins[i].Predictions)
+		}
+	}
+	report.Calibration = bins
+
+	return report
+}
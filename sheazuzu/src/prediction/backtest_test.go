@@ -0,0 +1,83 @@
+package prediction
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// backtestResults are given unordered. The first day is symmetric, so the model of the second day expects 1 home goal
+// and no away goal and predicts a home win with 1 - e^-1 and a draw with e^-1.
+var backtestResults = []Result{
+	{HomeTeam: "A", AwayTeam: "B", Date: date("2021-03-02"), HomeGoals: 2, AwayGoals: 2},
+	{HomeTeam: "A", AwayTeam: "B", Date: date("2021-03-01"), HomeGoals: 1, AwayGoals: 0},
+	{HomeTeam: "B", AwayTeam: "A", Date: date("2021-03-01"), HomeGoals: 1, AwayGoals: 0},
+}
+
+func TestBacktest(t *testing.T) {
+	t.Parallel()
+
+	homeWin, draw := 1-math.Exp(-1), math.Exp(-1)
+
+	cases := map[string]struct {
+		minHistory int
+		matches    int
+		brierScore float64
+		logLoss    float64
+	}{
+		// the matches of the first day have no history, the draw of the second day is predicted with e^-1
+		"predicted draw": {
+			minHistory: 2,
+			matches:    1,
+			brierScore: homeWin*homeWin + (draw-1)*(draw-1),
+			logLoss:    1,
+		},
+		"not enough history": {minHistory: 3},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			report := Backtest(Config{MaxGoals: 10, Iterations: 100}, backtestResults, tc.minHistory)
+
+			assert.Equal(t, tc.matches, report.Matches)
+			assert.InDelta(t, tc.brierScore, report.BrierScore, 1e-6)
+			assert.InDelta(t, tc.logLoss, report.LogLoss, 1e-6)
+			assert.Len(t, report.Calibration, calibrationBins)
+
+			predictions := 0
+			for _, bin := range report.Calibration {
+				predictions += bin.Predictions
+			}
+			assert.Equal(t, 3*tc.matches, predictions)
+		})
+	}
+}
+
+func TestBacktest_calibration(t *testing.T) {
+	t.Parallel()
+
+	report := Backtest(Config{MaxGoals: 10, Iterations: 100}, backtestResults, 2)
+
+	// the away win with 0 is in the first bin, the draw with 0.37 in the fourth and the home win with 0.63 in the seventh
+	expected := map[int]CalibrationBin{
+		0: {Lower: 0, Upper: 0.1, Predictions: 1, MeanProbability: 0, ObservedFrequency: 0},
+		3: {Lower: 0.3, Upper: 0.4, Predictions: 1, MeanProbability: math.Exp(-1), ObservedFrequency: 1},
+		6: {Lower: 0.6, Upper: 0.7, Predictions: 1, MeanProbability: 1 - math.Exp(-1), ObservedFrequency: 0},
+	}
+
+	for i, bin := range report.Calibration {
+		want := expected[i]
+		if _, ok := expected[i]; !ok {
+			want = CalibrationBin{Lower: float64(i) / calibrationBins, Upper: float64(i+1) / calibrationBins}
+		}
+		assert.InDelta(t, want.Lower, bin.Lower, 1e-9, "bin %d", i)
+		assert.InDelta(t, want.Upper, bin.Upper, 1e-9, "bin %d", i)
+		assert.Equal(t, want.Predictions, bin.Predictions, "bin %d", i)
+		assert.InDelta(t, want.MeanProbability, bin.MeanProbability, 1e-6, "bin %d", i)
+		assert.InDelta(t, want.ObservedFrequency, bin.ObservedFrequency, 1e-9, "bin %d", i)
+	}
+}
@@ -0,0 +1,44 @@
+package prediction
+
+import (
+	"flag"
+	"fmt"
+)
+
+// Config contains the parameters of the goal model.
+// Decay is the per day weight decay of historical results, MaxGoals the highest number of goals per team considered
+// in the scoreline probabilities. BacktestMinHistory is the number of earlier results needed before a match is
+// included in the backtest.
+type Config struct {
+	Decay              float64
+	MaxGoals           int
+	Iterations         int
+	BacktestMinHistory int
+}
+
+func BindConfig(config *Config, fs *flag.FlagSet) {
+	fs.Float64Var(&config.Decay, "prediction.decay", 0.0019, "the per day decay of the weight of historical results (0 disables the decay)")
+	fs.IntVar(&config.MaxGoals, "prediction.maxGoals", 10, "the highest number of goals per team considered in the scoreline probabilities")
+	fs.IntVar(&config.Iterations, "prediction.iterations", 100, "the maximum number of iterations used to fit the team strengths")
+	fs.IntVar(&config.BacktestMinHistory, "prediction.backtestMinHistory", 50, "the number of earlier results needed before a match is included in the backtest")
+}
+
+func (config *Config) IsValid() bool {
+
+	if config.Decay < 0 {
+		fmt.Println("the prediction decay must not be negative")
+		return false
+	}
+
+	if config.MaxGoals < 1 {
+		fmt.Println("the prediction max goals must be at least 1")
+		return false
+	}
+
+	if config.Iterations < 1 {
+		fmt.Println("the prediction iterations must be at least 1")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,293 @@
+// Package prediction provides a Poisson goal model to predict the outcome of matches from historical results.
+//
+// The expected goals of a match are modelled as
+//
+//	home goals ~ Poisson(homeRate * attack(home) * defence(away))
+//	away goals ~ Poisson(awayRate * attack(away) * defence(home))
+//
+// where attack and defence are the strengths of a team relative to the average team (1.0), and homeRate/awayRate
+// are the average goals of the home and away side. Older results are weighted down exponentially with Config.Decay.
+package prediction
+
+import (
+	"math"
+	"sheazuzu/sheazuzu/src/entity"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of the date stored with a match
+const DateLayout = "2006-01-02"
+
+// the strengths are shrunk towards the average team as if every team played one additional average match,
+// this keeps teams without goals or with very few matches from getting a strength of zero
+const priorWeight = 1.0
+
+// the fit stops as soon as no strength changes more than this between two iterations
+const tolerance = 1e-6
+
+type Result struct {
+	Id        int
+	HomeTeam  string
+	AwayTeam  string
+	Date      time.Time
+	HomeGoals int
+	AwayGoals int
+}
+
+// ParseDate parses the date of a match, ok is false if the date is not in the format of DateLayout.
+func ParseDate(date string) (time.Time, bool) {
+	parsed, err := time.Parse(DateLayout, strings.TrimSpace(date))
+	if err != nil {
+		return time.Time{}, false
+	}
+	return parsed, true
+}
+
+// ParseResult converts a match into a Result, ok is false if the match has no valid date or result "<home>:<away>".
+func ParseResult(data entity.MatchData) (Result, bool) {
+
+	date, ok := ParseDate(data.Date)
+	if !ok {
+		return Result{}, false
+	}
+
+	goals := strings.Split(data.Result, ":")
+	if len(goals) != 2 {
+		return Result{}, false
+	}
+
+	homeGoals, err := strconv.Atoi(strings.TrimSpace(goals[0]))
+	if err != nil || homeGoals < 0 {
+		return Result{}, false
+	}
+
+	awayGoals, err := strconv.Atoi(strings.TrimSpace(goals[1]))
+	if err != nil || awayGoals < 0 {
+		return Result{}, false
+	}
+
+	return Result{
+		Id:        data.Id,
+		HomeTeam:  data.HomeTeam,
+		AwayTeam:  data.AwayTeam,
+		Date:      date,
+		HomeGoals: homeGoals,
+		AwayGoals: awayGoals,
+	}, true
+}
+
+type Model struct {
+	config   Config
+	homeRate float64
+	awayRate float64
+	attack   map[string]float64
+	defence  map[string]float64
+}
+
+// Fit estimates the team strengths from all results played before the given point in time.
+// Results are weighted with exp(-decay * age in days), so recent results have a bigger influence.
+func Fit(config Config, results []Result, before time.Time) *Model {
+
+	model := &Model{
+		config:   config,
+		homeRate: 1,
+		awayRate: 1,
+		attack:   map[string]float64{},
+		defence:  map[string]float64{},
+	}
+
+	var history []Result
+	var weights []float64
+	for _, result := range results {
+		if !result.Date.Before(before) {
+			continue
+		}
+		age := before.Sub(result.Date).Hours() / 24
+		history = append(history, result)
+		weights = append(weights, math.Exp(-config.Decay*age))
+
+		model.attack[result.HomeTeam] = 1
+		model.attack[result.AwayTeam] = 1
+		model.defence[result.HomeTeam] = 1
+		model.defence[result.AwayTeam] = 1
+	}
+
+	if len(history) == 0 {
+		return model
+	}
+
+	for i := 0; i < config.Iterations; i++ {
+		model.updateRates(history, weights)
+		changed := model.updateAttack(history, weights)
+		changed = math.Max(changed, model.updateDefence(history, weights))
+
+		if changed < tolerance {
+			break
+		}
+	}
+	model.updateRates(history, weights)
+
+	return model
+}
+
+func (model *Model) updateRates(history []Result, weights []float64) {
+
+	var homeGoals, homeExpected, awayGoals, awayExpected float64
+	for i, result := range history {
+		homeGoals += weights[i] * float64(result.HomeGoals)
+		homeExpected += weights[i] * model.attack[result.HomeTeam] * model.defence[result.AwayTeam]
+		awayGoals += weights[i] * float64(result.AwayGoals)
+		awayExpected += weights[i] * model.attack[result.AwayTeam] * model.defence[result.HomeTeam]
+	}
+
+	if homeExpected > 0 {
+		model.homeRate = homeGoals / homeExpected
+	}
+	if awayExpected > 0 {
+		model.awayRate = awayGoals / awayExpected
+	}
+}
+
+// updateAttack re-estimates the attack strengths for fixed defence strengths and returns the biggest change
+func (model *Model) updateAttack(history []Result, weights []float64) float64 {
+
+	scored := map[string]float64{}
+	expected := map[string]float64{}
+	for i, result := range history {
+		scored[result.HomeTeam] += weights[i] * float64(result.HomeGoals)
+		expected[result.HomeTeam] += weights[i] * model.homeRate * model.defence[result.AwayTeam]
+		scored[result.AwayTeam] += weights[i] * float64(result.AwayGoals)
+		expected[result.AwayTeam] += weights[i] * model.awayRate * model.defence[result.HomeTeam]
+	}
+
+	return update(model.attack, scored, expected)
+}
+
+// updateDefence re-estimates the defence strengths for fixed attack strengths and returns the biggest change
+func (model *Model) updateDefence(history []Result, weights []float64) float64 {
+
+	conceded := map[string]float64{}
+	expected := map[string]float64{}
+	for i, result := range history {
+		conceded[result.HomeTeam] += weights[i] * float64(result.AwayGoals)
+		expected[result.HomeTeam] += weights[i] * model.awayRate * model.attack[result.AwayTeam]
+		conceded[result.AwayTeam] += weights[i] * float64(result.HomeGoals)
+		expected[result.AwayTeam] += weights[i] * model.homeRate * model.attack[result.HomeTeam]
+	}
+
+	return update(model.defence, conceded, expected)
+}
+
+// update sets every strength to observed/expected goals, normalised to an average strength of 1
+func update(strengths, observed, expected map[string]float64) float64 {
+
+	updated := map[string]float64{}
+	sum := 0.0
+	for team := range strengths {
+		updated[team] = (observed[team] + priorWeight) / (expected[team] + priorWeight)
+		sum += updated[team]
+	}
+	mean := sum / float64(len(updated))
+
+	changed := 0.0
+	for team, strength := range updated {
+		strength = strength / mean
+		changed = math.Max(changed, math.Abs(strength-strengths[team]))
+		strengths[team] = strength
+	}
+
+	return changed
+}
+
+type Scoreline struct {
+	HomeGoals   int
+	AwayGoals   int
+	Probability float64
+}
+
+type Prediction struct {
+	ExpectedHomeGoals float64
+	ExpectedAwayGoals float64
+	HomeWin           float64
+	Draw              float64
+	AwayWin           float64
+	// Scorelines contains the probability of every scoreline up to Config.MaxGoals, the most likely first
+	Scorelines []Scoreline
+}
+
+// Strength returns the attack and defence strength of a team, teams without results have average strength 1.
+func (model *Model) Strength(team string) (attack, defence float64) {
+	attack, ok := model.attack[team]
+	if !ok {
+		attack = 1
+	}
+	defence, ok = model.defence[team]
+	if !ok {
+		defence = 1
+	}
+	return attack, defence
+}
+
+// Predict returns the scoreline and home win/draw/away win probabilities for a match between the given teams.
+func (model *Model) Predict(homeTeam, awayTeam string) Prediction {
+
+	homeAttack, homeDefence := model.Strength(homeTeam)
+	awayAttack, awayDefence := model.Strength(awayTeam)
+
+	prediction := Prediction{
+		ExpectedHomeGoals: model.homeRate * homeAttack * awayDefence,
+		ExpectedAwayGoals: model.awayRate * awayAttack * homeDefence,
+	}
+
+	homeGoals := poisson(prediction.ExpectedHomeGoals, model.config.MaxGoals)
+	awayGoals := poisson(prediction.ExpectedAwayGoals, model.config.MaxGoals)
+
+	total := 0.0
+	for home, homeProbability := range homeGoals {
+		for away, awayProbability := range awayGoals {
+			probability := homeProbability * awayProbability
+			total += probability
+
+			switch {
+			case home > away:
+				prediction.HomeWin += probability
+			case home == away:
+				prediction.Draw += probability
+			default:
+				prediction.AwayWin += probability
+			}
+
+			prediction.Scorelines = append(prediction.Scorelines, Scoreline{
+				HomeGoals:   home,
+				AwayGoals:   away,
+				Probability: probability,
+			})
+		}
+	}
+
+	// the scorelines above MaxGoals are cut off, so the outcomes are scaled to add up to 1 again
+	if total > 0 {
+		prediction.HomeWin /= total
+		prediction.Draw /= total
+		prediction.AwayWin /= total
+	}
+
+	sort.SliceStable(prediction.Scorelines, func(i, j int) bool {
+		return prediction.Scorelines[i].Probability > prediction.Scorelines[j].Probability
+	})
+
+	return prediction
+}
+
+// poisson returns the probabilities of 0..max events for the given rate
+func poisson(rate float64, max int) []float64 {
+	probabilities := make([]float64, max+1)
+	probabilities[0] = math.Exp(-rate)
+	for k := 1; k <= max; k++ {
+		probabilities[k] = probabilities[k-1] * rate / float64(k)
+	}
+	return probabilities
+}
@@ -0,0 +1,192 @@
+package prediction
+
+import (
+	"math"
+	"sheazuzu/sheazuzu/src/entity"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func date(day string) time.Time {
+	parsed, _ := ParseDate(day)
+	return parsed
+}
+
+func TestParseResult(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		data   entity.MatchData
+		result Result
+		ok     bool
+	}{
+		"valid": {
+			data:   entity.MatchData{Id: 7, HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01", Result: "2:1"},
+			result: Result{Id: 7, HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: date("2021-03-01"), HomeGoals: 2, AwayGoals: 1},
+			ok:     true,
+		},
+		"spaces": {
+			data:   entity.MatchData{Date: " 2021-03-01 ", Result: " 3 : 0 "},
+			result: Result{Date: date("2021-03-01"), HomeGoals: 3},
+			ok:     true,
+		},
+		"not played":     {data: entity.MatchData{Date: "2021-03-01", Result: ""}},
+		"other format":   {data: entity.MatchData{Date: "2021-03-01", Result: "2-1"}},
+		"three parts":    {data: entity.MatchData{Date: "2021-03-01", Result: "1:2:3"}},
+		"no number":      {data: entity.MatchData{Date: "2021-03-01", Result: "a:1"}},
+		"negative goals": {data: entity.MatchData{Date: "2021-03-01", Result: "-1:2"}},
+		"invalid date":   {data: entity.MatchData{Date: "01.03.2021", Result: "2:1"}},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			result, ok := ParseResult(tc.data)
+			assert.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.result, result)
+		})
+	}
+}
+
+func TestPoisson(t *testing.T) {
+	t.Parallel()
+
+	// e^-1.5 * 1.5^k / k!
+	expected := []float64{0.22313016, 0.33469524, 0.25102143, 0.12551072}
+	probabilities := poisson(1.5, 3)
+
+	assert.Len(t, probabilities, len(expected))
+	for k := range expected {
+		assert.InDelta(t, expected[k], probabilities[k], 1e-8, "%d goals", k)
+	}
+	assert.Equal(t, []float64{1, 0, 0}, poisson(0, 2))
+}
+
+// symmetric results keep the strengths of both teams at 1, so the rates are the weighted mean goals
+var symmetric = []Result{
+	{HomeTeam: "A", AwayTeam: "B", Date: date("2021-01-30"), HomeGoals: 1, AwayGoals: 1},
+	{HomeTeam: "B", AwayTeam: "A", Date: date("2021-01-30"), HomeGoals: 1, AwayGoals: 1},
+	{HomeTeam: "A", AwayTeam: "B", Date: date("2021-03-10"), HomeGoals: 3, AwayGoals: 1},
+	{HomeTeam: "B", AwayTeam: "A", Date: date("2021-03-10"), HomeGoals: 3, AwayGoals: 1},
+	// played at the time of the fit, so it is not part of the history
+	{HomeTeam: "A", AwayTeam: "B", Date: date("2021-03-11"), HomeGoals: 9, AwayGoals: 0},
+}
+
+func TestFit_decay(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		decay    float64
+		expected float64
+	}{
+		// (3 + 1) / 2
+		"no decay": {decay: 0, expected: 2},
+		// the older results are 39 days older and weigh half as much: (3 + 0.5) / 1.5
+		"half weight": {decay: math.Ln2 / 39, expected: 3.5 / 1.5},
+		// only the latest results count
+		"strong decay": {decay: 10, expected: 3},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			model := Fit(Config{Decay: tc.decay, MaxGoals: 10, Iterations: 100}, symmetric, date("2021-03-11"))
+
+			for _, team := range []string{"A", "B"} {
+				attack, defence := model.Strength(team)
+				assert.InDelta(t, 1, attack, 1e-9)
+				assert.InDelta(t, 1, defence, 1e-9)
+			}
+
+			prediction := model.Predict("A", "B")
+			assert.InDelta(t, tc.expected, prediction.ExpectedHomeGoals, 1e-9)
+			assert.InDelta(t, 1, prediction.ExpectedAwayGoals, 1e-9)
+		})
+	}
+}
+
+func TestFit_strengths(t *testing.T) {
+	t.Parallel()
+
+	results := []Result{
+		{HomeTeam: "Strong", AwayTeam: "Weak", Date: date("2021-03-01"), HomeGoals: 4, AwayGoals: 0},
+		{HomeTeam: "Weak", AwayTeam: "Strong", Date: date("2021-03-08"), HomeGoals: 0, AwayGoals: 3},
+	}
+	model := Fit(Config{MaxGoals: 10, Iterations: 100}, results, date("2021-04-01"))
+
+	strongAttack, strongDefence := model.Strength("Strong")
+	weakAttack, weakDefence := model.Strength("Weak")
+	assert.Greater(t, strongAttack, weakAttack)
+	assert.Less(t, strongDefence, weakDefence)
+	// the strengths are relative to the average team
+	assert.InDelta(t, 2, strongAttack+weakAttack, 1e-9)
+	assert.InDelta(t, 2, strongDefence+weakDefence, 1e-9)
+
+	attack, defence := model.Strength("Unknown")
+	assert.Equal(t, 1.0, attack)
+	assert.Equal(t, 1.0, defence)
+
+	prediction := model.Predict("Strong", "Weak")
+	assert.Greater(t, prediction.HomeWin, prediction.AwayWin)
+}
+
+func TestPredict(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		model    *Model
+		maxGoals int
+		homeWin  float64
+		draw     float64
+		awayWin  float64
+	}{
+		// a model without results expects 1 goal per side, with at most 1 goal the 4 scorelines are equally likely
+		"one goal": {
+			model:    Fit(Config{MaxGoals: 1, Iterations: 1}, nil, date("2021-03-01")),
+			maxGoals: 1,
+			homeWin:  0.25, draw: 0.5, awayWin: 0.25,
+		},
+		// the away side never scores, so the home side wins unless it does not score either: P(0) = e^-1
+		"no away goals": {
+			model:    &Model{homeRate: 1, awayRate: 0, config: Config{MaxGoals: 10}},
+			maxGoals: 10,
+			homeWin:  1 - math.Exp(-1), draw: math.Exp(-1),
+		},
+		"symmetric history": {
+			model:    Fit(Config{MaxGoals: 10, Iterations: 100}, symmetric, date("2021-03-11")),
+			maxGoals: 10,
+			// the outcomes of Poisson(2) home goals against Poisson(1) away goals, cut off at 10 goals
+			homeWin: 0.6057, draw: 0.2117, awayWin: 0.1826,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			prediction := tc.model.Predict("A", "B")
+
+			assert.InDelta(t, tc.homeWin, prediction.HomeWin, 1e-4)
+			assert.InDelta(t, tc.draw, prediction.Draw, 1e-4)
+			assert.InDelta(t, tc.awayWin, prediction.AwayWin, 1e-4)
+			assert.InDelta(t, 1, prediction.HomeWin+prediction.Draw+prediction.AwayWin, 1e-9)
+
+			assert.Len(t, prediction.Scorelines, (tc.maxGoals+1)*(tc.maxGoals+1))
+			total := 0.0
+			for i, scoreline := range prediction.Scorelines {
+				total += scoreline.Probability
+				if i > 0 {
+					assert.LessOrEqual(t, scoreline.Probability, prediction.Scorelines[i-1].Probability)
+				}
+			}
+			assert.LessOrEqual(t, total, 1+1e-9)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go.uber.org/zap"
+	"golang.org/x/sync/singleflight"
+	"sheazuzu/common/src/cache"
+	"sheazuzu/common/src/metrics"
+	"sheazuzu/sheazuzu/src/entity"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// the name of the match cache in the metrics
+const matchCache = "match"
+
+// CachedRepository is a read-through cache of the matches by id in front of a repository. All other lookups are passed
+// to the repository. The concurrent lookups of a missing match share a single query and every write invalidates the
+// matches it wrote. The shared query runs with its own timeout of loadTimeout, so a lookup giving up does not fail the
+// lookups waiting for the same match. The missing matches are loaded from the primary, as a lagging read replica would
+// fill the cache with a match older than the last write. A failing cache is treated as a miss, so the cache never fails
+// a call.
+// Writes of other instances are only seen after the TTL of the cache, unless the cache is shared by all instances.
+type CachedRepository struct {
+	Repository
+	cache cache.Cache
+	group singleflight.Group
+	// the number of invalidations, a lookup does not cache a match, if it was invalidated while the lookup ran
+	invalidations uint64
+	loadTimeout   time.Duration
+	logger        *zap.SugaredLogger
+}
+
+type cachedUnitOfWorkKey struct{}
+
+// cachedUnitOfWork collects the matches written in a unit of work, they are invalidated when it is done
+type cachedUnitOfWork struct {
+	ids []int
+}
+
+func ProvideCachedRepository(repository Repository, cache cache.Cache, loadTimeout time.Duration, logger *zap.SugaredLogger) *CachedRepository {
+	return &CachedRepository{
+		Repository:  repository,
+		cache:       cache,
+		loadTimeout: loadTimeout,
+		logger:      logger,
+	}
+}
+
+// detachedContext keeps the values of its parent, like the span of the trace, but is neither canceled nor has a
+// deadline with it
+type detachedContext struct {
+	context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+// FindMatchDataByIdInDB returns the match from the cache or loads and caches it. The whole match is cached, the
+// projection is applied to the cached match. A unit of work bypasses the cache, as it reads its own uncommitted writes.
+func (repository *CachedRepository) FindMatchDataByIdInDB(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error) {
+
+	if ctx.Value(cachedUnitOfWorkKey{}) != nil {
+		return repository.Repository.FindMatchDataByIdInDB(ctx, id, projection)
+	}
+
+	key := matchKey(id)
+
+	data, ok := repository.get(ctx, key)
+	if ok {
+		metrics.RecordCacheHit(ctx, matchCache)
+		return project(data, projection), nil
+	}
+	metrics.RecordCacheMiss(ctx, matchCache)
+
+	// the query is shared with the lookups of other callers, so it must neither end with the context of the first caller
+	// nor depend on its read your writes window
+	loading := repository.group.DoChan(key, func() (interface{}, error) {
+		loadCtx, cancel := context.WithTimeout(withPrimaryRead(detachedContext{ctx}), repository.loadTimeout)
+		defer cancel()
+
+		invalidations := atomic.LoadUint64(&repository.invalidations)
+
+		data, err := repository.Repository.FindMatchDataByIdInDB(loadCtx, id, entity.Projection{})
+		if err != nil {
+			return entity.MatchData{}, err
+		}
+
+		if atomic.LoadUint64(&repository.invalidations) == invalidations {
+			repository.set(loadCtx, key, data)
+		}
+
+		return data, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return entity.MatchData{}, ctx.Err()
+	case loaded := <-loading:
+		if loaded.Err != nil {
+			return entity.MatchData{}, loaded.Err
+		}
+		return project(loaded.Val.(entity.MatchData), projection), nil
+	}
+}
+
+func (repository *CachedRepository) UpdateMatchDataInDB(ctx context.Context, data entity.MatchData) (string, int, error) {
+
+	msg, id, err := repository.Repository.UpdateMatchDataInDB(ctx, data)
+	if id != 0 {
+		repository.invalidate(ctx, id)
+	}
+
+	return msg, id, err
+}
+
+// UpsertMatchDataBatchInDB invalidates all matches of the batch with an id, also if the batch failed
+func (repository *CachedRepository) UpsertMatchDataBatchInDB(ctx context.Context, data []entity.MatchData, allOrNothing bool) ([]entity.UpsertResult, error) {
+
+	results, err := repository.Repository.UpsertMatchDataBatchInDB(ctx, data, allOrNothing)
+
+	var ids []int
+	for i := range results {
+		if results[i].Id != 0 {
+			ids = append(ids, results[i].Id)
+		}
+	}
+	for i := range data {
+		if data[i].Id != 0 {
+			ids = append(ids, data[i].Id)
+		}
+	}
+	repository.invalidate(ctx, ids...)
+
+	return results, err
+}
+
+// WithinTransaction runs f in a unit of work of the repository. The matches written in the unit of work are
+// invalidated when the outermost unit of work is done, so no lookup caches a match before it is committed.
+func (repository *CachedRepository) WithinTransaction(ctx context.Context, f func(ctx context.Context) error) error {
+
+	if ctx.Value(cachedUnitOfWorkKey{}) != nil {
+		return repository.Repository.WithinTransaction(ctx, f)
+	}
+
+	unitOfWork := &cachedUnitOfWork{}
+	defer func() {
+		repository.invalidate(context.Background(), unitOfWork.ids...)
+	}()
+
+	return repository.Repository.WithinTransaction(context.WithValue(ctx, cachedUnitOfWorkKey{}, unitOfWork), f)
+}
+
+// WithinSnapshot runs f in a snapshot of the repository, if it supports them, and otherwise just runs f. The lookups by
+// id bypass the cache in f, as the cache does not know the snapshot.
+func (repository *CachedRepository) WithinSnapshot(ctx context.Context, f func(ctx context.Context) error) error {
+
+	ctx = context.WithValue(ctx, cachedUnitOfWorkKey{}, &cachedUnitOfWork{})
+
+	snapshots, ok := repository.Repository.(SnapshotReader)
+	if !ok {
+		return f(ctx)
+	}
+	return snapshots.WithinSnapshot(ctx, f)
+}
+
+// invalidate removes the matches from the cache, in a unit of work they are removed when it is done
+func (repository *CachedRepository) invalidate(ctx context.Context, ids ...int) {
+
+	if len(ids) == 0 {
+		return
+	}
+
+	if unitOfWork, ok := ctx.Value(cachedUnitOfWorkKey{}).(*cachedUnitOfWork); ok {
+		unitOfWork.ids = append(unitOfWork.ids, ids...)
+		return
+	}
+
+	atomic.AddUint64(&repository.invalidations, 1)
+
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = matchKey(id)
+		// later lookups must not share a running query, which may have read the match before the write
+		repository.group.Forget(keys[i])
+	}
+
+	err := repository.cache.Delete(ctx, keys...)
+	if err != nil {
+		metrics.RecordCacheError(ctx, matchCache)
+		repository.logger.Errorw("error invalidating the cached matches, they are stale until they expire", "ids", ids, "error", err)
+	}
+}
+
+func (repository *CachedRepository) get(ctx context.Context, key string) (entity.MatchData, bool) {
+
+	value, err := repository.cache.Get(ctx, key)
+	if errors.Is(err, cache.ErrMiss) {
+		return entity.MatchData{}, false
+	}
+	if err != nil {
+		metrics.RecordCacheError(ctx, matchCache)
+		repository.logger.Warnw("error reading the match cache", "key", key, "error", err)
+		return entity.MatchData{}, false
+	}
+
+	var data entity.MatchData
+	err = json.Unmarshal(value, &data)
+	if err != nil {
+		metrics.RecordCacheError(ctx, matchCache)
+		repository.logger.Warnw("error decoding the cached match", "key", key, "error", err)
+		return entity.MatchData{}, false
+	}
+
+	return data, true
+}
+
+func (repository *CachedRepository) set(ctx context.Context, key string, data entity.MatchData) {
+
+	value, err := json.Marshal(data)
+	if err == nil {
+		err = repository.cache.Set(ctx, key, value)
+	}
+	if err != nil {
+		metrics.RecordCacheError(ctx, matchCache)
+		repository.logger.Warnw("error caching the match", "key", key, "error", err)
+	}
+}
+
+func matchKey(id int) string {
+	return "match:" + strconv.Itoa(id)
+}
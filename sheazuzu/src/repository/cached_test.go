@@ -0,0 +1,211 @@
+package repository_test
+
+import (
+	"context"
+	"errors"
+	"sheazuzu/common/src/cache"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/repository"
+	"sheazuzu/sheazuzu/src/repository/repositorytest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestCachedRepository(t *testing.T) {
+	newRepository := func(t *testing.T) repository.Repository {
+		memory := repository.ProvideMemoryRepository(zap.NewNop().Sugar())
+		return repository.ProvideCachedRepository(memory, cache.NewLRU(100, time.Minute), time.Second, zap.NewNop().Sugar())
+	}
+
+	repositorytest.Run(t, newRepository)
+	repositorytest.RunSavepoints(t, newRepository)
+}
+
+// countingRepository counts the lookups by id and blocks them until release is closed or their context is done
+type countingRepository struct {
+	repository.Repository
+	lookups int32
+	release chan struct{}
+}
+
+func (repository *countingRepository) FindMatchDataByIdInDB(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error) {
+	atomic.AddInt32(&repository.lookups, 1)
+	select {
+	case <-repository.release:
+	case <-ctx.Done():
+		return entity.MatchData{}, ctx.Err()
+	}
+	return repository.Repository.FindMatchDataByIdInDB(ctx, id, projection)
+}
+
+// failingCache fails all operations
+type failingCache struct{}
+
+func (failingCache) Get(context.Context, string) ([]byte, error) { return nil, errors.New("down") }
+func (failingCache) Set(context.Context, string, []byte) error   { return errors.New("down") }
+func (failingCache) Delete(context.Context, ...string) error     { return errors.New("down") }
+func (failingCache) Close() error                                { return nil }
+
+func TestCachedRepository_cache(t *testing.T) {
+
+	ctx := context.Background()
+	match := entity.MatchData{Id: 1, HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01", Result: "2:0"}
+	changed := match
+	changed.Result = "3:0"
+
+	newRepository := func(t *testing.T, c cache.Cache) (*repository.CachedRepository, *countingRepository) {
+		counting := &countingRepository{
+			Repository: repository.ProvideMemoryRepository(zap.NewNop().Sugar()),
+			release:    make(chan struct{}),
+		}
+		close(counting.release)
+
+		_, _, err := counting.UpdateMatchDataInDB(ctx, match)
+		require.NoError(t, err)
+
+		return repository.ProvideCachedRepository(counting, c, time.Second, zap.NewNop().Sugar()), counting
+	}
+
+	t.Run("lookups are cached", func(t *testing.T) {
+		cached, counting := newRepository(t, cache.NewLRU(10, time.Minute))
+
+		for i := 0; i < 3; i++ {
+			data, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+			assert.NoError(t, err)
+			assert.Equal(t, match, data)
+		}
+		assert.EqualValues(t, 1, counting.lookups)
+
+		data, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{Fields: []string{"Result"}})
+		assert.NoError(t, err)
+		assert.Equal(t, entity.MatchData{Id: 1, Date: "2021-03-01", Result: "2:0"}, data)
+		assert.EqualValues(t, 1, counting.lookups)
+	})
+
+	t.Run("concurrent lookups share a query", func(t *testing.T) {
+		cached, counting := newRepository(t, cache.NewLRU(10, time.Minute))
+		counting.release = make(chan struct{})
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				data, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+				assert.NoError(t, err)
+				assert.Equal(t, match, data)
+			}()
+		}
+
+		// wait for the first lookup to block in the repository, the others wait for it
+		for atomic.LoadInt32(&counting.lookups) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+		time.Sleep(10 * time.Millisecond)
+		close(counting.release)
+		wg.Wait()
+
+		assert.EqualValues(t, 1, counting.lookups)
+	})
+
+	t.Run("canceled lookup does not fail the shared query", func(t *testing.T) {
+		cached, counting := newRepository(t, cache.NewLRU(10, time.Minute))
+		counting.release = make(chan struct{})
+
+		firstCtx, cancel := context.WithCancel(ctx)
+		first := make(chan error)
+		go func() {
+			_, err := cached.FindMatchDataByIdInDB(firstCtx, 1, entity.Projection{})
+			first <- err
+		}()
+		for atomic.LoadInt32(&counting.lookups) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		second := make(chan entity.MatchData)
+		go func() {
+			data, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+			assert.NoError(t, err)
+			second <- data
+		}()
+
+		// the first caller gives up, the query goes on for the second one
+		cancel()
+		assert.ErrorIs(t, <-first, context.Canceled)
+		close(counting.release)
+		assert.Equal(t, match, <-second)
+		assert.EqualValues(t, 1, counting.lookups)
+	})
+
+	t.Run("shared query times out", func(t *testing.T) {
+		cached, counting := newRepository(t, cache.NewLRU(10, time.Minute))
+		counting.release = make(chan struct{})
+		cached = repository.ProvideCachedRepository(counting, cache.NewLRU(10, time.Minute), 10*time.Millisecond, zap.NewNop().Sugar())
+
+		_, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("writes invalidate", func(t *testing.T) {
+		cached, _ := newRepository(t, cache.NewLRU(10, time.Minute))
+
+		_, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+		require.NoError(t, err)
+
+		_, err = cached.UpsertMatchDataBatchInDB(ctx, []entity.MatchData{changed}, true)
+		require.NoError(t, err)
+
+		data, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+		assert.NoError(t, err)
+		assert.Equal(t, changed, data)
+	})
+
+	t.Run("unit of work invalidates when done", func(t *testing.T) {
+		cached, _ := newRepository(t, cache.NewLRU(10, time.Minute))
+
+		err := cached.WithinTransaction(ctx, func(txCtx context.Context) error {
+			_, err := cached.UpsertMatchDataBatchInDB(txCtx, []entity.MatchData{changed}, true)
+			require.NoError(t, err)
+
+			// the unit of work reads its own write, outside the committed match is cached
+			data, err := cached.FindMatchDataByIdInDB(txCtx, 1, entity.Projection{})
+			assert.NoError(t, err)
+			assert.Equal(t, changed, data)
+
+			_, err = cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+			return err
+		})
+		require.NoError(t, err)
+
+		data, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+		assert.NoError(t, err)
+		assert.Equal(t, changed, data)
+	})
+
+	t.Run("failing cache is a miss", func(t *testing.T) {
+		cached, counting := newRepository(t, failingCache{})
+
+		for i := 0; i < 2; i++ {
+			data, err := cached.FindMatchDataByIdInDB(ctx, 1, entity.Projection{})
+			assert.NoError(t, err)
+			assert.Equal(t, match, data)
+		}
+		assert.EqualValues(t, 2, counting.lookups)
+
+		_, _, err := cached.UpdateMatchDataInDB(ctx, entity.MatchData{HomeTeam: "Bremen"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("missing match", func(t *testing.T) {
+		cached, _ := newRepository(t, cache.NewLRU(10, time.Minute))
+
+		_, err := cached.FindMatchDataByIdInDB(ctx, 2, entity.Projection{})
+		assert.ErrorIs(t, err, entity.ErrNotFound)
+	})
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"flag"
+	"fmt"
+)
+
+const (
+	BackendMySQL  = "mysql"
+	BackendMongo  = "mongo"
+	BackendMemory = "memory"
+)
+
+// Config selects the storage backend of the matches. The memory backend loses all matches with a restart and is meant
+// for local development and tests.
+type Config struct {
+	Backend string
+}
+
+func BindConfig(config *Config, fs *flag.FlagSet) {
+	fs.StringVar(&config.Backend, "storage.backend", BackendMySQL, "storage backend of the matches, either 'mysql', 'mongo' or 'memory', 'mysql' uses the SQL database of database.driver")
+}
+
+// IsValid returns true, if the backend is known.
+func (config *Config) IsValid() bool {
+
+	if config.Backend != BackendMySQL && config.Backend != BackendMongo && config.Backend != BackendMemory {
+		fmt.Println("storage backend must either be 'mysql', 'mongo' or 'memory'")
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,382 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"go.uber.org/zap"
+	"sheazuzu/sheazuzu/src/entity"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// MemoryRepository keeps the matches in memory, they are lost with a restart.
+// It behaves like the MySQL repository and is meant for local development and tests.
+type MemoryRepository struct {
+	mutex   sync.RWMutex
+	matches map[int]entity.MatchData
+	lastId  int
+	logger  *zap.SugaredLogger
+}
+
+func ProvideMemoryRepository(logger *zap.SugaredLogger) *MemoryRepository {
+	return &MemoryRepository{
+		matches: map[int]entity.MatchData{},
+		logger:  logger,
+	}
+}
+
+func (repository *MemoryRepository) FindMatchDataByIdInDB(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error) {
+
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	data, ok := repository.matches[id]
+	if !ok {
+		return entity.MatchData{}, entity.ErrNotFound
+	}
+
+	return project(data, projection), nil
+}
+
+func (repository *MemoryRepository) FindAllMatchDataInDB(ctx context.Context, projection entity.Projection, page entity.Page) ([]entity.MatchData, error) {
+
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var data []entity.MatchData
+	for _, matchData := range repository.sorted() {
+		if page.After != nil && (matchData.Date < page.After.Date || matchData.Date == page.After.Date && matchData.Id <= page.After.Id) {
+			continue
+		}
+		if page.Limit > 0 && len(data) == page.Limit {
+			break
+		}
+		data = append(data, project(matchData, projection))
+	}
+
+	return data, nil
+}
+
+func (repository *MemoryRepository) FindPlayedMatchDataInDB(ctx context.Context) ([]entity.MatchData, error) {
+
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	var data []entity.MatchData
+	for _, matchData := range repository.sorted() {
+		if strings.Contains(matchData.Result, ":") {
+			data = append(data, matchData)
+		}
+	}
+
+	return data, nil
+}
+
+func (repository *MemoryRepository) UpdateMatchDataInDB(ctx context.Context, data entity.MatchData) (string, int, error) {
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	err := repository.create(&data)
+	if err != nil {
+		return "failed - memory", 0, err
+	}
+
+	return "successful!", data.Id, nil
+}
+
+func (repository *MemoryRepository) FindStatisticsInDB(ctx context.Context) (entity.Statistics, error) {
+
+	repository.mutex.RLock()
+	defer repository.mutex.RUnlock()
+
+	statistics := entity.Statistics{}
+	byMatchType := map[string]*entity.OutcomeCount{}
+	bySeason := map[string]*entity.OutcomeCount{}
+	scorelines := map[string]int{}
+	cleanSheets := map[string]int{}
+
+	for _, matchData := range repository.sorted() {
+		home, away, ok := goals(matchData.Result)
+		if !ok {
+			continue
+		}
+
+		countOutcome(&statistics.Overall, home, away)
+		countOutcome(outcomeCount(byMatchType, matchData.MatchType), home, away)
+		countOutcome(outcomeCount(bySeason, season(matchData.Date)), home, away)
+		scorelines[fmt.Sprintf("%d:%d", home, away)]++
+
+		// a team keeps a clean sheet at home, if the away team did not score and vice versa
+		cleanSheets[matchData.HomeTeam] += boolToInt(away == 0)
+		cleanSheets[matchData.AwayTeam] += boolToInt(home == 0)
+	}
+
+	statistics.ByMatchType = sortedOutcomes(byMatchType)
+	statistics.BySeason = sortedOutcomes(bySeason)
+
+	for scoreline, count := range scorelines {
+		statistics.Scorelines = append(statistics.Scorelines, entity.ScorelineCount{Scoreline: scoreline, Count: count})
+	}
+	sort.Slice(statistics.Scorelines, func(i, j int) bool {
+		a, b := statistics.Scorelines[i], statistics.Scorelines[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Scoreline < b.Scoreline
+	})
+	if len(statistics.Scorelines) > topScorelines {
+		statistics.Scorelines = statistics.Scorelines[:topScorelines]
+	}
+
+	for team, count := range cleanSheets {
+		statistics.CleanSheets = append(statistics.CleanSheets, entity.CleanSheetCount{Team: team, CleanSheets: count})
+	}
+	sort.Slice(statistics.CleanSheets, func(i, j int) bool {
+		a, b := statistics.CleanSheets[i], statistics.CleanSheets[j]
+		return a.CleanSheets > b.CleanSheets || a.CleanSheets == b.CleanSheets && a.Team < b.Team
+	})
+
+	return statistics, nil
+}
+
+// UpsertMatchDataBatchInDB creates or updates all matches and returns one result per match.
+// Storing a match in memory cannot fail, so the batch is always stored completely.
+func (repository *MemoryRepository) UpsertMatchDataBatchInDB(ctx context.Context, data []entity.MatchData, allOrNothing bool) ([]entity.UpsertResult, error) {
+
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+
+	results := make([]entity.UpsertResult, len(data))
+	for i := range data {
+		created := repository.upsert(&data[i])
+		results[i] = entity.UpsertResult{Id: data[i].Id, Created: created, Attempted: true}
+	}
+
+	return results, nil
+}
+
+// WithinTransaction runs f and restores the matches of before, if f returns an error or panics. A nested unit of work
+// restores only its own changes, like a savepoint. The matches are not locked while f runs, so restoring them also
+// reverts the changes of concurrent calls, which is fine for development and tests.
+func (repository *MemoryRepository) WithinTransaction(ctx context.Context, f func(ctx context.Context) error) error {
+
+	repository.mutex.RLock()
+	matches := make(map[int]entity.MatchData, len(repository.matches))
+	for id, matchData := range repository.matches {
+		matches[id] = matchData
+	}
+	repository.mutex.RUnlock()
+
+	// like an auto increment column the ids are not reused after a rollback
+	restore := func() {
+		repository.mutex.Lock()
+		repository.matches = matches
+		repository.mutex.Unlock()
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			restore()
+			panic(p)
+		}
+	}()
+
+	err := f(ctx)
+	if err != nil {
+		restore()
+		return err
+	}
+
+	return nil
+}
+
+// create stores a new match, a match without id gets the next id like with an auto increment column
+func (repository *MemoryRepository) create(data *entity.MatchData) error {
+
+	if data.Id == 0 {
+		data.Id = repository.lastId + 1
+	}
+
+	if _, exists := repository.matches[data.Id]; exists {
+		return fmt.Errorf("duplicate entry '%d' for key 'id'", data.Id)
+	}
+
+	if data.Id > repository.lastId {
+		repository.lastId = data.Id
+	}
+	repository.matches[data.Id] = *data
+
+	return nil
+}
+
+// upsert replaces the match with the same id or, if no id is set, with the same teams and date.
+// If there is no such match, it is created. The returned bool is true, if the match was created.
+func (repository *MemoryRepository) upsert(data *entity.MatchData) bool {
+
+	if data.Id == 0 {
+		for id, existing := range repository.matches {
+			if existing.HomeTeam == data.HomeTeam && existing.AwayTeam == data.AwayTeam && existing.Date == data.Date {
+				data.Id = id
+				break
+			}
+		}
+	}
+
+	if _, exists := repository.matches[data.Id]; exists {
+		repository.matches[data.Id] = *data
+		return false
+	}
+
+	// the match does not exist, so creating it cannot fail
+	_ = repository.create(data)
+	return true
+}
+
+// sorted returns all matches ordered by date and id
+func (repository *MemoryRepository) sorted() []entity.MatchData {
+
+	data := make([]entity.MatchData, 0, len(repository.matches))
+	for _, matchData := range repository.matches {
+		data = append(data, matchData)
+	}
+
+	sort.Slice(data, func(i, j int) bool {
+		return data[i].Date < data[j].Date || data[i].Date == data[j].Date && data[i].Id < data[j].Id
+	})
+
+	return data
+}
+
+// project returns a copy of the match with only the fields of the projection. Like in MySQL the keys are always set.
+func project(data entity.MatchData, projection entity.Projection) entity.MatchData {
+
+	if projection.All() {
+		return data
+	}
+
+	result := entity.MatchData{Id: data.Id, Date: data.Date}
+	for _, field := range projection.Fields {
+		switch field {
+		case "AwayTeam":
+			result.AwayTeam = data.AwayTeam
+		case "HomeTeam":
+			result.HomeTeam = data.HomeTeam
+		case "MatchType":
+			result.MatchType = data.MatchType
+		case "Result":
+			result.Result = data.Result
+		case "AdditionalInformation":
+			result.AdditionalInformation = projectAdditionalInformation(data.AdditionalInformation, projection.AdditionalInformation)
+		}
+	}
+
+	return result
+}
+
+func projectAdditionalInformation(data entity.AdditionalInformation, fields []string) entity.AdditionalInformation {
+
+	if len(fields) == 0 {
+		return data
+	}
+
+	result := entity.AdditionalInformation{Additional: data.Additional}
+	result.ID = data.ID
+	for _, field := range fields {
+		if field == "Information" {
+			result.Information = data.Information
+		}
+	}
+
+	return result
+}
+
+// goals parses the result "<home goals>:<away goals>", ok is false for matches without a valid result
+func goals(result string) (home int, away int, ok bool) {
+
+	parts := strings.Split(result, ":")
+	if len(parts) != 2 || !isDigits(parts[0]) || !isDigits(parts[1]) {
+		return 0, 0, false
+	}
+
+	home, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	away, err = strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return home, away, true
+}
+
+// isDigits reports whether the number consists of digits only, without sign or spaces
+func isDigits(number string) bool {
+	return number != "" && strings.Trim(number, "0123456789") == ""
+}
+
+// season returns the season of the date "YYYY-MM-DD", a season starts in July,
+// e.g. a match on 2021-03-01 belongs to the season 2020/2021
+func season(date string) string {
+
+	if len(date) < 7 {
+		return ""
+	}
+
+	year, err := strconv.Atoi(date[0:4])
+	if err != nil {
+		return ""
+	}
+
+	month, err := strconv.Atoi(date[5:7])
+	if err != nil {
+		return ""
+	}
+
+	if month < 7 {
+		year--
+	}
+
+	return fmt.Sprintf("%d/%d", year, year+1)
+}
+
+func outcomeCount(counts map[string]*entity.OutcomeCount, name string) *entity.OutcomeCount {
+
+	count, ok := counts[name]
+	if !ok {
+		count = &entity.OutcomeCount{Name: name}
+		counts[name] = count
+	}
+
+	return count
+}
+
+func countOutcome(count *entity.OutcomeCount, home, away int) {
+	count.Matches++
+	count.HomeWins += boolToInt(home > away)
+	count.Draws += boolToInt(home == away)
+	count.AwayWins += boolToInt(home < away)
+	count.Goals += home + away
+}
+
+func sortedOutcomes(counts map[string]*entity.OutcomeCount) []entity.OutcomeCount {
+
+	result := make([]entity.OutcomeCount, 0, len(counts))
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+
+	return result
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,18 @@
+package repository_test
+
+import (
+	"sheazuzu/sheazuzu/src/repository"
+	"sheazuzu/sheazuzu/src/repository/repositorytest"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestMemoryRepository(t *testing.T) {
+	newRepository := func(t *testing.T) repository.Repository {
+		return repository.ProvideMemoryRepository(zap.NewNop().Sugar())
+	}
+
+	repositorytest.Run(t, newRepository)
+	repositorytest.RunSavepoints(t, newRepository)
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"go.uber.org/zap"
+	"sheazuzu/sheazuzu/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+)
+
+// MongoRepository stores the matches in the match data collection of MongoDB
+type MongoRepository struct {
+	Mongo  *database.MongoDatabase
+	logger *zap.SugaredLogger
+}
+
+func ProvideMongoRepository(Mongo *database.MongoDatabase, logger *zap.SugaredLogger) *MongoRepository {
+	return &MongoRepository{
+		Mongo:  Mongo,
+		logger: logger,
+	}
+}
+
+func (repository *MongoRepository) FindMatchDataByIdInDB(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error) {
+	return repository.Mongo.FindByID(ctx, id, projection)
+}
+
+func (repository *MongoRepository) FindAllMatchDataInDB(ctx context.Context, projection entity.Projection, page entity.Page) ([]entity.MatchData, error) {
+	return repository.Mongo.FindAll(ctx, projection, page)
+}
+
+func (repository *MongoRepository) FindPlayedMatchDataInDB(ctx context.Context) ([]entity.MatchData, error) {
+	return repository.Mongo.FindPlayed(ctx)
+}
+
+func (repository *MongoRepository) UpdateMatchDataInDB(ctx context.Context, data entity.MatchData) (string, int, error) {
+
+	err := repository.Mongo.Save(ctx, &data)
+	if err != nil {
+		return "failed - Mongo", 0, err
+	}
+
+	return "successful!", data.Id, nil
+}
+
+func (repository *MongoRepository) FindStatisticsInDB(ctx context.Context) (entity.Statistics, error) {
+	return repository.Mongo.FindStatistics(ctx)
+}
+
+func (repository *MongoRepository) UpsertMatchDataBatchInDB(ctx context.Context, data []entity.MatchData, allOrNothing bool) ([]entity.UpsertResult, error) {
+	return repository.Mongo.UpsertBatch(ctx, data, allOrNothing)
+}
+
+// WithinTransaction runs f in a MongoDB transaction, which needs a replica set. MongoDB has no savepoints, so a nested
+// unit of work is part of the outer one and only rolled back with it. A transaction failing with a transient error is
+// run again, so f must not have side effects besides the repository calls.
+func (repository *MongoRepository) WithinTransaction(ctx context.Context, f func(ctx context.Context) error) error {
+	return repository.Mongo.WithinTransaction(ctx, f)
+}
+
+// WithinSnapshot runs f with a session, whose reads see the matches as of its first read. It needs MongoDB 5.0.
+func (repository *MongoRepository) WithinSnapshot(ctx context.Context, f func(ctx context.Context) error) error {
+	return repository.Mongo.WithinSnapshot(ctx, f)
+}
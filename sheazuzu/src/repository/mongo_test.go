@@ -0,0 +1,42 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"sheazuzu/common/src/mongo"
+	"sheazuzu/sheazuzu/src/database"
+	"sheazuzu/sheazuzu/src/repository"
+	"sheazuzu/sheazuzu/src/repository/repositorytest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestMongoRepository needs a MongoDB replica set for the transactions, e.g. SHEAZUZU_TEST_MONGO_URI="mongodb://localhost:27017/?replicaSet=rs0".
+// The database sheazuzu_test is dropped.
+func TestMongoRepository(t *testing.T) {
+
+	uri := os.Getenv("SHEAZUZU_TEST_MONGO_URI")
+	if uri == "" {
+		t.Skip("SHEAZUZU_TEST_MONGO_URI is not set")
+	}
+
+	logger := zap.NewNop().Sugar()
+
+	repositorytest.Run(t, func(t *testing.T) repository.Repository {
+		mongoDatabase, err := mongo.NewMongoDatabase(&mongo.Config{URI: uri, Database: "sheazuzu_test", Timeout: mongo.DefaultTimeout}, logger)
+		require.NoError(t, err)
+
+		require.NoError(t, mongoDatabase.Connect(context.Background()))
+		t.Cleanup(func() { mongoDatabase.Disconnect(context.Background()) })
+
+		require.NoError(t, mongoDatabase.Database.Drop(context.Background()))
+
+		mongoRepository := database.NewMongoDatabase(mongoDatabase)
+		_, err = mongoRepository.ReconcileIndexes(context.Background(), mongo.ReconcileOptions{})
+		require.NoError(t, err)
+
+		return repository.ProvideMongoRepository(mongoRepository, logger)
+	})
+}
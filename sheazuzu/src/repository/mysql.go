@@ -0,0 +1,550 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"github.com/jinzhu/gorm"
+	"go.uber.org/zap"
+	commondb "sheazuzu/common/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+	"strconv"
+	"time"
+)
+
+// MySQLRepository stores the matches with gorm in MySQL, PostgreSQL or, for local development and tests, in SQLite.
+// All methods take part in the unit of work of their context, see WithinTransaction.
+// With outbox every write of a match also stores an entity.OutboxEntry in the same transaction, which the outbox relay
+// mirrors to MongoDB.
+// With replicas the reads outside of a unit of work are routed to the read replicas, see commondb.ReplicaRouter.
+type MySQLRepository struct {
+	DB       *gorm.DB
+	outbox   bool
+	replicas *commondb.ReplicaRouter
+	logger   *zap.SugaredLogger
+}
+
+func ProvideMySQLRepository(DB *gorm.DB, outbox bool, logger *zap.SugaredLogger) *MySQLRepository {
+	return &MySQLRepository{
+		DB:     DB,
+		outbox: outbox,
+		logger: logger,
+	}
+}
+
+// WithReplicas routes the reads to the replicas of the router, its primary has to be the DB of the repository
+func (repository *MySQLRepository) WithReplicas(replicas *commondb.ReplicaRouter) *MySQLRepository {
+	repository.replicas = replicas
+	return repository
+}
+
+func (repository *MySQLRepository) FindMatchDataByIdInDB(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error) {
+
+	var data entity.MatchData
+
+	db := selectProjection(repository.reader(ctx), projection).Where("id = ?", id).Find(&data)
+	if db.RecordNotFound() {
+		return entity.MatchData{}, entity.ErrNotFound
+	}
+	if db.Error != nil {
+		return entity.MatchData{}, db.Error
+	}
+
+	return data, nil
+}
+
+// FindAllMatchDataInDB returns the page of matches ordered by date and id, only the fields of the projection are loaded.
+// The page is read with a keyset query on the (date, id) index, so its cost does not grow with the position of the page.
+func (repository *MySQLRepository) FindAllMatchDataInDB(ctx context.Context, projection entity.Projection, page entity.Page) ([]entity.MatchData, error) {
+
+	var data []entity.MatchData
+
+	db := selectProjection(repository.reader(ctx), projection)
+	if page.After != nil {
+		db = db.Where("date > ? OR (date = ? AND id > ?)", page.After.Date, page.After.Date, page.After.Id)
+	}
+
+	db = db.Order("date").Order("id").Limit(page.Limit).Find(&data)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return data, nil
+}
+
+// selectProjection restricts the selected columns and the preloaded AdditionalInformation to the projection.
+// The keys are always selected, as the AdditionalInformation is loaded by the id of the match and the pages are
+// continued after the date and id of their last match.
+func selectProjection(db *gorm.DB, projection entity.Projection) *gorm.DB {
+
+	if projection.All() {
+		return db.Preload("AdditionalInformation")
+	}
+
+	columns := []string{"id", "date"}
+	for _, field := range projection.Fields {
+		if field != "Id" && field != "Date" && field != "AdditionalInformation" {
+			columns = append(columns, gorm.ToColumnName(field))
+		}
+	}
+	db = db.Select(columns)
+
+	if !projection.Has("AdditionalInformation") {
+		return db
+	}
+
+	if len(projection.AdditionalInformation) == 0 {
+		return db.Preload("AdditionalInformation")
+	}
+
+	additionalColumns := []string{"id", "additional"}
+	for _, field := range projection.AdditionalInformation {
+		if field != "Additional" {
+			additionalColumns = append(additionalColumns, gorm.ToColumnName(field))
+		}
+	}
+
+	return db.Preload("AdditionalInformation", func(db *gorm.DB) *gorm.DB {
+		return db.Select(additionalColumns)
+	})
+}
+
+// FindPlayedMatchDataInDB returns all matches with a result
+func (repository *MySQLRepository) FindPlayedMatchDataInDB(ctx context.Context) ([]entity.MatchData, error) {
+
+	var data []entity.MatchData
+
+	db := repository.reader(ctx).Where(hasResultSql).Order("date").Find(&data)
+	if db.Error != nil {
+		return nil, db.Error
+	}
+
+	return data, nil
+}
+
+// UpdateMatchDataInDB creates the match and its AdditionalInformation in a unit of work
+func (repository *MySQLRepository) UpdateMatchDataInDB(ctx context.Context, data entity.MatchData) (string, int, error) {
+
+	err := repository.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := repository.create(repository.conn(ctx), &data)
+		if err != nil {
+			return err
+		}
+		return repository.writeOutbox(repository.conn(ctx), data)
+	})
+	if err != nil {
+		return "failed - mySQL", 0, err
+	}
+
+	return "successful!", data.Id, nil
+}
+
+// create inserts the match and its AdditionalInformation. Unlike MySQL and SQLite, PostgreSQL does not advance the
+// sequence of the ids, if a match is inserted with an id, so the sequence is moved to the largest id afterwards.
+func (repository *MySQLRepository) create(db *gorm.DB, data *entity.MatchData) error {
+
+	explicitId := data.Id != 0
+
+	err := db.Set("gorm:save_associations", false).Create(data).Error
+	if err != nil {
+		return err
+	}
+
+	if explicitId && db.Dialect().GetName() == "postgres" {
+		table := db.NewScope(data).TableName()
+		err = db.Exec("SELECT setval(pg_get_serial_sequence(?, 'id'), (SELECT MAX(id) FROM "+table+"))", table).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	return saveAdditionalInformation(db, data)
+}
+
+// saveAdditionalInformation stores the AdditionalInformation of the match. gorm would convert the id of the match to
+// the character with this code for the string column additional, so the reference is set here. Without an id the
+// stored AdditionalInformation of the match is updated instead of inserting a second row.
+func saveAdditionalInformation(db *gorm.DB, data *entity.MatchData) error {
+
+	info := &data.AdditionalInformation
+	info.Additional = strconv.Itoa(data.Id)
+
+	if info.ID == 0 {
+		var stored entity.AdditionalInformation
+		found := db.Where("additional = ?", info.Additional).First(&stored)
+		if found.Error != nil && !found.RecordNotFound() {
+			return found.Error
+		}
+		info.ID = stored.ID
+		info.CreatedAt = stored.CreatedAt
+	}
+
+	return db.Save(info).Error
+}
+
+// writeOutbox stores the written match in the outbox, if it is enabled. It has to be called in the transaction of the write.
+func (repository *MySQLRepository) writeOutbox(tx *gorm.DB, data entity.MatchData) error {
+
+	if !repository.outbox {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&entity.OutboxEntry{MatchId: data.Id, Payload: string(payload), NextAttemptAt: time.Now()}).Error
+}
+
+// the result of a match is stored as "<home goals>:<away goals>", matches without a result are ignored by all statistics
+const hasResultSql = "result LIKE '%:%'"
+
+// statisticsSql contains the expressions of the statistics, which differ between the SQL dialects
+type statisticsSql struct {
+	// hasResult selects the matches with a well-formed result, like "2:1", the others are ignored by the statistics
+	hasResult string
+	homeGoals string
+	awayGoals string
+	// a season starts in July, e.g. a match on 2021-03-01 belongs to the season 2020/2021
+	season    string
+	scoreline string
+}
+
+var mysqlStatistics = statisticsSql{
+	hasResult: "result REGEXP '^[0-9]+:[0-9]+$'",
+	homeGoals: "CAST(SUBSTRING_INDEX(result, ':', 1) AS UNSIGNED)",
+	awayGoals: "CAST(SUBSTRING_INDEX(result, ':', -1) AS UNSIGNED)",
+	season: "CASE WHEN CAST(SUBSTRING(date, 6, 2) AS UNSIGNED) >= 7 " +
+		"THEN CONCAT(LEFT(date, 4), '/', LEFT(date, 4) + 1) " +
+		"ELSE CONCAT(LEFT(date, 4) - 1, '/', LEFT(date, 4)) END",
+	scoreline: "CONCAT(CAST(SUBSTRING_INDEX(result, ':', 1) AS UNSIGNED), ':', CAST(SUBSTRING_INDEX(result, ':', -1) AS UNSIGNED))",
+}
+
+var sqliteStatistics = statisticsSql{
+	// SQLite has no REGEXP, the patterns require digits around exactly one colon
+	hasResult: "result GLOB '[0-9]*:[0-9]*' AND result NOT GLOB '*[^0-9:]*' AND result NOT GLOB '*:*:*'",
+	homeGoals: "CAST(substr(result, 1, instr(result, ':') - 1) AS INTEGER)",
+	awayGoals: "CAST(substr(result, instr(result, ':') + 1) AS INTEGER)",
+	season: "CASE WHEN CAST(substr(date, 6, 2) AS INTEGER) >= 7 " +
+		"THEN substr(date, 1, 4) || '/' || (CAST(substr(date, 1, 4) AS INTEGER) + 1) " +
+		"ELSE (CAST(substr(date, 1, 4) AS INTEGER) - 1) || '/' || substr(date, 1, 4) END",
+	scoreline: "CAST(substr(result, 1, instr(result, ':') - 1) AS INTEGER) || ':' || CAST(substr(result, instr(result, ':') + 1) AS INTEGER)",
+}
+
+var postgresStatistics = statisticsSql{
+	hasResult: "result ~ '^[0-9]+:[0-9]+$'",
+	homeGoals: "CAST(split_part(result, ':', 1) AS INTEGER)",
+	awayGoals: "CAST(split_part(result, ':', 2) AS INTEGER)",
+	season: "CASE WHEN CAST(substr(date, 6, 2) AS INTEGER) >= 7 " +
+		"THEN substr(date, 1, 4) || '/' || (CAST(substr(date, 1, 4) AS INTEGER) + 1) " +
+		"ELSE (CAST(substr(date, 1, 4) AS INTEGER) - 1) || '/' || substr(date, 1, 4) END",
+	scoreline: "CAST(split_part(result, ':', 1) AS INTEGER) || ':' || CAST(split_part(result, ':', 2) AS INTEGER)",
+}
+
+// outcomes returns the columns of an entity.OutcomeCount
+func (expressions statisticsSql) outcomes() string {
+	return "COUNT(*) AS matches, " +
+		"COALESCE(SUM(CASE WHEN " + expressions.homeGoals + " > " + expressions.awayGoals + " THEN 1 ELSE 0 END), 0) AS home_wins, " +
+		"COALESCE(SUM(CASE WHEN " + expressions.homeGoals + " = " + expressions.awayGoals + " THEN 1 ELSE 0 END), 0) AS draws, " +
+		"COALESCE(SUM(CASE WHEN " + expressions.homeGoals + " < " + expressions.awayGoals + " THEN 1 ELSE 0 END), 0) AS away_wins, " +
+		"COALESCE(SUM(" + expressions.homeGoals + " + " + expressions.awayGoals + "), 0) AS goals"
+}
+
+// statisticsSql returns the expressions of the statistics for the dialect of the database
+func (repository *MySQLRepository) statisticsSql() statisticsSql {
+
+	switch repository.DB.Dialect().GetName() {
+	case "postgres":
+		return postgresStatistics
+	case "sqlite3":
+		return sqliteStatistics
+	default:
+		return mysqlStatistics
+	}
+}
+
+func (repository *MySQLRepository) FindStatisticsInDB(ctx context.Context) (entity.Statistics, error) {
+
+	var statistics entity.Statistics
+	expressions := repository.statisticsSql()
+	outcomesSql := expressions.outcomes()
+
+	matches := func() *gorm.DB {
+		return repository.reader(ctx).Model(&entity.MatchData{}).Where(expressions.hasResult)
+	}
+
+	db := matches().Select(outcomesSql).Scan(&statistics.Overall)
+	if db.Error != nil {
+		return entity.Statistics{}, db.Error
+	}
+
+	db = matches().Select("match_type AS name, " + outcomesSql).
+		Group("match_type").Order("match_type").Scan(&statistics.ByMatchType)
+	if db.Error != nil {
+		return entity.Statistics{}, db.Error
+	}
+
+	db = matches().Select(expressions.season + " AS name, " + outcomesSql).
+		Group("name").Order("name").Scan(&statistics.BySeason)
+	if db.Error != nil {
+		return entity.Statistics{}, db.Error
+	}
+
+	db = matches().Select(expressions.scoreline + " AS scoreline, COUNT(*) AS count").
+		Group("scoreline").Order("count DESC, scoreline").Limit(topScorelines).Scan(&statistics.Scorelines)
+	if db.Error != nil {
+		return entity.Statistics{}, db.Error
+	}
+
+	// a team keeps a clean sheet at home, if the away team did not score and vice versa
+	table := repository.DB.NewScope(&entity.MatchData{}).TableName()
+	db = repository.reader(ctx).Raw(
+		"SELECT team, SUM(clean_sheets) AS clean_sheets FROM (" +
+			"SELECT home_team AS team, SUM(CASE WHEN " + expressions.awayGoals + " = 0 THEN 1 ELSE 0 END) AS clean_sheets FROM " + table + " WHERE " + expressions.hasResult + " GROUP BY home_team " +
+			"UNION ALL " +
+			"SELECT away_team AS team, SUM(CASE WHEN " + expressions.homeGoals + " = 0 THEN 1 ELSE 0 END) AS clean_sheets FROM " + table + " WHERE " + expressions.hasResult + " GROUP BY away_team" +
+			") AS clean_sheets_per_side GROUP BY team ORDER BY clean_sheets DESC, team").
+		Scan(&statistics.CleanSheets)
+	if db.Error != nil {
+		return entity.Statistics{}, db.Error
+	}
+
+	return statistics, nil
+}
+
+// UpsertMatchDataBatchInDB creates or updates all matches and returns one result per match.
+// With allOrNothing all matches are written in one transaction, which is rolled back as soon as one match fails.
+// Otherwise every match is written in its own transaction, so a failing match does not affect the others.
+func (repository *MySQLRepository) UpsertMatchDataBatchInDB(ctx context.Context, data []entity.MatchData, allOrNothing bool) ([]entity.UpsertResult, error) {
+
+	results := make([]entity.UpsertResult, len(data))
+
+	if !allOrNothing {
+		for i := range data {
+			results[i] = repository.upsertInTransaction(ctx, &data[i])
+		}
+		return results, nil
+	}
+
+	failed := false
+	err := repository.WithinTransaction(ctx, func(ctx context.Context) error {
+
+		for i := range data {
+			created, err := repository.upsertMatchData(repository.conn(ctx), &data[i])
+			results[i] = entity.UpsertResult{Id: data[i].Id, Created: created, Attempted: true, Err: err}
+			if err == nil {
+				continue
+			}
+
+			failed = true
+			for j := 0; j < i; j++ {
+				results[j].RolledBack = true
+			}
+			return err
+		}
+
+		return nil
+	})
+	if failed {
+		return results, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (repository *MySQLRepository) upsertInTransaction(ctx context.Context, data *entity.MatchData) entity.UpsertResult {
+
+	created := false
+	err := repository.WithinTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		created, err = repository.upsertMatchData(repository.conn(ctx), data)
+		return err
+	})
+	if err != nil {
+		return entity.UpsertResult{Id: data.Id, Attempted: true, Err: err}
+	}
+
+	return entity.UpsertResult{Id: data.Id, Created: created, Attempted: true}
+}
+
+// upsertMatchData updates the match with the same id or, if no id is set, with the same teams and date.
+// If there is no such match, it is created. The returned bool is true, if the match was created.
+func (repository *MySQLRepository) upsertMatchData(db *gorm.DB, data *entity.MatchData) (bool, error) {
+
+	query := db.Where("id = ?", data.Id)
+	if data.Id == 0 {
+		query = db.Where("home_team = ? AND away_team = ? AND date = ?", data.HomeTeam, data.AwayTeam, data.Date)
+	}
+
+	var existing entity.MatchData
+	found := query.First(&existing)
+	if found.Error != nil && !gorm.IsRecordNotFoundError(found.Error) {
+		return false, found.Error
+	}
+
+	created := found.RecordNotFound()
+	if created {
+		err := repository.create(db, data)
+		if err != nil {
+			return false, err
+		}
+	} else {
+		data.Id = existing.Id
+		err := db.Set("gorm:save_associations", false).Save(data).Error
+		if err == nil {
+			err = saveAdditionalInformation(db, data)
+		}
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return created, repository.writeOutbox(db, *data)
+}
+
+// unitOfWork is the transaction of a WithinTransaction call, it is passed to the repository calls in the context
+type unitOfWork struct {
+	// db is the connection pool, the transaction was started on
+	db *gorm.DB
+	tx *gorm.DB
+	// depth is the number of enclosing units of work, a nested unit of work runs in the savepoint sp_<depth>
+	depth int
+}
+
+type unitOfWorkKey struct{}
+
+// conn returns the transaction of the unit of work of the context or, outside of a unit of work, the connection pool of
+// the primary.
+// The queries with it are traced as children of the span of the context.
+func (repository *MySQLRepository) conn(ctx context.Context) *gorm.DB {
+
+	if unit, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork); ok && unit.db == repository.DB {
+		return commondb.WithContext(unit.tx, ctx)
+	}
+
+	return commondb.WithContext(repository.DB, ctx)
+}
+
+// primaryReadKey marks the reads, which must not go to a replica, as they may lag behind the primary
+type primaryReadKey struct{}
+
+// withPrimaryRead returns a context, whose reads go to the primary
+func withPrimaryRead(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadKey{}, true)
+}
+
+// reader returns the connection of the reads, like conn, but outside of a unit of work the reads go to the replicas,
+// unless the context asks for the primary
+func (repository *MySQLRepository) reader(ctx context.Context) *gorm.DB {
+
+	if unit, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork); ok && unit.db == repository.DB {
+		return commondb.WithContext(unit.tx, ctx)
+	}
+
+	if repository.replicas != nil && ctx.Value(primaryReadKey{}) == nil {
+		return commondb.WithContext(repository.replicas.Read(ctx), ctx)
+	}
+
+	return commondb.WithContext(repository.DB, ctx)
+}
+
+// WithinTransaction runs f in a transaction, which the repository calls with the context passed to f take part in.
+// The transaction is rolled back, if f returns an error or panics, and committed otherwise. Within a unit of work f runs
+// in a savepoint instead, which is rolled back alone, so the outer unit of work can handle the error and continue.
+func (repository *MySQLRepository) WithinTransaction(ctx context.Context, f func(ctx context.Context) error) (err error) {
+
+	outer, nested := ctx.Value(unitOfWorkKey{}).(*unitOfWork)
+	if nested && outer.db == repository.DB {
+		return repository.withinSavepoint(ctx, outer, f)
+	}
+
+	tx := repository.DB.Begin()
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err = f(context.WithValue(ctx, unitOfWorkKey{}, &unitOfWork{db: repository.DB, tx: tx}))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = tx.Commit().Error
+	// the replicas may not have the written matches yet, so the next reads of the caller go to the primary
+	if err == nil && repository.replicas != nil {
+		repository.replicas.RecordWrite(ctx)
+	}
+
+	return err
+}
+
+// WithinSnapshot runs f in a read-only transaction with the isolation level repeatable read, so all reads of f see the
+// matches as of its first read. SQLite ignores the options, but its transactions are serializable anyway. The reads
+// of the snapshot go to the primary.
+func (repository *MySQLRepository) WithinSnapshot(ctx context.Context, f func(ctx context.Context) error) error {
+
+	if unit, ok := ctx.Value(unitOfWorkKey{}).(*unitOfWork); ok && unit.db == repository.DB {
+		return f(ctx)
+	}
+
+	tx := repository.DB.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if tx.Error != nil {
+		return tx.Error
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	err := f(context.WithValue(ctx, unitOfWorkKey{}, &unitOfWork{db: repository.DB, tx: tx}))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (repository *MySQLRepository) withinSavepoint(ctx context.Context, outer *unitOfWork, f func(ctx context.Context) error) error {
+
+	unit := &unitOfWork{db: outer.db, tx: outer.tx, depth: outer.depth + 1}
+	savepoint := fmt.Sprintf("sp_%d", unit.depth)
+
+	err := unit.tx.Exec("SAVEPOINT " + savepoint).Error
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			unit.tx.Exec("ROLLBACK TO SAVEPOINT " + savepoint)
+			panic(p)
+		}
+	}()
+
+	err = f(context.WithValue(ctx, unitOfWorkKey{}, unit))
+	if err != nil {
+		rollbackErr := unit.tx.Exec("ROLLBACK TO SAVEPOINT " + savepoint).Error
+		if rollbackErr != nil {
+			repository.logger.Errorw("error rolling back to the savepoint", "savepoint", savepoint, "error", rollbackErr)
+		}
+		return err
+	}
+
+	return unit.tx.Exec("RELEASE SAVEPOINT " + savepoint).Error
+}
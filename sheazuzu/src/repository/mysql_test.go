@@ -0,0 +1,43 @@
+package repository_test
+
+import (
+	"context"
+	"os"
+	"sheazuzu/sheazuzu/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/repository"
+	"sheazuzu/sheazuzu/src/repository/repositorytest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestMySQLRepository needs a MySQL database, e.g. SHEAZUZU_TEST_MYSQL_DSN="root:secret@/sheazuzu_test?charset=utf8&parseTime=True".
+// The schema is migrated and all matches in the database are deleted.
+func TestMySQLRepository(t *testing.T) {
+
+	dsn := os.Getenv("SHEAZUZU_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("SHEAZUZU_TEST_MYSQL_DSN is not set")
+	}
+
+	newRepository := func(t *testing.T) repository.Repository {
+		db, err := database.OpenDB("mysql", dsn)
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+		require.NoError(t, err)
+		_, err = migrator.Up(context.Background(), 0)
+		require.NoError(t, err)
+
+		db.Delete(&entity.MatchData{})
+		db.Unscoped().Delete(&entity.AdditionalInformation{})
+
+		return repository.ProvideMySQLRepository(db, false, zap.NewNop().Sugar())
+	}
+
+	repositorytest.Run(t, newRepository)
+	repositorytest.RunSavepoints(t, newRepository)
+}
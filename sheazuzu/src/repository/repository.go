@@ -1,63 +1,57 @@
 package repository
 
 import (
-	"github.com/jinzhu/gorm"
-	"go.uber.org/zap"
-	"sheazuzu/sheazuzu/src/database"
+	"context"
 	"sheazuzu/sheazuzu/src/entity"
 )
 
-type SheazuzuRepository struct {
-	DB     *gorm.DB
-	Mongo  *database.MongoDatabase
-	logger *zap.SugaredLogger
+// Repository is the storage of the matches, implemented by every storage backend.
+// A missing match is reported with entity.ErrNotFound, all other errors are specific to the backend.
+// All methods take part in the unit of work of their context, see Transactor.
+type Repository interface {
+	Transactor
+	// FindMatchDataByIdInDB returns the match with the given id, only the fields of the projection are loaded.
+	FindMatchDataByIdInDB(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error)
+	// FindAllMatchDataInDB returns the page of matches ordered by date and id, only the fields of the projection are loaded.
+	FindAllMatchDataInDB(ctx context.Context, projection entity.Projection, page entity.Page) ([]entity.MatchData, error)
+	// FindPlayedMatchDataInDB returns all matches with a result ordered by date.
+	FindPlayedMatchDataInDB(ctx context.Context) ([]entity.MatchData, error)
+	// UpdateMatchDataInDB creates the match and returns its id. A match without id gets the next free id.
+	UpdateMatchDataInDB(ctx context.Context, data entity.MatchData) (string, int, error)
+	// FindStatisticsInDB aggregates the statistics of all matches with a result.
+	FindStatisticsInDB(ctx context.Context) (entity.Statistics, error)
+	// UpsertMatchDataBatchInDB creates or updates all matches and returns one result per match.
+	UpsertMatchDataBatchInDB(ctx context.Context, data []entity.MatchData, allOrNothing bool) ([]entity.UpsertResult, error)
 }
 
-func ProvideSheazuzuRepository(DB *gorm.DB, Mongo *database.MongoDatabase, logger *zap.SugaredLogger) *SheazuzuRepository {
-	return &SheazuzuRepository{
-		DB:     DB,
-		Mongo:  Mongo,
-		logger: logger,
-	}
+// Transactor groups repository calls into a unit of work.
+type Transactor interface {
+	// WithinTransaction runs f in a unit of work, which the repository calls with the context passed to f take part in.
+	// It is rolled back, if f returns an error or panics, and committed otherwise. A nested unit of work is rolled back
+	// alone, where the backend supports savepoints.
+	WithinTransaction(ctx context.Context, f func(ctx context.Context) error) error
 }
 
-func (repository *SheazuzuRepository) FindMatchDataByIdInDB(id int) (entity.MatchData, error) {
-
-	var data entity.MatchData
-
-	db := repository.DB.Preload("AdditionalInformation").Where("id = ?", id).Find(&data)
-	if db.Error != nil {
-		return entity.MatchData{}, db.Error
-	}
-
-	/*
-		mongoEntity, err := repository.Mongo.FindByID(context.Background(), id)
-		if err != nil {
-			return entity.MatchData{}, db.Error
-		}
-
-		fmt.Println("mongoEntity", mongoEntity)
-
-
-	*/
-	return data, nil
+// SnapshotReader is implemented by the backends, which can read the matches from one consistent snapshot.
+type SnapshotReader interface {
+	// WithinSnapshot runs f in a read-only unit of work, whose reads with the context passed to f all see the matches
+	// as of its start, so f can read them in several pages without seeing concurrent writes. Within a unit of work f
+	// runs in it.
+	WithinSnapshot(ctx context.Context, f func(ctx context.Context) error) error
 }
 
-func (repository *SheazuzuRepository) UpdateMatchDataInDB(data entity.MatchData) (string, int, error) {
-
-	db := repository.DB.Create(&data)
-	if db.Error != nil {
-		return "failed - mySQL", 0, db.Error
-	}
-
-	/*
-		err := repository.Mongo.Save(context.Background(), data)
-		if err != nil {
-			return "failed - Mongo", 0, db.Error
-		}
-
-	*/
+var (
+	_ SnapshotReader = (*MySQLRepository)(nil)
+	_ SnapshotReader = (*MongoRepository)(nil)
+	_ SnapshotReader = (*CachedRepository)(nil)
+)
 
-	return "successful!", data.Id, nil
+var (
+	_ Repository = (*MySQLRepository)(nil)
+	_ Repository = (*MongoRepository)(nil)
+	_ Repository = (*MemoryRepository)(nil)
+	_ Repository = (*CachedRepository)(nil)
+)
 
-}
+// number of the most frequent scorelines in the statistics
+const topScorelines = 10
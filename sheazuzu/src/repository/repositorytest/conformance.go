@@ -0,0 +1,480 @@
+// Package repositorytest provides the conformance test suite of the storage backends. Every implementation of
+// repository.Repository has to pass it, so the service behaves the same with every backend.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/repository"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Run runs the conformance tests against the repositories created by newRepository.
+// Every test gets a new repository, which has to be empty.
+func Run(t *testing.T, newRepository func(t *testing.T) repository.Repository) {
+
+	tests := map[string]func(t *testing.T, repo repository.Repository){
+		"create and find by id":           testCreateAndFind,
+		"find missing match":              testFindMissing,
+		"create with existing id":         testCreateExistingId,
+		"projection":                      testProjection,
+		"pages":                           testPages,
+		"played matches":                  testPlayed,
+		"statistics":                      testStatistics,
+		"statistics of a season":          testStatisticsSeason,
+		"statistics without matches":      testStatisticsEmpty,
+		"statistics of malformed results": testStatisticsMalformed,
+		"upsert best effort":              testUpsertBestEffort,
+		"upsert all or nothing":           testUpsertAllOrNothing,
+		"created ids follow stored ids":   testCreateAfterExplicitId,
+		"unit of work commits":            testUnitOfWorkCommit,
+		"unit of work rolls back":         testUnitOfWorkRollback,
+		"unit of work rolls back panic":   testUnitOfWorkPanic,
+		"nested unit of work":             testNestedUnitOfWork,
+	}
+
+	// the tests share the database of the backend, so they must not run in parallel
+	for name, test := range tests {
+		test := test
+		t.Run(name, func(t *testing.T) {
+			test(t, newRepository(t))
+		})
+	}
+}
+
+var ctx = context.Background()
+
+// matches is a season of a small league, the last match is not played yet
+var matches = []entity.MatchData{
+	{Date: "2020-08-01", HomeTeam: "Arsenal", AwayTeam: "Burnley", MatchType: "league", Result: "2:1"},
+	{Date: "2021-03-01", HomeTeam: "Burnley", AwayTeam: "Arsenal", MatchType: "league", Result: "0:0"},
+	{Date: "2021-08-01", HomeTeam: "Arsenal", AwayTeam: "Chelsea", MatchType: "cup", Result: "1:3"},
+	{Date: "2021-09-01", HomeTeam: "Chelsea", AwayTeam: "Burnley", MatchType: "cup", Result: ""},
+}
+
+// create stores the matches and returns them with their ids
+func create(t *testing.T, repo repository.Repository, data ...entity.MatchData) []entity.MatchData {
+
+	created := make([]entity.MatchData, 0, len(data))
+	for _, matchData := range data {
+		_, id, err := repo.UpdateMatchDataInDB(ctx, matchData)
+		require.NoError(t, err)
+		require.NotZero(t, id)
+
+		matchData.Id = id
+		created = append(created, matchData)
+	}
+
+	return created
+}
+
+// assertMatches compares the columns of the matches, the additional information carries timestamps set by the backend
+func assertMatches(t *testing.T, expected, actual []entity.MatchData) {
+
+	strip := func(data []entity.MatchData) []entity.MatchData {
+		result := make([]entity.MatchData, 0, len(data))
+		for _, matchData := range data {
+			matchData.AdditionalInformation = entity.AdditionalInformation{}
+			result = append(result, matchData)
+		}
+		return result
+	}
+
+	assert.Equal(t, strip(expected), strip(actual))
+}
+
+func testCreateAndFind(t *testing.T, repo repository.Repository) {
+
+	created := create(t, repo, matches...)
+
+	for _, expected := range created {
+		actual, err := repo.FindMatchDataByIdInDB(ctx, expected.Id, entity.Projection{})
+		require.NoError(t, err)
+		assertMatches(t, []entity.MatchData{expected}, []entity.MatchData{actual})
+	}
+}
+
+func testFindMissing(t *testing.T, repo repository.Repository) {
+
+	created := create(t, repo, matches[0])
+
+	_, err := repo.FindMatchDataByIdInDB(ctx, created[0].Id+1, entity.Projection{})
+	assert.True(t, errors.Is(err, entity.ErrNotFound), "expected entity.ErrNotFound, got %v", err)
+}
+
+func testCreateExistingId(t *testing.T, repo repository.Repository) {
+
+	created := create(t, repo, matches[0])
+
+	duplicate := matches[1]
+	duplicate.Id = created[0].Id
+	_, _, err := repo.UpdateMatchDataInDB(ctx, duplicate)
+	assert.Error(t, err)
+
+	actual, err := repo.FindMatchDataByIdInDB(ctx, created[0].Id, entity.Projection{})
+	require.NoError(t, err)
+	assertMatches(t, created, []entity.MatchData{actual})
+}
+
+func testProjection(t *testing.T, repo repository.Repository) {
+
+	created := create(t, repo, matches[0])
+
+	actual, err := repo.FindMatchDataByIdInDB(ctx, created[0].Id, entity.Projection{Fields: []string{"HomeTeam", "Result"}})
+	require.NoError(t, err)
+
+	// the keys are always loaded
+	expected := entity.MatchData{Id: created[0].Id, Date: created[0].Date, HomeTeam: created[0].HomeTeam, Result: created[0].Result}
+	assertMatches(t, []entity.MatchData{expected}, []entity.MatchData{actual})
+
+	page, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{Fields: []string{"AwayTeam"}}, entity.Page{Limit: 10})
+	require.NoError(t, err)
+
+	expected = entity.MatchData{Id: created[0].Id, Date: created[0].Date, AwayTeam: created[0].AwayTeam}
+	assertMatches(t, []entity.MatchData{expected}, page)
+}
+
+func testPages(t *testing.T, repo repository.Repository) {
+
+	// two matches on the same day are ordered by their id
+	sameDay := matches[3]
+	sameDay.HomeTeam, sameDay.AwayTeam = "Burnley", "Chelsea"
+
+	// created in reverse order, so the order of the ids differs from the order of the dates
+	created := create(t, repo, matches[3], sameDay, matches[2], matches[1], matches[0])
+	ordered := []entity.MatchData{created[4], created[3], created[2], created[0], created[1]}
+
+	first, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 2})
+	require.NoError(t, err)
+	assertMatches(t, ordered[0:2], first)
+
+	second, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 2, After: &entity.PageKey{Date: first[1].Date, Id: first[1].Id}})
+	require.NoError(t, err)
+	assertMatches(t, ordered[2:4], second)
+
+	last, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 2, After: &entity.PageKey{Date: second[1].Date, Id: second[1].Id}})
+	require.NoError(t, err)
+	assertMatches(t, ordered[4:], last)
+
+	empty, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 2, After: &entity.PageKey{Date: last[0].Date, Id: last[0].Id}})
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func testPlayed(t *testing.T, repo repository.Repository) {
+
+	created := create(t, repo, matches[2], matches[3], matches[0], matches[1])
+
+	played, err := repo.FindPlayedMatchDataInDB(ctx)
+	require.NoError(t, err)
+	assertMatches(t, []entity.MatchData{created[2], created[3], created[0]}, played)
+}
+
+func testStatistics(t *testing.T, repo repository.Repository) {
+
+	create(t, repo, matches...)
+
+	statistics, err := repo.FindStatisticsInDB(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.OutcomeCount{Matches: 3, HomeWins: 1, Draws: 1, AwayWins: 1, Goals: 7}, statistics.Overall)
+	assert.Equal(t, []entity.OutcomeCount{
+		{Name: "cup", Matches: 1, AwayWins: 1, Goals: 4},
+		{Name: "league", Matches: 2, HomeWins: 1, Draws: 1, Goals: 3},
+	}, statistics.ByMatchType)
+	assert.Equal(t, []entity.OutcomeCount{
+		{Name: "2020/2021", Matches: 2, HomeWins: 1, Draws: 1, Goals: 3},
+		{Name: "2021/2022", Matches: 1, AwayWins: 1, Goals: 4},
+	}, statistics.BySeason)
+	assert.Equal(t, []entity.ScorelineCount{
+		{Scoreline: "0:0", Count: 1},
+		{Scoreline: "1:3", Count: 1},
+		{Scoreline: "2:1", Count: 1},
+	}, statistics.Scorelines)
+	assert.Equal(t, []entity.CleanSheetCount{
+		{Team: "Arsenal", CleanSheets: 1},
+		{Team: "Burnley", CleanSheets: 1},
+		{Team: "Chelsea", CleanSheets: 0},
+	}, statistics.CleanSheets)
+}
+
+// season is a fixture with more scorelines than the statistics list, a season boundary and matches without result
+var season = []entity.MatchData{
+	{Date: "2021-06-30", HomeTeam: "Arsenal", AwayTeam: "Burnley", MatchType: "league", Result: "1:0"},
+	{Date: "2021-07-01", HomeTeam: "Burnley", AwayTeam: "Arsenal", MatchType: "league", Result: "1:0"},
+	{Date: "2021-07-02", HomeTeam: "Arsenal", AwayTeam: "Chelsea", MatchType: "cup", Result: "0:0"},
+	{Date: "2021-08-01", HomeTeam: "Chelsea", AwayTeam: "Arsenal", MatchType: "league", Result: "2:2"},
+	{Date: "2021-08-02", HomeTeam: "Burnley", AwayTeam: "Chelsea", MatchType: "league", Result: "1:0"},
+	{Date: "2021-08-03", HomeTeam: "Chelsea", AwayTeam: "Burnley", MatchType: "league", Result: "3:1"},
+	{Date: "2021-08-04", HomeTeam: "Arsenal", AwayTeam: "Burnley", MatchType: "league", Result: "4:0"},
+	{Date: "2021-08-05", HomeTeam: "Burnley", AwayTeam: "Arsenal", MatchType: "cup", Result: "0:5"},
+	{Date: "2021-08-06", HomeTeam: "Chelsea", AwayTeam: "Arsenal", MatchType: "league", Result: "2:3"},
+	{Date: "2021-08-07", HomeTeam: "Arsenal", AwayTeam: "Chelsea", MatchType: "league", Result: "6:1"},
+	{Date: "2021-08-08", HomeTeam: "Burnley", AwayTeam: "Chelsea", MatchType: "league", Result: "0:2"},
+	{Date: "2021-08-09", HomeTeam: "Chelsea", AwayTeam: "Burnley", MatchType: "cup", Result: "1:1"},
+	{Date: "2021-08-10", HomeTeam: "Arsenal", AwayTeam: "Burnley", MatchType: "league", Result: "7:0"},
+	{Date: "2021-08-11", HomeTeam: "Burnley", AwayTeam: "Arsenal", MatchType: "league", Result: "postponed"},
+	{Date: "2021-08-12", HomeTeam: "Chelsea", AwayTeam: "Arsenal", MatchType: "league", Result: ""},
+}
+
+func testStatisticsSeason(t *testing.T, repo repository.Repository) {
+
+	create(t, repo, season...)
+
+	statistics, err := repo.FindStatisticsInDB(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.OutcomeCount{Matches: 13, HomeWins: 7, Draws: 3, AwayWins: 3, Goals: 43}, statistics.Overall)
+	assert.Equal(t, []entity.OutcomeCount{
+		{Name: "cup", Matches: 3, Draws: 2, AwayWins: 1, Goals: 7},
+		{Name: "league", Matches: 10, HomeWins: 7, Draws: 1, AwayWins: 2, Goals: 36},
+	}, statistics.ByMatchType)
+	// a season starts on the 1st of July
+	assert.Equal(t, []entity.OutcomeCount{
+		{Name: "2020/2021", Matches: 1, HomeWins: 1, Goals: 1},
+		{Name: "2021/2022", Matches: 12, HomeWins: 6, Draws: 3, AwayWins: 3, Goals: 42},
+	}, statistics.BySeason)
+	// the 10 most frequent scorelines, 7:0 is the 11th
+	assert.Equal(t, []entity.ScorelineCount{
+		{Scoreline: "1:0", Count: 3},
+		{Scoreline: "0:0", Count: 1},
+		{Scoreline: "0:2", Count: 1},
+		{Scoreline: "0:5", Count: 1},
+		{Scoreline: "1:1", Count: 1},
+		{Scoreline: "2:2", Count: 1},
+		{Scoreline: "2:3", Count: 1},
+		{Scoreline: "3:1", Count: 1},
+		{Scoreline: "4:0", Count: 1},
+		{Scoreline: "6:1", Count: 1},
+	}, statistics.Scorelines)
+	assert.Equal(t, []entity.CleanSheetCount{
+		{Team: "Arsenal", CleanSheets: 5},
+		{Team: "Burnley", CleanSheets: 2},
+		{Team: "Chelsea", CleanSheets: 2},
+	}, statistics.CleanSheets)
+}
+
+func testStatisticsEmpty(t *testing.T, repo repository.Repository) {
+
+	create(t, repo, matches[3])
+
+	statistics, err := repo.FindStatisticsInDB(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.OutcomeCount{}, statistics.Overall)
+	assert.Empty(t, statistics.ByMatchType)
+	assert.Empty(t, statistics.BySeason)
+	assert.Empty(t, statistics.Scorelines)
+	assert.Empty(t, statistics.CleanSheets)
+}
+
+// testStatisticsMalformed ignores the matches, whose result is no "<home goals>:<away goals>"
+func testStatisticsMalformed(t *testing.T, repo repository.Repository) {
+
+	create(t, repo, matches[0])
+	for _, result := range []string{"2:x", "3 : 1", ":", "1:2:3", "-1:2"} {
+		create(t, repo, entity.MatchData{Date: "2021-03-01", HomeTeam: "Burnley", AwayTeam: "Arsenal", MatchType: "league", Result: result})
+	}
+
+	statistics, err := repo.FindStatisticsInDB(ctx)
+	require.NoError(t, err)
+
+	assert.Equal(t, entity.OutcomeCount{Matches: 1, HomeWins: 1, Goals: 3}, statistics.Overall)
+	assert.Equal(t, []entity.ScorelineCount{{Scoreline: "2:1", Count: 1}}, statistics.Scorelines)
+	assert.Equal(t, []entity.CleanSheetCount{
+		{Team: "Arsenal", CleanSheets: 0},
+		{Team: "Burnley", CleanSheets: 0},
+	}, statistics.CleanSheets)
+}
+
+func testUpsertBestEffort(t *testing.T, repo repository.Repository) {
+
+	created := create(t, repo, matches[0], matches[1])
+
+	byId := created[0]
+	byId.Result = "3:1"
+
+	// without id the match is identified by its teams and date
+	byTeamsAndDate := matches[1]
+	byTeamsAndDate.Result = "1:1"
+
+	results, err := repo.UpsertMatchDataBatchInDB(ctx, []entity.MatchData{byId, byTeamsAndDate, matches[2]}, false)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, entity.UpsertResult{Id: created[0].Id, Created: false, Attempted: true}, results[0])
+	assert.Equal(t, entity.UpsertResult{Id: created[1].Id, Created: false, Attempted: true}, results[1])
+	assert.True(t, results[2].Created)
+	assert.True(t, results[2].Attempted)
+	assert.NoError(t, results[2].Err)
+	assert.NotZero(t, results[2].Id)
+
+	byTeamsAndDate.Id = created[1].Id
+	inserted := matches[2]
+	inserted.Id = results[2].Id
+
+	all, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 10})
+	require.NoError(t, err)
+	assertMatches(t, []entity.MatchData{byId, byTeamsAndDate, inserted}, all)
+}
+
+func testUpsertAllOrNothing(t *testing.T, repo repository.Repository) {
+
+	created := create(t, repo, matches[0])
+
+	updated := created[0]
+	updated.Result = "0:5"
+
+	results, err := repo.UpsertMatchDataBatchInDB(ctx, []entity.MatchData{updated, matches[1]}, true)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+
+	for _, result := range results {
+		assert.NoError(t, result.Err)
+		assert.True(t, result.Attempted)
+		assert.False(t, result.RolledBack)
+	}
+	assert.False(t, results[0].Created)
+	assert.True(t, results[1].Created)
+
+	inserted := matches[1]
+	inserted.Id = results[1].Id
+
+	all, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 10})
+	require.NoError(t, err)
+	assertMatches(t, []entity.MatchData{updated, inserted}, all)
+}
+
+func testCreateAfterExplicitId(t *testing.T, repo repository.Repository) {
+
+	explicit := matches[0]
+	explicit.Id = 1000
+	created := create(t, repo, explicit, matches[1])
+
+	assert.Equal(t, 1000, created[0].Id)
+	assert.Greater(t, created[1].Id, 1000)
+}
+
+func testUnitOfWorkCommit(t *testing.T, repo repository.Repository) {
+
+	var created []entity.MatchData
+	err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		for _, matchData := range matches[:2] {
+			_, id, err := repo.UpdateMatchDataInDB(ctx, matchData)
+			if err != nil {
+				return err
+			}
+			matchData.Id = id
+			created = append(created, matchData)
+		}
+
+		// the unit of work reads its own writes
+		_, err := repo.FindMatchDataByIdInDB(ctx, created[0].Id, entity.Projection{})
+		return err
+	})
+	require.NoError(t, err)
+
+	all, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 10})
+	require.NoError(t, err)
+	assertMatches(t, created, all)
+}
+
+func testUnitOfWorkRollback(t *testing.T, repo repository.Repository) {
+
+	created := create(t, repo, matches[0])
+
+	failure := errors.New("failure")
+	err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, _, err := repo.UpdateMatchDataInDB(ctx, matches[1])
+		if err != nil {
+			return err
+		}
+
+		updated := created[0]
+		updated.Result = "9:9"
+		_, err = repo.UpsertMatchDataBatchInDB(ctx, []entity.MatchData{updated}, true)
+		if err != nil {
+			return err
+		}
+
+		return failure
+	})
+	assert.Equal(t, failure, err)
+
+	all, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 10})
+	require.NoError(t, err)
+	assertMatches(t, created, all)
+}
+
+func testUnitOfWorkPanic(t *testing.T, repo repository.Repository) {
+
+	assert.Panics(t, func() {
+		_ = repo.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, _, err := repo.UpdateMatchDataInDB(ctx, matches[0])
+			require.NoError(t, err)
+			panic("failure")
+		})
+	})
+
+	all, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+// testNestedUnitOfWork checks the contract of all backends, a nested unit of work is rolled back with the outer one
+func testNestedUnitOfWork(t *testing.T, repo repository.Repository) {
+
+	failure := errors.New("failure")
+	err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, _, err := repo.UpdateMatchDataInDB(ctx, matches[0])
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		return failure
+	})
+	assert.Equal(t, failure, err)
+
+	all, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, all)
+}
+
+// RunSavepoints runs the tests of nested units of work against the repositories created by newRepository, which have to
+// roll back a failed nested unit of work alone. MongoDB has no savepoints, so they do not apply to it.
+func RunSavepoints(t *testing.T, newRepository func(t *testing.T) repository.Repository) {
+
+	t.Run("failed nested unit of work", func(t *testing.T) {
+		repo := newRepository(t)
+
+		outer := matches[0]
+		failure := errors.New("failure")
+		err := repo.WithinTransaction(ctx, func(ctx context.Context) error {
+			_, id, err := repo.UpdateMatchDataInDB(ctx, outer)
+			require.NoError(t, err)
+			outer.Id = id
+
+			err = repo.WithinTransaction(ctx, func(ctx context.Context) error {
+				_, _, err := repo.UpdateMatchDataInDB(ctx, matches[1])
+				require.NoError(t, err)
+				return failure
+			})
+			assert.Equal(t, failure, err)
+
+			// the outer unit of work continues after the nested one failed
+			_, err = repo.FindMatchDataByIdInDB(ctx, outer.Id, entity.Projection{})
+			return err
+		})
+		require.NoError(t, err)
+
+		all, err := repo.FindAllMatchDataInDB(ctx, entity.Projection{}, entity.Page{Limit: 10})
+		require.NoError(t, err)
+		assertMatches(t, []entity.MatchData{outer}, all)
+	})
+}
@@ -0,0 +1,222 @@
+package repository_test
+
+import (
+	"context"
+	"path/filepath"
+	"sheazuzu/common/src/cache"
+	commondb "sheazuzu/common/src/database"
+	"sheazuzu/sheazuzu/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/repository"
+	"sheazuzu/sheazuzu/src/repository/repositorytest"
+	"testing"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// TestSQLiteRepository runs the MySQL repository against a migrated SQLite database in a temporary file
+func TestSQLiteRepository(t *testing.T) {
+	newRepository := func(t *testing.T) repository.Repository {
+		db, err := database.OpenDB("sqlite3", "file:"+filepath.Join(t.TempDir(), "sheazuzu.db"))
+		require.NoError(t, err)
+		t.Cleanup(func() { db.Close() })
+
+		migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+		require.NoError(t, err)
+		_, err = migrator.Up(context.Background(), 0)
+		require.NoError(t, err)
+
+		return repository.ProvideMySQLRepository(db, true, zap.NewNop().Sugar())
+	}
+
+	repositorytest.Run(t, newRepository)
+	repositorytest.RunSavepoints(t, newRepository)
+}
+
+// TestSQLiteRepository_adoptSchema migrates a database created by AutoMigrate before the migrations were introduced,
+// the table of the matches exists without the index of the pagination
+func TestSQLiteRepository_adoptSchema(t *testing.T) {
+	t.Parallel()
+
+	db, err := database.OpenDB("sqlite3", "file:"+filepath.Join(t.TempDir(), "sheazuzu.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	require.NoError(t, db.Exec(`CREATE TABLE match_data (id integer PRIMARY KEY AUTOINCREMENT, away_team varchar(255),
+		date varchar(255), home_team varchar(255), match_type varchar(255), result varchar(255))`).Error)
+
+	migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	var indexes int
+	require.NoError(t, db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = 'match_data' AND name = 'idx_match_data_date_id'").Row().Scan(&indexes))
+	assert.Equal(t, 1, indexes)
+}
+
+// openMigrated opens a migrated SQLite database in a temporary file
+func openMigrated(t *testing.T, name string) *gorm.DB {
+
+	db, err := database.OpenDB("sqlite3", "file:"+filepath.Join(t.TempDir(), name))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	return db
+}
+
+// TestSQLiteRepository_replicas routes the reads to a second database, which does not replicate the primary, so a read
+// finds the match only on the primary
+func TestSQLiteRepository_replicas(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		window      time.Duration
+		transaction bool
+		// reader is the caller of the read after the write: the "writer", "other" caller or one "without session"
+		reader string
+		found  bool
+	}{
+		"read from the replica":          {reader: "writer", found: false},
+		"within the window":              {window: time.Minute, reader: "writer", found: true},
+		"other caller within the window": {window: time.Minute, reader: "other", found: false},
+		"without session":                {window: time.Minute, reader: "without session", found: false},
+		"within a unit of work":          {transaction: true, reader: "writer", found: true},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			primary, replica := openMigrated(t, "primary.db"), openMigrated(t, "replica.db")
+			router := commondb.NewReplicaRouter(primary, []commondb.Replica{{Endpoint: "replica", DB: replica}}, tc.window, zap.NewNop().Sugar())
+			repo := repository.ProvideMySQLRepository(primary, false, zap.NewNop().Sugar()).WithReplicas(router)
+
+			ctx := commondb.WithSession(context.Background(), time.Time{})
+			found, id := false, 0
+			err := repo.WithinTransaction(ctx, func(txCtx context.Context) error {
+				var err error
+				_, id, err = repo.UpdateMatchDataInDB(txCtx, entity.MatchData{HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01"})
+				if err != nil || !tc.transaction {
+					return err
+				}
+				_, err = repo.FindMatchDataByIdInDB(txCtx, id, entity.Projection{})
+				found = err == nil
+				return nil
+			})
+			require.NoError(t, err)
+
+			if !tc.transaction {
+				readCtx := map[string]context.Context{
+					"writer":          ctx,
+					"other":           commondb.WithSession(context.Background(), time.Time{}),
+					"without session": context.Background(),
+				}[tc.reader]
+				_, err = repo.FindMatchDataByIdInDB(readCtx, id, entity.Projection{})
+				found = err == nil
+				if !found {
+					assert.ErrorIs(t, err, entity.ErrNotFound)
+				}
+			}
+			assert.Equal(t, tc.found, found)
+		})
+	}
+}
+
+// TestSQLiteRepository_upsertAdditionalInformation updates a match without the id of its AdditionalInformation, which
+// has to update the stored row instead of inserting a second one
+func TestSQLiteRepository_upsertAdditionalInformation(t *testing.T) {
+	t.Parallel()
+
+	db, err := database.OpenDB("sqlite3", "file:"+filepath.Join(t.TempDir(), "sheazuzu.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	repo := repository.ProvideMySQLRepository(db, false, zap.NewNop().Sugar())
+	ctx := context.Background()
+
+	data := entity.MatchData{HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01"}
+	data.AdditionalInformation.Information = "derby"
+	_, id, err := repo.UpdateMatchDataInDB(ctx, data)
+	require.NoError(t, err)
+	stored, err := repo.FindMatchDataByIdInDB(ctx, id, entity.Projection{})
+	require.NoError(t, err)
+
+	for _, byId := range []bool{true, false} {
+		update := data
+		if byId {
+			update.Id = id
+		}
+		update.Result = "2:0"
+		update.AdditionalInformation.Information = "north derby"
+
+		results, err := repo.UpsertMatchDataBatchInDB(ctx, []entity.MatchData{update}, true)
+		require.NoError(t, err)
+		require.NoError(t, results[0].Err)
+		assert.False(t, results[0].Created)
+	}
+
+	var rows int
+	require.NoError(t, db.Model(&entity.AdditionalInformation{}).Count(&rows).Error)
+	assert.Equal(t, 1, rows)
+
+	updated, err := repo.FindMatchDataByIdInDB(ctx, id, entity.Projection{})
+	require.NoError(t, err)
+	assert.Equal(t, "north derby", updated.AdditionalInformation.Information)
+	assert.Equal(t, stored.AdditionalInformation.ID, updated.AdditionalInformation.ID)
+	assert.Equal(t, stored.AdditionalInformation.CreatedAt.Unix(), updated.AdditionalInformation.CreatedAt.Unix())
+}
+
+// TestSQLiteRepository_cachedReplicas keeps the replica at the match before the write, the cache must be filled from
+// the primary nevertheless
+func TestSQLiteRepository_cachedReplicas(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	match := entity.MatchData{HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01", Result: "2:0"}
+
+	primary, replica := openMigrated(t, "primary.db"), openMigrated(t, "replica.db")
+	for _, db := range []*gorm.DB{primary, replica} {
+		_, id, err := repository.ProvideMySQLRepository(db, false, zap.NewNop().Sugar()).UpdateMatchDataInDB(ctx, match)
+		require.NoError(t, err)
+		match.Id = id
+	}
+
+	router := commondb.NewReplicaRouter(primary, []commondb.Replica{{Endpoint: "replica", DB: replica}}, time.Minute, zap.NewNop().Sugar())
+	mysql := repository.ProvideMySQLRepository(primary, false, zap.NewNop().Sugar()).WithReplicas(router)
+	cached := repository.ProvideCachedRepository(mysql, cache.NewLRU(10, time.Minute), time.Second, zap.NewNop().Sugar())
+
+	changed := match
+	changed.Result = "3:0"
+	writer := commondb.WithSession(ctx, time.Time{})
+	upserted, err := cached.UpsertMatchDataBatchInDB(writer, []entity.MatchData{changed}, true)
+	require.NoError(t, err)
+	require.NoError(t, upserted[0].Err)
+
+	// the other caller loads the match into the cache, the writer gets it from there within its window
+	for _, caller := range []context.Context{commondb.WithSession(ctx, time.Time{}), ctx, writer} {
+		data, err := cached.FindMatchDataByIdInDB(caller, match.Id, entity.Projection{})
+		require.NoError(t, err)
+		assert.Equal(t, "3:0", data.Result)
+	}
+
+	// the replica still has the match before the write
+	data, err := mysql.FindMatchDataByIdInDB(ctx, match.Id, entity.Projection{})
+	require.NoError(t, err)
+	assert.Equal(t, "2:0", data.Result)
+}
@@ -1,47 +1,339 @@
 package service
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"go.uber.org/zap"
 	verrors "sheazuzu/common/src/errors"
+	"sheazuzu/common/src/fieldset"
+	"sheazuzu/common/src/pagination"
+	"sheazuzu/common/src/utils"
 	"sheazuzu/sheazuzu/src/entity"
 	"sheazuzu/sheazuzu/src/generated/sheazuzu"
 	"sheazuzu/sheazuzu/src/mapper"
+	"sheazuzu/sheazuzu/src/prediction"
+	"sheazuzu/sheazuzu/src/repository"
+	"time"
 )
 
 type sheazuzuRepository interface {
-	FindMatchDataByIdInDB(int) (entity.MatchData, error)
-	UpdateMatchDataInDB(data entity.MatchData) (string, int, error)
+	repository.Transactor
+	FindMatchDataByIdInDB(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error)
+	FindAllMatchDataInDB(ctx context.Context, projection entity.Projection, page entity.Page) ([]entity.MatchData, error)
+	UpdateMatchDataInDB(ctx context.Context, data entity.MatchData) (string, int, error)
+	FindStatisticsInDB(ctx context.Context) (entity.Statistics, error)
+	FindPlayedMatchDataInDB(ctx context.Context) ([]entity.MatchData, error)
+	UpsertMatchDataBatchInDB(ctx context.Context, data []entity.MatchData, allOrNothing bool) ([]entity.UpsertResult, error)
 }
 
 type Service struct {
-	atbRepository sheazuzuRepository
-	logger        *zap.SugaredLogger
+	atbRepository    sheazuzuRepository
+	predictionConfig prediction.Config
+	logger           *zap.SugaredLogger
 }
 
-func ProvideSheazuzuService(sheazuzuRepository sheazuzuRepository, logger *zap.SugaredLogger) *Service {
+func ProvideSheazuzuService(sheazuzuRepository sheazuzuRepository, predictionConfig prediction.Config, logger *zap.SugaredLogger) *Service {
 	return &Service{
-		atbRepository: sheazuzuRepository,
-		logger:        logger,
+		atbRepository:    sheazuzuRepository,
+		predictionConfig: predictionConfig,
+		logger:           logger,
 	}
 }
 
-func (service *Service) FindMatchDataById(id int) (sheazuzu.MatchData, error) {
+// FindMatchDataById returns the match with the given id, restricted to the given fields. nil fields returns all fields.
+func (service *Service) FindMatchDataById(ctx context.Context, id int, fields []string) (sheazuzu.MatchData, error) {
 	op := verrors.Op("service: Find MatchData by id")
 
-	data, err := service.atbRepository.FindMatchDataByIdInDB(id)
+	data, err := service.atbRepository.FindMatchDataByIdInDB(ctx, id, mapper.FieldsToProjection(fields))
+	if errors.Is(err, entity.ErrNotFound) {
+		return sheazuzu.MatchData{}, verrors.E(op, err, verrors.HttpNotFound, verrors.Info{Name: "id", Val: id})
+	}
 	if err != nil {
 		return sheazuzu.MatchData{}, verrors.E(op, err)
 	}
 
-	return mapper.MatchDataToBo(data), nil
+	result := mapper.MatchDataToBo(data)
+	fieldset.Project(&result, fields)
+
+	return result, nil
 }
 
-func (service *Service) UpdateMatchData(data sheazuzu.MatchData) (string, int, error) {
+// FindAllMatchData returns up to limit matches behind the cursor after, ordered by date and id and restricted to the
+// given fields. nil fields returns all fields, a nil cursor the first page. The returned cursor points behind the last
+// match of the page and is nil on the last page.
+func (service *Service) FindAllMatchData(ctx context.Context, fields []string, after *pagination.Cursor, limit int) ([]sheazuzu.MatchData, *pagination.Cursor, error) {
+	op := verrors.Op("service: Find all MatchData")
+
+	// one more match than requested is loaded to find out, if there is a next page
+	page := entity.Page{Limit: limit + 1}
+	if after != nil {
+		page.After = &entity.PageKey{Date: after.Key, Id: after.Id}
+	}
+
+	data, err := service.atbRepository.FindAllMatchDataInDB(ctx, mapper.FieldsToProjection(fields), page)
+	if err != nil {
+		return nil, nil, verrors.E(op, err)
+	}
+
+	var next *pagination.Cursor
+	if len(data) > limit {
+		data = data[:limit]
+		last := data[limit-1]
+		next = &pagination.Cursor{Key: last.Date, Id: last.Id}
+	}
+
+	result := make([]sheazuzu.MatchData, 0, len(data))
+	for _, matchData := range data {
+		result = append(result, mapper.MatchDataToBo(matchData))
+	}
+	fieldset.Project(&result, fields)
+
+	return result, next, nil
+}
+
+func (service *Service) UpdateMatchData(ctx context.Context, data sheazuzu.MatchData) (string, int, error) {
 	op := verrors.Op("service: Update MatchData")
 
-	msg, id, err := service.atbRepository.UpdateMatchDataInDB(mapper.BoToMatchData(data))
+	msg, id, err := service.atbRepository.UpdateMatchDataInDB(ctx, mapper.BoToMatchData(data))
 	if err != nil {
 		return "", 0, verrors.E(op, err)
 	}
 	return msg, id, nil
 }
+
+// FindStatistics returns the statistics, restricted to the given fields. nil fields returns all fields.
+func (service *Service) FindStatistics(ctx context.Context, fields []string) (sheazuzu.Statistics, error) {
+	op := verrors.Op("service: Find Statistics")
+
+	statistics, err := service.atbRepository.FindStatisticsInDB(ctx)
+	if err != nil {
+		return sheazuzu.Statistics{}, verrors.E(op, err)
+	}
+
+	result := mapper.StatisticsToBo(statistics)
+	fieldset.Project(&result, fields)
+
+	return result, nil
+}
+
+// PredictMatch predicts the outcome of the match with the given id from all results played before its date.
+// The prediction is restricted to the given fields, nil fields returns all fields.
+// The match and the results are read in one unit of work, so the prediction is based on one state of the matches.
+func (service *Service) PredictMatch(ctx context.Context, id int, fields []string) (sheazuzu.Prediction, error) {
+	op := verrors.Op("service: Predict Match")
+	info := verrors.Info{Name: "id", Val: id}
+
+	var data entity.MatchData
+	var date time.Time
+	var results []prediction.Result
+
+	err := service.atbRepository.WithinTransaction(ctx, func(ctx context.Context) error {
+
+		// the date and teams are needed for the prediction, independent of the requested fields
+		var err error
+		data, err = service.atbRepository.FindMatchDataByIdInDB(ctx, id, entity.Projection{Fields: []string{"AwayTeam", "Date", "HomeTeam", "Id"}})
+		if errors.Is(err, entity.ErrNotFound) {
+			return verrors.E(op, info, err, verrors.HttpNotFound)
+		}
+		if err != nil {
+			return verrors.E(op, info, err)
+		}
+
+		var ok bool
+		date, ok = prediction.ParseDate(data.Date)
+		if !ok {
+			return verrors.E(op, info, verrors.InputError, "the match has no valid date (expected "+prediction.DateLayout+")")
+		}
+
+		results, err = service.findPlayedResults(ctx)
+		if err != nil {
+			return verrors.E(op, info, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return sheazuzu.Prediction{}, err
+	}
+
+	model := prediction.Fit(service.predictionConfig, results, date)
+
+	result := mapper.PredictionToBo(data, model.Predict(data.HomeTeam, data.AwayTeam))
+	fieldset.Project(&result, fields)
+
+	return result, nil
+}
+
+// Backtest predicts every played match from the results before it and reports how well the predictions match the outcomes.
+func (service *Service) Backtest(ctx context.Context) (prediction.BacktestReport, error) {
+	op := verrors.Op("service: Backtest Predictions")
+
+	results, err := service.findPlayedResults(ctx)
+	if err != nil {
+		return prediction.BacktestReport{}, verrors.E(op, err)
+	}
+
+	return prediction.Backtest(service.predictionConfig, results, service.predictionConfig.BacktestMinHistory), nil
+}
+
+func (service *Service) findPlayedResults(ctx context.Context) ([]prediction.Result, error) {
+
+	played, err := service.atbRepository.FindPlayedMatchDataInDB(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]prediction.Result, 0, len(played))
+	for _, data := range played {
+		result, ok := prediction.ParseResult(data)
+		if !ok {
+			service.logger.Debugw("ignoring match without valid date or result", "id", data.Id, "date", data.Date, "result", data.Result)
+			continue
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+const (
+	BatchModeAllOrNothing = "all_or_nothing"
+	BatchModeBestEffort   = "best_effort"
+
+	batchStatusCreated    = "created"
+	batchStatusUpdated    = "updated"
+	batchStatusFailed     = "failed"
+	batchStatusRolledBack = "rolled_back"
+	batchStatusSkipped    = "skipped"
+)
+
+// UpsertMatchDataBatch creates or updates all matches and reports the result of every match in the order of the request.
+// In all-or-nothing mode nothing is stored, if a single match is invalid or fails.
+func (service *Service) UpsertMatchDataBatch(ctx context.Context, data []sheazuzu.MatchData, mode string) (sheazuzu.BatchResponse, error) {
+	op := verrors.Op("service: Upsert MatchData Batch")
+
+	if mode == "" {
+		mode = BatchModeAllOrNothing
+	}
+	if mode != BatchModeAllOrNothing && mode != BatchModeBestEffort {
+		return sheazuzu.BatchResponse{}, verrors.E(op, verrors.InputError, fmt.Sprintf("unknown batch mode '%s'", mode))
+	}
+	allOrNothing := mode == BatchModeAllOrNothing
+
+	results := make([]sheazuzu.BatchItemResult, len(data))
+	var valid []entity.MatchData
+	var validIndexes []int
+	invalid := false
+
+	for i, item := range data {
+		results[i] = sheazuzu.BatchItemResult{Index: utils.ToIntPtr(i), Id: item.Id}
+
+		err := validateBatchItem(item)
+		if err != nil {
+			invalid = true
+			setBatchItemError(&results[i], verrors.E(op, verrors.InputError, verrors.Info{Name: "index", Val: i}, err), err.Error())
+			continue
+		}
+
+		valid = append(valid, mapper.BoToMatchData(item))
+		validIndexes = append(validIndexes, i)
+	}
+
+	if invalid && allOrNothing {
+		for _, i := range validIndexes {
+			results[i].Status = utils.ToStringPtr(batchStatusSkipped)
+			results[i].Message = utils.ToStringPtr("not stored, because other matches of the batch are invalid")
+		}
+		return batchResponse(mode, results), nil
+	}
+
+	upserted, err := service.atbRepository.UpsertMatchDataBatchInDB(ctx, valid, allOrNothing)
+	if err != nil {
+		return sheazuzu.BatchResponse{}, verrors.E(op, verrors.DatabaseError, err)
+	}
+
+	for k, result := range upserted {
+		item := &results[validIndexes[k]]
+
+		switch {
+		case result.Err != nil:
+			err := verrors.E(op, verrors.DatabaseError, verrors.Info{Name: "index", Val: validIndexes[k]}, result.Err)
+			service.logger.Warnw("error storing a match of the batch", "index", validIndexes[k], "error", err)
+			setBatchItemError(item, err, "")
+		case !result.Attempted:
+			item.Status = utils.ToStringPtr(batchStatusSkipped)
+			item.Message = utils.ToStringPtr("not stored, because an earlier match of the batch failed")
+		case result.RolledBack:
+			item.Status = utils.ToStringPtr(batchStatusRolledBack)
+			item.Message = utils.ToStringPtr("rolled back, because another match of the batch failed")
+		case result.Created:
+			item.Id = utils.ToIntPtr(result.Id)
+			item.Status = utils.ToStringPtr(batchStatusCreated)
+		default:
+			item.Id = utils.ToIntPtr(result.Id)
+			item.Status = utils.ToStringPtr(batchStatusUpdated)
+		}
+	}
+
+	return batchResponse(mode, results), nil
+}
+
+// a match is identified by its id or by its teams and date, so these have to be set to upsert it
+func validateBatchItem(item sheazuzu.MatchData) error {
+
+	if utils.ToString(item.HomeTeam) == "" || utils.ToString(item.AwayTeam) == "" {
+		return fmt.Errorf("home_team and away_team are required")
+	}
+
+	if item.Id == nil && utils.ToString(item.Date) == "" {
+		return fmt.Errorf("date is required for matches without id")
+	}
+
+	return nil
+}
+
+// setBatchItemError marks the match as failed. The reason is added to the message and must not contain internal details.
+func setBatchItemError(item *sheazuzu.BatchItemResult, err error, reason string) {
+
+	message := errorMessage(err)
+	if reason != "" {
+		message += ": " + reason
+	}
+
+	item.Status = utils.ToStringPtr(batchStatusFailed)
+	item.Code = utils.ToInt32Ptr(verrors.GetErrorCode(err))
+	item.Message = utils.ToStringPtr(message)
+}
+
+// errorMessage returns a fixed message for the kind of the error. The causes may contain details of the database, so
+// they are only logged.
+func errorMessage(err error) string {
+	switch {
+	case verrors.Is(err, verrors.InputError):
+		return "the match is invalid"
+	case verrors.Is(err, verrors.DatabaseError):
+		return "the match could not be stored"
+	default:
+		return "the match could not be processed"
+	}
+}
+
+func batchResponse(mode string, results []sheazuzu.BatchItemResult) sheazuzu.BatchResponse {
+
+	succeeded, failed := 0, 0
+	for _, result := range results {
+		status := utils.ToString(result.Status)
+		if status == batchStatusCreated || status == batchStatusUpdated {
+			succeeded++
+		} else {
+			failed++
+		}
+	}
+
+	return sheazuzu.BatchResponse{
+		Mode:      utils.ToStringPtr(mode),
+		Succeeded: utils.ToIntPtr(succeeded),
+		Failed:    utils.ToIntPtr(failed),
+		Results:   &results,
+	}
+}
@@ -0,0 +1,226 @@
+package service_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sheazuzu/common/src/utils"
+	"sheazuzu/sheazuzu/src/database"
+	"sheazuzu/sheazuzu/src/entity"
+	"sheazuzu/sheazuzu/src/generated/sheazuzu"
+	"sheazuzu/sheazuzu/src/prediction"
+	"sheazuzu/sheazuzu/src/repository"
+	"sheazuzu/sheazuzu/src/service"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// newService returns a service on a migrated SQLite database, which rejects matches of the home team "Broken" with a
+// database error
+func newService(t *testing.T) (*service.Service, *repository.MySQLRepository) {
+
+	db, err := database.OpenDB("sqlite3", "file:"+filepath.Join(t.TempDir(), "sheazuzu.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := database.NewMigrator(db, zap.NewNop().Sugar())
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background(), 0)
+	require.NoError(t, err)
+
+	require.NoError(t, db.Exec(`CREATE TRIGGER reject_broken BEFORE INSERT ON match_data WHEN NEW.home_team = 'Broken'
+		BEGIN SELECT RAISE(ABORT, 'constraint failed: secret details of the database'); END`).Error)
+
+	repo := repository.ProvideMySQLRepository(db, false, zap.NewNop().Sugar())
+	return service.ProvideSheazuzuService(repo, prediction.Config{MaxGoals: 10, Iterations: 100}, zap.NewNop().Sugar()), repo
+}
+
+func match(homeTeam, awayTeam, date string) sheazuzu.MatchData {
+	return sheazuzu.MatchData{HomeTeam: utils.ToStringPtrOrNil(homeTeam), AwayTeam: utils.ToStringPtrOrNil(awayTeam), Date: utils.ToStringPtrOrNil(date)}
+}
+
+func TestUpsertMatchDataBatch(t *testing.T) {
+	t.Parallel()
+
+	valid := match("Bremen", "Hamburg", "2021-03-01")
+	other := match("Mainz", "Köln", "2021-04-01")
+	broken := match("Broken", "Hamburg", "2021-05-01")
+	invalid := match("Bremen", "", "2021-06-01")
+
+	cases := map[string]struct {
+		data     []sheazuzu.MatchData
+		mode     string
+		statuses []string
+		messages []string
+		stored   int
+	}{
+		"all or nothing": {
+			data:     []sheazuzu.MatchData{valid, other},
+			statuses: []string{"created", "created"},
+			messages: []string{"", ""},
+			stored:   2,
+		},
+		"all or nothing with an invalid match": {
+			data:     []sheazuzu.MatchData{valid, invalid},
+			mode:     service.BatchModeAllOrNothing,
+			statuses: []string{"skipped", "failed"},
+			messages: []string{"not stored, because other matches of the batch are invalid", "the match is invalid: home_team and away_team are required"},
+		},
+		"all or nothing rolled back": {
+			data:     []sheazuzu.MatchData{valid, broken, other},
+			statuses: []string{"rolled_back", "failed", "skipped"},
+			messages: []string{"rolled back, because another match of the batch failed", "the match could not be stored", "not stored, because an earlier match of the batch failed"},
+		},
+		"best effort": {
+			data:     []sheazuzu.MatchData{valid, broken, invalid, other},
+			mode:     service.BatchModeBestEffort,
+			statuses: []string{"created", "failed", "failed", "created"},
+			messages: []string{"", "the match could not be stored", "the match is invalid: home_team and away_team are required", ""},
+			stored:   2,
+		},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc, repo := newService(t)
+
+			response, err := svc.UpsertMatchDataBatch(context.Background(), tc.data, tc.mode)
+			require.NoError(t, err)
+			require.Len(t, *response.Results, len(tc.data))
+
+			for i, result := range *response.Results {
+				assert.Equal(t, i, utils.ToInt(result.Index))
+				assert.Equal(t, tc.statuses[i], utils.ToString(result.Status), "status of %d", i)
+				assert.Equal(t, tc.messages[i], utils.ToString(result.Message), "message of %d", i)
+				assert.Equal(t, result.Status != nil && *result.Status == "failed", result.Code != nil, "code of %d", i)
+			}
+			assert.Equal(t, tc.stored, utils.ToInt(response.Succeeded))
+			assert.Equal(t, len(tc.data)-tc.stored, utils.ToInt(response.Failed))
+
+			stored, err := repo.FindAllMatchDataInDB(context.Background(), entity.Projection{}, entity.Page{Limit: 10})
+			require.NoError(t, err)
+			assert.Len(t, stored, tc.stored)
+		})
+	}
+}
+
+func TestUpsertMatchDataBatch_validation(t *testing.T) {
+	t.Parallel()
+
+	withId := match("Bremen", "Hamburg", "")
+	withId.Id = utils.ToIntPtr(7)
+
+	cases := map[string]struct {
+		data    sheazuzu.MatchData
+		message string
+	}{
+		"valid":                {data: match("Bremen", "Hamburg", "2021-03-01")},
+		"id without date":      {data: withId},
+		"no home team":         {data: match("", "Hamburg", "2021-03-01"), message: "the match is invalid: home_team and away_team are required"},
+		"no away team":         {data: match("Bremen", "", "2021-03-01"), message: "the match is invalid: home_team and away_team are required"},
+		"no date and no id":    {data: match("Bremen", "Hamburg", ""), message: "the match is invalid: date is required for matches without id"},
+		"no teams and no date": {data: sheazuzu.MatchData{}, message: "the match is invalid: home_team and away_team are required"},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			svc, _ := newService(t)
+
+			response, err := svc.UpsertMatchDataBatch(context.Background(), []sheazuzu.MatchData{tc.data}, service.BatchModeBestEffort)
+			require.NoError(t, err)
+
+			result := (*response.Results)[0]
+			assert.Equal(t, tc.message, utils.ToString(result.Message))
+			assert.Equal(t, tc.message != "", utils.ToString(result.Status) == "failed")
+		})
+	}
+}
+
+func TestUpsertMatchDataBatch_unknownMode(t *testing.T) {
+	t.Parallel()
+
+	svc, _ := newService(t)
+
+	_, err := svc.UpsertMatchDataBatch(context.Background(), []sheazuzu.MatchData{match("Bremen", "Hamburg", "2021-03-01")}, "some")
+	assert.ErrorContains(t, err, "unknown batch mode 'some'")
+}
+
+type unitOfWorkKey struct{}
+
+// transactionRepository records the outcomes of the units of work and if the reads took part in one. With failPlayed the
+// history read fails.
+type transactionRepository struct {
+	*repository.MySQLRepository
+	failPlayed bool
+	outcomes   []string
+	reads      []bool
+}
+
+func (repo *transactionRepository) WithinTransaction(ctx context.Context, f func(ctx context.Context) error) error {
+
+	err := repo.MySQLRepository.WithinTransaction(context.WithValue(ctx, unitOfWorkKey{}, true), f)
+	if err != nil {
+		repo.outcomes = append(repo.outcomes, "rolled back")
+	} else {
+		repo.outcomes = append(repo.outcomes, "committed")
+	}
+	return err
+}
+
+func (repo *transactionRepository) FindMatchDataByIdInDB(ctx context.Context, id int, projection entity.Projection) (entity.MatchData, error) {
+	repo.reads = append(repo.reads, ctx.Value(unitOfWorkKey{}) != nil)
+	return repo.MySQLRepository.FindMatchDataByIdInDB(ctx, id, projection)
+}
+
+func (repo *transactionRepository) FindPlayedMatchDataInDB(ctx context.Context) ([]entity.MatchData, error) {
+	repo.reads = append(repo.reads, ctx.Value(unitOfWorkKey{}) != nil)
+	if repo.failPlayed {
+		return nil, errors.New("connection lost")
+	}
+	return repo.MySQLRepository.FindPlayedMatchDataInDB(ctx)
+}
+
+func TestPredictMatch_unitOfWork(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		failPlayed bool
+		outcomes   []string
+	}{
+		"committed":   {outcomes: []string{"committed"}},
+		"rolled back": {failPlayed: true, outcomes: []string{"rolled back"}},
+	}
+
+	for name, tc := range cases {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			_, mysql := newService(t)
+			_, id, err := mysql.UpdateMatchDataInDB(context.Background(), entity.MatchData{HomeTeam: "Bremen", AwayTeam: "Hamburg", Date: "2021-03-01"})
+			require.NoError(t, err)
+
+			repo := &transactionRepository{MySQLRepository: mysql, failPlayed: tc.failPlayed}
+			svc := service.ProvideSheazuzuService(repo, prediction.Config{MaxGoals: 10, Iterations: 100}, zap.NewNop().Sugar())
+
+			_, err = svc.PredictMatch(context.Background(), id, nil)
+			assert.Equal(t, tc.failPlayed, err != nil)
+			assert.Equal(t, tc.outcomes, repo.outcomes)
+			// both reads take part in the unit of work
+			assert.Equal(t, []bool{true, true}, repo.reads)
+
+			// the transaction is done, otherwise the single connection of SQLite would still be taken
+			_, _, err = mysql.UpdateMatchDataInDB(context.Background(), entity.MatchData{HomeTeam: "Mainz", AwayTeam: "Köln", Date: "2021-03-02"})
+			assert.NoError(t, err)
+		})
+	}
+}